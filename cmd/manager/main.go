@@ -8,30 +8,70 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/controller"
 	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/chunking"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/compression"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/signing"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/spool"
 	lhSyncService "github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/sync-service"
 	"github.com/operator-framework/operator-sdk/pkg/log/zap"
 	sdkVersion "github.com/operator-framework/operator-sdk/version"
 	"github.com/spf13/pflag"
 
+	// Import all transports, each transport registers itself in the transport registry
+	_ "github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/fanout"
+	_ "github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/filesystem"
+	_ "github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/grpc"
+	_ "github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/http"
+	_ "github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/kafka"
+	_ "github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/mqtt"
+	_ "github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/nats"
+
+	// Import the middlewares that are not imported above, each middleware registers itself in the middleware registry
+	_ "github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/chaos"
+	_ "github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/observability"
+
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
 const (
-	metricsHost                     = "0.0.0.0"
-	metricsPort               int32 = 8527
-	envVarSyncInterval              = "PERIODIC_SYNC_INTERVAL"
-	envVarLeafHubName               = "LH_ID"
-	envVarControllerNamespace       = "POD_NAMESPACE"
-	leaderElectionLockName          = "leaf-hub-status-sync-lock"
+	metricsHost                      = "0.0.0.0"
+	metricsPort                int32 = 8527
+	envVarSyncInterval               = "PERIODIC_SYNC_INTERVAL"
+	envVarLeafHubName                = "LH_ID"
+	envVarControllerNamespace        = "POD_NAMESPACE"
+	envVarTransportType              = "TRANSPORT_TYPE"
+	envVarTransportMiddlewares       = "TRANSPORT_MIDDLEWARES"
+	envVarBundleEncoding             = "BUNDLE_ENCODING"
+	defaultBundleEncoding            = "json"
+	envVarBundleCodec                = "BUNDLE_CODEC"
+	defaultBundleCodec               = "json"
+	leaderElectionLockName           = "leaf-hub-status-sync-lock"
 )
 
+var transportType = pflag.String("transport-type", "",
+	fmt.Sprintf("the transport to use, overrides the %s environment variable (default %s)", envVarTransportType,
+		lhSyncService.TransportType))
+
+// defaultTransportMiddlewares are used when the middlewares are not configured explicitly, in order. each one is
+// enabled by the environment variable of its own configuration.
+var defaultTransportMiddlewares = []struct {
+	name            string
+	enabledByEnvVar string
+}{
+	{name: compression.MiddlewareName, enabledByEnvVar: compression.EnvVarBundleCompression}, // before signing
+	{name: signing.MiddlewareName, enabledByEnvVar: signing.EnvVarBundleSigningKeyPath},      // before spooling
+	{name: spool.MiddlewareName, enabledByEnvVar: spool.EnvVarSpoolDir},                      // before chunking
+	{name: chunking.MiddlewareName, enabledByEnvVar: chunking.EnvVarBundleMaxChunkSize},
+}
+
 func printVersion(log logr.Logger) {
 	log.Info(fmt.Sprintf("Go Version: %s", runtime.Version()))
 	log.Info(fmt.Sprintf("Go OS/Arch: %s/%s", runtime.GOOS, runtime.GOARCH))
@@ -72,22 +112,39 @@ func doMain() int {
 		return 1
 	}
 
+	bundleEncoding, found := os.LookupEnv(envVarBundleEncoding)
+	if !found {
+		bundleEncoding = defaultBundleEncoding
+	}
+
+	bundleCodec, found := os.LookupEnv(envVarBundleCodec)
+	if !found {
+		bundleCodec = defaultBundleCodec
+	}
+
 	// transport layer initialization
-	syncService, err := lhSyncService.NewSyncService(ctrl.Log.WithName("sync-service"))
+	selectedTransportType := getTransportType()
+
+	transportObj, err := transport.NewService(selectedTransportType, ctrl.Log.WithName(selectedTransportType))
 	if err != nil {
 		log.Error(err, "failed to initialize")
 		return 1
 	}
 
-	syncService.Start()
-	defer syncService.Stop()
+	transportObj.Start()
+	defer transportObj.Stop()
 
-	mgr, err := createManager(leaderElectionNamespace, metricsHost, metricsPort, syncService, syncInterval, leafHubName)
+	mgr, transportChain, err := createManager(leaderElectionNamespace, metricsHost, metricsPort, transportObj,
+		syncInterval, leafHubName, bundleEncoding, bundleCodec)
 	if err != nil {
 		log.Error(err, "Failed to create manager")
 		return 1
 	}
 
+	// the middlewares are stopped before the transport they wrap
+	transportChain.Start()
+	defer transportChain.Stop()
+
 	log.Info("Starting the Cmd.")
 
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
@@ -98,8 +155,48 @@ func doMain() int {
 	return 0
 }
 
-func createManager(leaderElectionNamespace, metricsHost string, metricsPort int32, transport transport.Transport,
-	syncInterval time.Duration, leafHubName string) (ctrl.Manager, error) {
+// getTransportType returns the transport type from the flag, the environment variable or the default, in that order.
+func getTransportType() string {
+	if *transportType != "" {
+		return *transportType
+	}
+
+	if transportTypeFromEnv, found := os.LookupEnv(envVarTransportType); found {
+		return transportTypeFromEnv
+	}
+
+	return lhSyncService.TransportType
+}
+
+// getTransportMiddlewares returns the names of the transport middlewares, the first one sees the messages first.
+// if the environment variable is not set, the default middlewares that are enabled by their own configuration are used.
+func getTransportMiddlewares() []string {
+	if middlewaresFromEnv, found := os.LookupEnv(envVarTransportMiddlewares); found {
+		middlewares := make([]string, 0)
+
+		for _, name := range strings.Split(middlewaresFromEnv, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				middlewares = append(middlewares, name)
+			}
+		}
+
+		return middlewares
+	}
+
+	middlewares := make([]string, 0, len(defaultTransportMiddlewares))
+
+	for _, middleware := range defaultTransportMiddlewares {
+		if _, found := os.LookupEnv(middleware.enabledByEnvVar); found {
+			middlewares = append(middlewares, middleware.name)
+		}
+	}
+
+	return middlewares
+}
+
+func createManager(leaderElectionNamespace, metricsHost string, metricsPort int32, transportObj transport.Transport,
+	syncInterval time.Duration, leafHubName string, bundleEncoding string, bundleCodec string) (ctrl.Manager,
+	*transport.Chain, error) {
 	options := ctrl.Options{
 		MetricsBindAddress:      fmt.Sprintf("%s:%d", metricsHost, metricsPort),
 		LeaderElection:          true,
@@ -107,20 +204,27 @@ func createManager(leaderElectionNamespace, metricsHost string, metricsPort int3
 		LeaderElectionNamespace: leaderElectionNamespace,
 	}
 
+	transportChain, err := transport.NewChain(transportObj, getTransportMiddlewares(),
+		ctrl.Log.WithName("transport-middleware"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create transport middlewares: %w", err)
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), options)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create a new manager: %w", err)
+		return nil, nil, fmt.Errorf("failed to create a new manager: %w", err)
 	}
 
 	if err := controller.AddToScheme(mgr.GetScheme()); err != nil {
-		return nil, fmt.Errorf("failed to add schemes: %w", err)
+		return nil, nil, fmt.Errorf("failed to add schemes: %w", err)
 	}
 
-	if err := controller.AddControllers(mgr, transport, syncInterval, leafHubName); err != nil {
-		return nil, fmt.Errorf("failed to add controllers: %w", err)
+	if err := controller.AddControllers(mgr, transportChain, syncInterval, leafHubName, bundleEncoding,
+		bundleCodec); err != nil {
+		return nil, nil, fmt.Errorf("failed to add controllers: %w", err)
 	}
 
-	return mgr, nil
+	return mgr, transportChain, nil
 }
 
 func main() {
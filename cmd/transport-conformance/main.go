@@ -0,0 +1,106 @@
+// Copyright (c) 2021 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	logrTesting "github.com/go-logr/logr/testing"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/conformance"
+	lhSyncService "github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/sync-service"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/sync-service/fakeess"
+	"github.com/operator-framework/operator-sdk/pkg/log/zap"
+	"github.com/spf13/pflag"
+
+	// Import all transports, each transport registers itself in the transport registry
+	_ "github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/fanout"
+	_ "github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/filesystem"
+	_ "github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/grpc"
+	_ "github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/http"
+	_ "github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/kafka"
+	_ "github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/mqtt"
+	_ "github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/nats"
+)
+
+// the environment variables of the sync service transport, set to point at the fake Edge Sync Service.
+const (
+	envVarSyncServiceProtocol = "SYNC_SERVICE_PROTOCOL"
+	envVarSyncServiceHost     = "SYNC_SERVICE_HOST"
+	envVarSyncServicePort     = "SYNC_SERVICE_PORT"
+)
+
+var (
+	transportType = pflag.String("transport-type", lhSyncService.TransportType,
+		"the transport to check, configured using its environment variables")
+	useFakeESS = pflag.Bool("fake-ess", true,
+		"check the sync service transport against an in-memory fake Edge Sync Service")
+	verbose = pflag.Bool("verbose", false, "log the messages of the checked transport")
+	options = conformance.Options{}
+)
+
+// stdoutT reports the results of the checks to stdout.
+type stdoutT struct{}
+
+func (t *stdoutT) Helper() {}
+
+func (t *stdoutT) Logf(format string, args ...interface{}) {
+	fmt.Printf(format+"\n", args...)
+}
+
+func (t *stdoutT) Errorf(format string, args ...interface{}) {
+	fmt.Printf("    "+format+"\n", args...)
+}
+
+func doMain() int {
+	pflag.DurationVar(&options.DeliveryTimeout, "delivery-timeout", 0,
+		"how long to wait for the delivery result of a message (default 30s)")
+	pflag.IntVar(&options.Senders, "senders", 0, "the number of concurrent senders (default 10)")
+	pflag.IntVar(&options.VersionsPerSender, "versions", 0, "the number of versions each sender sends (default 10)")
+	pflag.CommandLine.AddFlagSet(zap.FlagSet())
+	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
+	pflag.Parse()
+
+	var log logr.Logger = logrTesting.NullLogger{}
+	if *verbose {
+		log = zap.Logger()
+	}
+
+	if *transportType == lhSyncService.TransportType && *useFakeESS {
+		fakeESS := fakeess.NewServer()
+		defer fakeESS.Close()
+
+		for envVar, value := range map[string]string{
+			envVarSyncServiceProtocol: fakeESS.Protocol(),
+			envVarSyncServiceHost:     fakeESS.Host(),
+			envVarSyncServicePort:     strconv.Itoa(int(fakeESS.Port())),
+		} {
+			if err := os.Setenv(envVar, value); err != nil {
+				fmt.Printf("failed to set %s - %v\n", envVar, err)
+				return 1
+			}
+		}
+	}
+
+	passed := conformance.Run(&stdoutT{}, func() (transport.Transport, error) {
+		return transport.NewService(*transportType, log.WithName(*transportType))
+	}, &options)
+
+	if !passed {
+		fmt.Printf("FAIL %s\n", *transportType)
+		return 1
+	}
+
+	fmt.Printf("PASS %s\n", *transportType)
+
+	return 0
+}
+
+func main() {
+	os.Exit(doMain())
+}
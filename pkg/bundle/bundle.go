@@ -23,4 +23,6 @@ type Bundle interface {
 	DeleteObject(object Object)
 	// GetBundleGeneration function to get bundle generation.
 	GetBundleGeneration() uint64
+	// IncrementGeneration function to increment bundle generation without changing its content, so it's sent again.
+	IncrementGeneration()
 }
@@ -91,6 +91,14 @@ func (bundle *ClustersPerPolicyBundle) GetBundleGeneration() uint64 {
 	return bundle.Generation
 }
 
+// IncrementGeneration function to increment bundle generation without changing its content.
+func (bundle *ClustersPerPolicyBundle) IncrementGeneration() {
+	bundle.lock.Lock()
+	defer bundle.lock.Unlock()
+
+	bundle.Generation++
+}
+
 func (bundle *ClustersPerPolicyBundle) getObjectIndexByUID(uid string) (int, error) {
 	for i, object := range bundle.Objects {
 		if object.PolicyID == uid {
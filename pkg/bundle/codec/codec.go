@@ -0,0 +1,44 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// JSONName is the name of the json codec.
+	JSONName = "json"
+	// ProtobufName is the name of the protobuf codec.
+	ProtobufName = "protobuf"
+)
+
+var (
+	errUnsupportedCodec = errors.New("unsupported codec")
+	// ErrUnsupportedBundleType is returned by a codec that can't encode or decode bundles of the given type.
+	ErrUnsupportedBundleType = errors.New("unsupported bundle type")
+)
+
+// Codec encodes bundles to message payloads and decodes them back.
+type Codec interface {
+	// GetName returns the name of the codec, which is recorded in the message metadata.
+	GetName() string
+	// GetContentType returns the media type of the encoded payloads.
+	GetContentType() string
+	// Encode encodes the given bundle.
+	Encode(bundle interface{}) ([]byte, error)
+	// Decode decodes the given payload into the bundle that bundle points to.
+	Decode(payload []byte, bundle interface{}) error
+}
+
+// NewCodec returns the codec with the given name.
+func NewCodec(name string) (Codec, error) {
+	switch name {
+	case JSONName:
+		return &jsonCodec{}, nil
+	case ProtobufName:
+		return &protobufCodec{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s (supported codecs: %s, %s)", errUnsupportedCodec, name, JSONName,
+			ProtobufName)
+	}
+}
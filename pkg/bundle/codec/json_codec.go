@@ -0,0 +1,33 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type jsonCodec struct{}
+
+func (codec *jsonCodec) GetName() string {
+	return JSONName
+}
+
+func (codec *jsonCodec) GetContentType() string {
+	return "application/json"
+}
+
+func (codec *jsonCodec) Encode(bundle interface{}) ([]byte, error) {
+	payload, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bundle - %w", err)
+	}
+
+	return payload, nil
+}
+
+func (codec *jsonCodec) Decode(payload []byte, bundle interface{}) error {
+	if err := json.Unmarshal(payload, bundle); err != nil {
+		return fmt.Errorf("failed to decode bundle - %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+package codec
+
+//go:generate protoc --go_out=paths=source_relative:. status_bundles.proto
+
+import (
+	"fmt"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/pkg/apis/policy/v1"
+	statusbundle "github.com/open-cluster-management/hub-of-hubs-data-types/bundle/status"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/bundle"
+	"google.golang.org/protobuf/proto"
+)
+
+// protobufCodec encodes the policy status bundles in the protobuf wire format, according to the schema in
+// status_bundles.proto. the bundles are converted to and from the messages generated from the schema.
+type protobufCodec struct{}
+
+func (codec *protobufCodec) GetName() string {
+	return ProtobufName
+}
+
+func (codec *protobufCodec) GetContentType() string {
+	return "application/x-protobuf"
+}
+
+func (codec *protobufCodec) Encode(statusBundle interface{}) ([]byte, error) {
+	var message proto.Message
+
+	switch typedBundle := statusBundle.(type) {
+	case *bundle.ClustersPerPolicyBundle:
+		message = toClustersPerPolicyMessage(&typedBundle.BaseClustersPerPolicyBundle)
+	case *statusbundle.BaseClustersPerPolicyBundle:
+		message = toClustersPerPolicyMessage(typedBundle)
+	case *bundle.ComplianceStatusBundle:
+		message = toComplianceStatusMessage(&typedBundle.BaseComplianceStatusBundle)
+	case *statusbundle.BaseComplianceStatusBundle:
+		message = toComplianceStatusMessage(typedBundle)
+	case *bundle.MinimalComplianceStatusBundle:
+		message = toMinimalComplianceStatusMessage(&typedBundle.BaseMinimalComplianceStatusBundle)
+	case *statusbundle.BaseMinimalComplianceStatusBundle:
+		message = toMinimalComplianceStatusMessage(typedBundle)
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedBundleType, statusBundle)
+	}
+
+	payload, err := proto.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bundle - %w", err)
+	}
+
+	return payload, nil
+}
+
+func (codec *protobufCodec) Decode(payload []byte, statusBundle interface{}) error {
+	switch typedBundle := statusBundle.(type) {
+	case *bundle.ClustersPerPolicyBundle:
+		return decodeClustersPerPolicyBundle(payload, &typedBundle.BaseClustersPerPolicyBundle)
+	case *statusbundle.BaseClustersPerPolicyBundle:
+		return decodeClustersPerPolicyBundle(payload, typedBundle)
+	case *bundle.ComplianceStatusBundle:
+		return decodeComplianceStatusBundle(payload, &typedBundle.BaseComplianceStatusBundle)
+	case *statusbundle.BaseComplianceStatusBundle:
+		return decodeComplianceStatusBundle(payload, typedBundle)
+	case *bundle.MinimalComplianceStatusBundle:
+		return decodeMinimalComplianceStatusBundle(payload, &typedBundle.BaseMinimalComplianceStatusBundle)
+	case *statusbundle.BaseMinimalComplianceStatusBundle:
+		return decodeMinimalComplianceStatusBundle(payload, typedBundle)
+	default:
+		return fmt.Errorf("%w: %T", ErrUnsupportedBundleType, statusBundle)
+	}
+}
+
+func toClustersPerPolicyMessage(statusBundle *statusbundle.BaseClustersPerPolicyBundle) *ClustersPerPolicyBundle {
+	message := &ClustersPerPolicyBundle{
+		Objects:     make([]*ClustersPerPolicy, 0, len(statusBundle.Objects)),
+		LeafHubName: statusBundle.LeafHubName,
+		Generation:  statusBundle.Generation,
+	}
+
+	for _, object := range statusBundle.Objects {
+		message.Objects = append(message.Objects, &ClustersPerPolicy{
+			PolicyId:          object.PolicyID,
+			Clusters:          object.Clusters,
+			RemediationAction: string(object.RemediationAction),
+			ResourceVersion:   object.ResourceVersion,
+		})
+	}
+
+	return message
+}
+
+func decodeClustersPerPolicyBundle(payload []byte, statusBundle *statusbundle.BaseClustersPerPolicyBundle) error {
+	message := &ClustersPerPolicyBundle{}
+	if err := proto.Unmarshal(payload, message); err != nil {
+		return fmt.Errorf("failed to decode bundle - %w", err)
+	}
+
+	statusBundle.Objects = make([]*statusbundle.ClustersPerPolicy, 0, len(message.Objects))
+	statusBundle.LeafHubName = message.LeafHubName
+	statusBundle.Generation = message.Generation
+
+	for _, object := range message.Objects {
+		statusBundle.Objects = append(statusBundle.Objects, &statusbundle.ClustersPerPolicy{
+			PolicyID:          object.PolicyId,
+			Clusters:          nonNil(object.Clusters),
+			RemediationAction: policiesv1.RemediationAction(object.RemediationAction),
+			ResourceVersion:   object.ResourceVersion,
+		})
+	}
+
+	return nil
+}
+
+func toComplianceStatusMessage(statusBundle *statusbundle.BaseComplianceStatusBundle) *ComplianceStatusBundle {
+	message := &ComplianceStatusBundle{
+		Objects:              make([]*PolicyComplianceStatus, 0, len(statusBundle.Objects)),
+		LeafHubName:          statusBundle.LeafHubName,
+		BaseBundleGeneration: statusBundle.BaseBundleGeneration,
+		Generation:           statusBundle.Generation,
+	}
+
+	for _, object := range statusBundle.Objects {
+		message.Objects = append(message.Objects, &PolicyComplianceStatus{
+			PolicyId:                  object.PolicyID,
+			NonCompliantClusters:      object.NonCompliantClusters,
+			UnknownComplianceClusters: object.UnknownComplianceClusters,
+			ResourceVersion:           object.ResourceVersion,
+		})
+	}
+
+	return message
+}
+
+func decodeComplianceStatusBundle(payload []byte, statusBundle *statusbundle.BaseComplianceStatusBundle) error {
+	message := &ComplianceStatusBundle{}
+	if err := proto.Unmarshal(payload, message); err != nil {
+		return fmt.Errorf("failed to decode bundle - %w", err)
+	}
+
+	statusBundle.Objects = make([]*statusbundle.PolicyComplianceStatus, 0, len(message.Objects))
+	statusBundle.LeafHubName = message.LeafHubName
+	statusBundle.BaseBundleGeneration = message.BaseBundleGeneration
+	statusBundle.Generation = message.Generation
+
+	for _, object := range message.Objects {
+		statusBundle.Objects = append(statusBundle.Objects, &statusbundle.PolicyComplianceStatus{
+			PolicyID:                  object.PolicyId,
+			NonCompliantClusters:      nonNil(object.NonCompliantClusters),
+			UnknownComplianceClusters: nonNil(object.UnknownComplianceClusters),
+			ResourceVersion:           object.ResourceVersion,
+		})
+	}
+
+	return nil
+}
+
+func toMinimalComplianceStatusMessage(
+	statusBundle *statusbundle.BaseMinimalComplianceStatusBundle) *MinimalComplianceStatusBundle {
+	message := &MinimalComplianceStatusBundle{
+		Objects:     make([]*MinimalPolicyComplianceStatus, 0, len(statusBundle.Objects)),
+		LeafHubName: statusBundle.LeafHubName,
+		Generation:  statusBundle.Generation,
+	}
+
+	for _, object := range statusBundle.Objects {
+		message.Objects = append(message.Objects, &MinimalPolicyComplianceStatus{
+			PolicyId:             object.PolicyID,
+			RemediationAction:    string(object.RemediationAction),
+			NonCompliantClusters: int64(object.NonCompliantClusters),
+			AppliedClusters:      int64(object.AppliedClusters),
+		})
+	}
+
+	return message
+}
+
+func decodeMinimalComplianceStatusBundle(payload []byte,
+	statusBundle *statusbundle.BaseMinimalComplianceStatusBundle) error {
+	message := &MinimalComplianceStatusBundle{}
+	if err := proto.Unmarshal(payload, message); err != nil {
+		return fmt.Errorf("failed to decode bundle - %w", err)
+	}
+
+	statusBundle.Objects = make([]*statusbundle.MinimalPolicyComplianceStatus, 0, len(message.Objects))
+	statusBundle.LeafHubName = message.LeafHubName
+	statusBundle.Generation = message.Generation
+
+	for _, object := range message.Objects {
+		statusBundle.Objects = append(statusBundle.Objects, &statusbundle.MinimalPolicyComplianceStatus{
+			PolicyID:             object.PolicyId,
+			RemediationAction:    policiesv1.RemediationAction(object.RemediationAction),
+			NonCompliantClusters: int(object.NonCompliantClusters),
+			AppliedClusters:      int(object.AppliedClusters),
+		})
+	}
+
+	return nil
+}
+
+// nonNil returns an empty slice instead of nil, since the bundles encode missing cluster lists as empty lists in json.
+func nonNil(values []string) []string {
+	if values == nil {
+		return make([]string, 0)
+	}
+
+	return values
+}
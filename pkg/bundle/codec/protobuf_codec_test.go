@@ -0,0 +1,198 @@
+package codec
+
+import (
+	"reflect"
+	"testing"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/pkg/apis/policy/v1"
+	statusbundle "github.com/open-cluster-management/hub-of-hubs-data-types/bundle/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const testLeafHubName = "hub1"
+
+func newClustersPerPolicyBundle() *statusbundle.BaseClustersPerPolicyBundle {
+	return &statusbundle.BaseClustersPerPolicyBundle{
+		Objects: []*statusbundle.ClustersPerPolicy{
+			{
+				PolicyID:          "policy-1",
+				Clusters:          []string{"cluster-1", "cluster-2"},
+				RemediationAction: policiesv1.RemediationAction("inform"),
+				ResourceVersion:   "100",
+			},
+			{
+				PolicyID:          "policy-2",
+				Clusters:          []string{},
+				RemediationAction: policiesv1.RemediationAction("enforce"),
+				ResourceVersion:   "101",
+			},
+		},
+		LeafHubName: testLeafHubName,
+		Generation:  7,
+	}
+}
+
+func newComplianceStatusBundle() *statusbundle.BaseComplianceStatusBundle {
+	return &statusbundle.BaseComplianceStatusBundle{
+		Objects: []*statusbundle.PolicyComplianceStatus{
+			{
+				PolicyID:                  "policy-1",
+				NonCompliantClusters:      []string{"cluster-1"},
+				UnknownComplianceClusters: []string{"cluster-2", "cluster-3"},
+				ResourceVersion:           "100",
+			},
+		},
+		LeafHubName:          testLeafHubName,
+		BaseBundleGeneration: 7,
+		Generation:           9,
+	}
+}
+
+func newMinimalComplianceStatusBundle() *statusbundle.BaseMinimalComplianceStatusBundle {
+	return &statusbundle.BaseMinimalComplianceStatusBundle{
+		Objects: []*statusbundle.MinimalPolicyComplianceStatus{
+			{
+				PolicyID:             "policy-1",
+				RemediationAction:    policiesv1.RemediationAction("inform"),
+				NonCompliantClusters: 3,
+				AppliedClusters:      10,
+			},
+			{
+				PolicyID:             "policy-2",
+				RemediationAction:    policiesv1.RemediationAction("enforce"),
+				NonCompliantClusters: 0,
+				AppliedClusters:      0,
+			},
+		},
+		LeafHubName: testLeafHubName,
+		Generation:  4,
+	}
+}
+
+func TestProtobufCodecRoundTrip(t *testing.T) {
+	codec, err := NewCodec(ProtobufName)
+	if err != nil {
+		t.Fatalf("failed to create the codec: %v", err)
+	}
+
+	for name, test := range map[string]struct {
+		original interface{}
+		decoded  interface{}
+	}{
+		"ClustersPerPolicy": {
+			original: newClustersPerPolicyBundle(),
+			decoded:  &statusbundle.BaseClustersPerPolicyBundle{},
+		},
+		"ComplianceStatus": {
+			original: newComplianceStatusBundle(),
+			decoded:  &statusbundle.BaseComplianceStatusBundle{},
+		},
+		"MinimalComplianceStatus": {
+			original: newMinimalComplianceStatusBundle(),
+			decoded:  &statusbundle.BaseMinimalComplianceStatusBundle{},
+		},
+		"empty": {
+			original: &statusbundle.BaseClustersPerPolicyBundle{Objects: []*statusbundle.ClustersPerPolicy{}},
+			decoded:  &statusbundle.BaseClustersPerPolicyBundle{},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			payload, err := codec.Encode(test.original)
+			if err != nil {
+				t.Fatalf("failed to encode: %v", err)
+			}
+
+			if err := codec.Decode(payload, test.decoded); err != nil {
+				t.Fatalf("failed to decode: %v", err)
+			}
+
+			if !reflect.DeepEqual(test.original, test.decoded) {
+				t.Fatalf("expected %+v, got %+v", test.original, test.decoded)
+			}
+		})
+	}
+}
+
+func TestProtobufCodecRejectsUnsupportedBundleType(t *testing.T) {
+	codec := &protobufCodec{}
+
+	if _, err := codec.Encode(struct{}{}); err == nil {
+		t.Fatal("expected encoding an unsupported bundle type to fail")
+	}
+
+	if err := codec.Decode(nil, &struct{}{}); err == nil {
+		t.Fatal("expected decoding an unsupported bundle type to fail")
+	}
+}
+
+// TestProtobufPayloadDecodesWithGeneratedMessages decodes the encoded bundles into the messages generated from
+// status_bundles.proto, as a hub side consumer with generated decoders does.
+func TestProtobufPayloadDecodesWithGeneratedMessages(t *testing.T) {
+	codec := &protobufCodec{}
+
+	for name, test := range map[string]struct {
+		statusBundle interface{}
+		decoded      proto.Message
+		expected     proto.Message
+	}{
+		"ClustersPerPolicy": {
+			statusBundle: newClustersPerPolicyBundle(),
+			decoded:      &ClustersPerPolicyBundle{},
+			expected: &ClustersPerPolicyBundle{
+				Objects: []*ClustersPerPolicy{
+					{
+						PolicyId:          "policy-1",
+						Clusters:          []string{"cluster-1", "cluster-2"},
+						RemediationAction: "inform",
+						ResourceVersion:   "100",
+					},
+					{PolicyId: "policy-2", RemediationAction: "enforce", ResourceVersion: "101"},
+				},
+				LeafHubName: testLeafHubName,
+				Generation:  7,
+			},
+		},
+		"ComplianceStatus": {
+			statusBundle: newComplianceStatusBundle(),
+			decoded:      &ComplianceStatusBundle{},
+			expected: &ComplianceStatusBundle{
+				Objects: []*PolicyComplianceStatus{{
+					PolicyId:                  "policy-1",
+					NonCompliantClusters:      []string{"cluster-1"},
+					UnknownComplianceClusters: []string{"cluster-2", "cluster-3"},
+					ResourceVersion:           "100",
+				}},
+				LeafHubName:          testLeafHubName,
+				BaseBundleGeneration: 7,
+				Generation:           9,
+			},
+		},
+		"MinimalComplianceStatus": {
+			statusBundle: newMinimalComplianceStatusBundle(),
+			decoded:      &MinimalComplianceStatusBundle{},
+			expected: &MinimalComplianceStatusBundle{
+				Objects: []*MinimalPolicyComplianceStatus{
+					{PolicyId: "policy-1", RemediationAction: "inform", NonCompliantClusters: 3, AppliedClusters: 10},
+					{PolicyId: "policy-2", RemediationAction: "enforce"},
+				},
+				LeafHubName: testLeafHubName,
+				Generation:  4,
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			payload, err := codec.Encode(test.statusBundle)
+			if err != nil {
+				t.Fatalf("failed to encode: %v", err)
+			}
+
+			if err := proto.Unmarshal(payload, test.decoded); err != nil {
+				t.Fatalf("failed to decode with the generated message: %v", err)
+			}
+
+			if !proto.Equal(test.decoded, test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, test.decoded)
+			}
+		})
+	}
+}
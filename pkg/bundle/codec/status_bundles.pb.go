@@ -0,0 +1,656 @@
+// The schema of the policy status bundles, as encoded by the protobuf codec (bundle codec "protobuf").
+// The Go types in status_bundles.pb.go are generated from this file using protoc-gen-go, run go generate after changing
+// it. Hub side consumers can generate their decoders from this file.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.33.0
+// 	protoc        (unknown)
+// source: status_bundles.proto
+
+package codec
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ClustersPerPolicyBundle is the ClustersPerPolicy status bundle.
+type ClustersPerPolicyBundle struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Objects     []*ClustersPerPolicy `protobuf:"bytes,1,rep,name=objects,proto3" json:"objects,omitempty"`
+	LeafHubName string               `protobuf:"bytes,2,opt,name=leaf_hub_name,json=leafHubName,proto3" json:"leaf_hub_name,omitempty"`
+	Generation  uint64               `protobuf:"varint,3,opt,name=generation,proto3" json:"generation,omitempty"`
+}
+
+func (x *ClustersPerPolicyBundle) Reset() {
+	*x = ClustersPerPolicyBundle{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_status_bundles_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ClustersPerPolicyBundle) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClustersPerPolicyBundle) ProtoMessage() {}
+
+func (x *ClustersPerPolicyBundle) ProtoReflect() protoreflect.Message {
+	mi := &file_status_bundles_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClustersPerPolicyBundle.ProtoReflect.Descriptor instead.
+func (*ClustersPerPolicyBundle) Descriptor() ([]byte, []int) {
+	return file_status_bundles_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ClustersPerPolicyBundle) GetObjects() []*ClustersPerPolicy {
+	if x != nil {
+		return x.Objects
+	}
+	return nil
+}
+
+func (x *ClustersPerPolicyBundle) GetLeafHubName() string {
+	if x != nil {
+		return x.LeafHubName
+	}
+	return ""
+}
+
+func (x *ClustersPerPolicyBundle) GetGeneration() uint64 {
+	if x != nil {
+		return x.Generation
+	}
+	return 0
+}
+
+type ClustersPerPolicy struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PolicyId          string   `protobuf:"bytes,1,opt,name=policy_id,json=policyId,proto3" json:"policy_id,omitempty"`
+	Clusters          []string `protobuf:"bytes,2,rep,name=clusters,proto3" json:"clusters,omitempty"`
+	RemediationAction string   `protobuf:"bytes,3,opt,name=remediation_action,json=remediationAction,proto3" json:"remediation_action,omitempty"`
+	ResourceVersion   string   `protobuf:"bytes,4,opt,name=resource_version,json=resourceVersion,proto3" json:"resource_version,omitempty"`
+}
+
+func (x *ClustersPerPolicy) Reset() {
+	*x = ClustersPerPolicy{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_status_bundles_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ClustersPerPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClustersPerPolicy) ProtoMessage() {}
+
+func (x *ClustersPerPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_status_bundles_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClustersPerPolicy.ProtoReflect.Descriptor instead.
+func (*ClustersPerPolicy) Descriptor() ([]byte, []int) {
+	return file_status_bundles_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ClustersPerPolicy) GetPolicyId() string {
+	if x != nil {
+		return x.PolicyId
+	}
+	return ""
+}
+
+func (x *ClustersPerPolicy) GetClusters() []string {
+	if x != nil {
+		return x.Clusters
+	}
+	return nil
+}
+
+func (x *ClustersPerPolicy) GetRemediationAction() string {
+	if x != nil {
+		return x.RemediationAction
+	}
+	return ""
+}
+
+func (x *ClustersPerPolicy) GetResourceVersion() string {
+	if x != nil {
+		return x.ResourceVersion
+	}
+	return ""
+}
+
+// ComplianceStatusBundle is the PolicyCompliance status bundle.
+type ComplianceStatusBundle struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Objects     []*PolicyComplianceStatus `protobuf:"bytes,1,rep,name=objects,proto3" json:"objects,omitempty"`
+	LeafHubName string                    `protobuf:"bytes,2,opt,name=leaf_hub_name,json=leafHubName,proto3" json:"leaf_hub_name,omitempty"`
+	// base_bundle_generation is the generation of the ClustersPerPolicy bundle this bundle is based on.
+	BaseBundleGeneration uint64 `protobuf:"varint,3,opt,name=base_bundle_generation,json=baseBundleGeneration,proto3" json:"base_bundle_generation,omitempty"`
+	Generation           uint64 `protobuf:"varint,4,opt,name=generation,proto3" json:"generation,omitempty"`
+}
+
+func (x *ComplianceStatusBundle) Reset() {
+	*x = ComplianceStatusBundle{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_status_bundles_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ComplianceStatusBundle) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComplianceStatusBundle) ProtoMessage() {}
+
+func (x *ComplianceStatusBundle) ProtoReflect() protoreflect.Message {
+	mi := &file_status_bundles_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComplianceStatusBundle.ProtoReflect.Descriptor instead.
+func (*ComplianceStatusBundle) Descriptor() ([]byte, []int) {
+	return file_status_bundles_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ComplianceStatusBundle) GetObjects() []*PolicyComplianceStatus {
+	if x != nil {
+		return x.Objects
+	}
+	return nil
+}
+
+func (x *ComplianceStatusBundle) GetLeafHubName() string {
+	if x != nil {
+		return x.LeafHubName
+	}
+	return ""
+}
+
+func (x *ComplianceStatusBundle) GetBaseBundleGeneration() uint64 {
+	if x != nil {
+		return x.BaseBundleGeneration
+	}
+	return 0
+}
+
+func (x *ComplianceStatusBundle) GetGeneration() uint64 {
+	if x != nil {
+		return x.Generation
+	}
+	return 0
+}
+
+type PolicyComplianceStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PolicyId                  string   `protobuf:"bytes,1,opt,name=policy_id,json=policyId,proto3" json:"policy_id,omitempty"`
+	NonCompliantClusters      []string `protobuf:"bytes,2,rep,name=non_compliant_clusters,json=nonCompliantClusters,proto3" json:"non_compliant_clusters,omitempty"`
+	UnknownComplianceClusters []string `protobuf:"bytes,3,rep,name=unknown_compliance_clusters,json=unknownComplianceClusters,proto3" json:"unknown_compliance_clusters,omitempty"`
+	ResourceVersion           string   `protobuf:"bytes,4,opt,name=resource_version,json=resourceVersion,proto3" json:"resource_version,omitempty"`
+}
+
+func (x *PolicyComplianceStatus) Reset() {
+	*x = PolicyComplianceStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_status_bundles_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PolicyComplianceStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PolicyComplianceStatus) ProtoMessage() {}
+
+func (x *PolicyComplianceStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_status_bundles_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PolicyComplianceStatus.ProtoReflect.Descriptor instead.
+func (*PolicyComplianceStatus) Descriptor() ([]byte, []int) {
+	return file_status_bundles_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PolicyComplianceStatus) GetPolicyId() string {
+	if x != nil {
+		return x.PolicyId
+	}
+	return ""
+}
+
+func (x *PolicyComplianceStatus) GetNonCompliantClusters() []string {
+	if x != nil {
+		return x.NonCompliantClusters
+	}
+	return nil
+}
+
+func (x *PolicyComplianceStatus) GetUnknownComplianceClusters() []string {
+	if x != nil {
+		return x.UnknownComplianceClusters
+	}
+	return nil
+}
+
+func (x *PolicyComplianceStatus) GetResourceVersion() string {
+	if x != nil {
+		return x.ResourceVersion
+	}
+	return ""
+}
+
+// MinimalComplianceStatusBundle is the MinimalPolicyCompliance status bundle.
+type MinimalComplianceStatusBundle struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Objects     []*MinimalPolicyComplianceStatus `protobuf:"bytes,1,rep,name=objects,proto3" json:"objects,omitempty"`
+	LeafHubName string                           `protobuf:"bytes,2,opt,name=leaf_hub_name,json=leafHubName,proto3" json:"leaf_hub_name,omitempty"`
+	Generation  uint64                           `protobuf:"varint,3,opt,name=generation,proto3" json:"generation,omitempty"`
+}
+
+func (x *MinimalComplianceStatusBundle) Reset() {
+	*x = MinimalComplianceStatusBundle{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_status_bundles_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MinimalComplianceStatusBundle) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MinimalComplianceStatusBundle) ProtoMessage() {}
+
+func (x *MinimalComplianceStatusBundle) ProtoReflect() protoreflect.Message {
+	mi := &file_status_bundles_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MinimalComplianceStatusBundle.ProtoReflect.Descriptor instead.
+func (*MinimalComplianceStatusBundle) Descriptor() ([]byte, []int) {
+	return file_status_bundles_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *MinimalComplianceStatusBundle) GetObjects() []*MinimalPolicyComplianceStatus {
+	if x != nil {
+		return x.Objects
+	}
+	return nil
+}
+
+func (x *MinimalComplianceStatusBundle) GetLeafHubName() string {
+	if x != nil {
+		return x.LeafHubName
+	}
+	return ""
+}
+
+func (x *MinimalComplianceStatusBundle) GetGeneration() uint64 {
+	if x != nil {
+		return x.Generation
+	}
+	return 0
+}
+
+type MinimalPolicyComplianceStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PolicyId             string `protobuf:"bytes,1,opt,name=policy_id,json=policyId,proto3" json:"policy_id,omitempty"`
+	RemediationAction    string `protobuf:"bytes,2,opt,name=remediation_action,json=remediationAction,proto3" json:"remediation_action,omitempty"`
+	NonCompliantClusters int64  `protobuf:"varint,3,opt,name=non_compliant_clusters,json=nonCompliantClusters,proto3" json:"non_compliant_clusters,omitempty"`
+	AppliedClusters      int64  `protobuf:"varint,4,opt,name=applied_clusters,json=appliedClusters,proto3" json:"applied_clusters,omitempty"`
+}
+
+func (x *MinimalPolicyComplianceStatus) Reset() {
+	*x = MinimalPolicyComplianceStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_status_bundles_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MinimalPolicyComplianceStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MinimalPolicyComplianceStatus) ProtoMessage() {}
+
+func (x *MinimalPolicyComplianceStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_status_bundles_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MinimalPolicyComplianceStatus.ProtoReflect.Descriptor instead.
+func (*MinimalPolicyComplianceStatus) Descriptor() ([]byte, []int) {
+	return file_status_bundles_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *MinimalPolicyComplianceStatus) GetPolicyId() string {
+	if x != nil {
+		return x.PolicyId
+	}
+	return ""
+}
+
+func (x *MinimalPolicyComplianceStatus) GetRemediationAction() string {
+	if x != nil {
+		return x.RemediationAction
+	}
+	return ""
+}
+
+func (x *MinimalPolicyComplianceStatus) GetNonCompliantClusters() int64 {
+	if x != nil {
+		return x.NonCompliantClusters
+	}
+	return 0
+}
+
+func (x *MinimalPolicyComplianceStatus) GetAppliedClusters() int64 {
+	if x != nil {
+		return x.AppliedClusters
+	}
+	return 0
+}
+
+var File_status_bundles_proto protoreflect.FileDescriptor
+
+var file_status_bundles_proto_rawDesc = []byte{
+	0x0a, 0x14, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x5f, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x73,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x13, 0x68, 0x75, 0x62, 0x6f, 0x66, 0x68, 0x75, 0x62,
+	0x73, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e, 0x76, 0x31, 0x22, 0x9f, 0x01, 0x0a, 0x17,
+	0x43, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x73, 0x50, 0x65, 0x72, 0x50, 0x6f, 0x6c, 0x69, 0x63,
+	0x79, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x12, 0x40, 0x0a, 0x07, 0x6f, 0x62, 0x6a, 0x65, 0x63,
+	0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x26, 0x2e, 0x68, 0x75, 0x62, 0x6f, 0x66,
+	0x68, 0x75, 0x62, 0x73, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x43,
+	0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x73, 0x50, 0x65, 0x72, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79,
+	0x52, 0x07, 0x6f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x12, 0x22, 0x0a, 0x0d, 0x6c, 0x65, 0x61,
+	0x66, 0x5f, 0x68, 0x75, 0x62, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0b, 0x6c, 0x65, 0x61, 0x66, 0x48, 0x75, 0x62, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1e, 0x0a,
+	0x0a, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x0a, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0xa6, 0x01,
+	0x0a, 0x11, 0x43, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x73, 0x50, 0x65, 0x72, 0x50, 0x6f, 0x6c,
+	0x69, 0x63, 0x79, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x5f, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x49, 0x64,
+	0x12, 0x1a, 0x0a, 0x08, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x73, 0x18, 0x02, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x08, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x73, 0x12, 0x2d, 0x0a, 0x12,
+	0x72, 0x65, 0x6d, 0x65, 0x64, 0x69, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x61, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x11, 0x72, 0x65, 0x6d, 0x65, 0x64, 0x69,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x29, 0x0a, 0x10, 0x72,
+	0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x56,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0xd9, 0x01, 0x0a, 0x16, 0x43, 0x6f, 0x6d, 0x70, 0x6c,
+	0x69, 0x61, 0x6e, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x42, 0x75, 0x6e, 0x64, 0x6c,
+	0x65, 0x12, 0x45, 0x0a, 0x07, 0x6f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x2b, 0x2e, 0x68, 0x75, 0x62, 0x6f, 0x66, 0x68, 0x75, 0x62, 0x73, 0x2e, 0x73,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x43,
+	0x6f, 0x6d, 0x70, 0x6c, 0x69, 0x61, 0x6e, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52,
+	0x07, 0x6f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x12, 0x22, 0x0a, 0x0d, 0x6c, 0x65, 0x61, 0x66,
+	0x5f, 0x68, 0x75, 0x62, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0b, 0x6c, 0x65, 0x61, 0x66, 0x48, 0x75, 0x62, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x34, 0x0a, 0x16,
+	0x62, 0x61, 0x73, 0x65, 0x5f, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x5f, 0x67, 0x65, 0x6e, 0x65,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x14, 0x62, 0x61,
+	0x73, 0x65, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x12, 0x1e, 0x0a, 0x0a, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x22, 0xd6, 0x01, 0x0a, 0x16, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x43, 0x6f, 0x6d,
+	0x70, 0x6c, 0x69, 0x61, 0x6e, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1b, 0x0a,
+	0x09, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x49, 0x64, 0x12, 0x34, 0x0a, 0x16, 0x6e, 0x6f,
+	0x6e, 0x5f, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x69, 0x61, 0x6e, 0x74, 0x5f, 0x63, 0x6c, 0x75, 0x73,
+	0x74, 0x65, 0x72, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x14, 0x6e, 0x6f, 0x6e, 0x43,
+	0x6f, 0x6d, 0x70, 0x6c, 0x69, 0x61, 0x6e, 0x74, 0x43, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x73,
+	0x12, 0x3e, 0x0a, 0x1b, 0x75, 0x6e, 0x6b, 0x6e, 0x6f, 0x77, 0x6e, 0x5f, 0x63, 0x6f, 0x6d, 0x70,
+	0x6c, 0x69, 0x61, 0x6e, 0x63, 0x65, 0x5f, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x73, 0x18,
+	0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x19, 0x75, 0x6e, 0x6b, 0x6e, 0x6f, 0x77, 0x6e, 0x43, 0x6f,
+	0x6d, 0x70, 0x6c, 0x69, 0x61, 0x6e, 0x63, 0x65, 0x43, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x73,
+	0x12, 0x29, 0x0a, 0x10, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x76, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x72, 0x65, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0xb1, 0x01, 0x0a, 0x1d,
+	0x4d, 0x69, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x69, 0x61, 0x6e, 0x63,
+	0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x12, 0x4c, 0x0a,
+	0x07, 0x6f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x32,
+	0x2e, 0x68, 0x75, 0x62, 0x6f, 0x66, 0x68, 0x75, 0x62, 0x73, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x69, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x50, 0x6f, 0x6c, 0x69,
+	0x63, 0x79, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x69, 0x61, 0x6e, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x52, 0x07, 0x6f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x12, 0x22, 0x0a, 0x0d, 0x6c,
+	0x65, 0x61, 0x66, 0x5f, 0x68, 0x75, 0x62, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0b, 0x6c, 0x65, 0x61, 0x66, 0x48, 0x75, 0x62, 0x4e, 0x61, 0x6d, 0x65, 0x12,
+	0x1e, 0x0a, 0x0a, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x0a, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22,
+	0xcc, 0x01, 0x0a, 0x1d, 0x4d, 0x69, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x50, 0x6f, 0x6c, 0x69, 0x63,
+	0x79, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x69, 0x61, 0x6e, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x5f, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x49, 0x64, 0x12, 0x2d,
+	0x0a, 0x12, 0x72, 0x65, 0x6d, 0x65, 0x64, 0x69, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x61, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x11, 0x72, 0x65, 0x6d, 0x65,
+	0x64, 0x69, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x34, 0x0a,
+	0x16, 0x6e, 0x6f, 0x6e, 0x5f, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x69, 0x61, 0x6e, 0x74, 0x5f, 0x63,
+	0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x14, 0x6e,
+	0x6f, 0x6e, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x69, 0x61, 0x6e, 0x74, 0x43, 0x6c, 0x75, 0x73, 0x74,
+	0x65, 0x72, 0x73, 0x12, 0x29, 0x0a, 0x10, 0x61, 0x70, 0x70, 0x6c, 0x69, 0x65, 0x64, 0x5f, 0x63,
+	0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0f, 0x61,
+	0x70, 0x70, 0x6c, 0x69, 0x65, 0x64, 0x43, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x73, 0x42, 0x4a,
+	0x5a, 0x48, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6f, 0x70, 0x65,
+	0x6e, 0x2d, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x2d, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65,
+	0x6d, 0x65, 0x6e, 0x74, 0x2f, 0x6c, 0x65, 0x61, 0x66, 0x2d, 0x68, 0x75, 0x62, 0x2d, 0x73, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x2d, 0x73, 0x79, 0x6e, 0x63, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x62, 0x75,
+	0x6e, 0x64, 0x6c, 0x65, 0x2f, 0x63, 0x6f, 0x64, 0x65, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
+}
+
+var (
+	file_status_bundles_proto_rawDescOnce sync.Once
+	file_status_bundles_proto_rawDescData = file_status_bundles_proto_rawDesc
+)
+
+func file_status_bundles_proto_rawDescGZIP() []byte {
+	file_status_bundles_proto_rawDescOnce.Do(func() {
+		file_status_bundles_proto_rawDescData = protoimpl.X.CompressGZIP(file_status_bundles_proto_rawDescData)
+	})
+	return file_status_bundles_proto_rawDescData
+}
+
+var file_status_bundles_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_status_bundles_proto_goTypes = []interface{}{
+	(*ClustersPerPolicyBundle)(nil),       // 0: hubofhubs.status.v1.ClustersPerPolicyBundle
+	(*ClustersPerPolicy)(nil),             // 1: hubofhubs.status.v1.ClustersPerPolicy
+	(*ComplianceStatusBundle)(nil),        // 2: hubofhubs.status.v1.ComplianceStatusBundle
+	(*PolicyComplianceStatus)(nil),        // 3: hubofhubs.status.v1.PolicyComplianceStatus
+	(*MinimalComplianceStatusBundle)(nil), // 4: hubofhubs.status.v1.MinimalComplianceStatusBundle
+	(*MinimalPolicyComplianceStatus)(nil), // 5: hubofhubs.status.v1.MinimalPolicyComplianceStatus
+}
+var file_status_bundles_proto_depIdxs = []int32{
+	1, // 0: hubofhubs.status.v1.ClustersPerPolicyBundle.objects:type_name -> hubofhubs.status.v1.ClustersPerPolicy
+	3, // 1: hubofhubs.status.v1.ComplianceStatusBundle.objects:type_name -> hubofhubs.status.v1.PolicyComplianceStatus
+	5, // 2: hubofhubs.status.v1.MinimalComplianceStatusBundle.objects:type_name -> hubofhubs.status.v1.MinimalPolicyComplianceStatus
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_status_bundles_proto_init() }
+func file_status_bundles_proto_init() {
+	if File_status_bundles_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_status_bundles_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ClustersPerPolicyBundle); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_status_bundles_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ClustersPerPolicy); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_status_bundles_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ComplianceStatusBundle); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_status_bundles_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PolicyComplianceStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_status_bundles_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MinimalComplianceStatusBundle); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_status_bundles_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MinimalPolicyComplianceStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_status_bundles_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_status_bundles_proto_goTypes,
+		DependencyIndexes: file_status_bundles_proto_depIdxs,
+		MessageInfos:      file_status_bundles_proto_msgTypes,
+	}.Build()
+	File_status_bundles_proto = out.File
+	file_status_bundles_proto_rawDesc = nil
+	file_status_bundles_proto_goTypes = nil
+	file_status_bundles_proto_depIdxs = nil
+}
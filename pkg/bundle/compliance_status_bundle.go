@@ -108,6 +108,15 @@ func (bundle *ComplianceStatusBundle) GetBundleGeneration() uint64 {
 	return bundle.Generation
 }
 
+// IncrementGeneration function to increment bundle generation without changing its content.
+func (bundle *ComplianceStatusBundle) IncrementGeneration() {
+	bundle.lock.Lock()
+	defer bundle.lock.Unlock()
+
+	bundle.BaseBundleGeneration = bundle.baseBundle.GetBundleGeneration()
+	bundle.Generation++
+}
+
 func (bundle *ComplianceStatusBundle) getObjectIndexByUID(uid string) (int, error) {
 	for i, object := range bundle.Objects {
 		if object.PolicyID == uid {
@@ -72,6 +72,14 @@ func (bundle *GenericStatusBundle) GetBundleGeneration() uint64 {
 	return bundle.Generation
 }
 
+// IncrementGeneration function to increment bundle generation without changing its content.
+func (bundle *GenericStatusBundle) IncrementGeneration() {
+	bundle.lock.Lock()
+	defer bundle.lock.Unlock()
+
+	bundle.Generation++
+}
+
 func (bundle *GenericStatusBundle) getObjectIndexByUID(uid types.UID) (int, error) {
 	for i, object := range bundle.Objects {
 		if object.GetUID() == uid {
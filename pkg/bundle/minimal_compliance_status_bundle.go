@@ -85,6 +85,14 @@ func (bundle *MinimalComplianceStatusBundle) GetBundleGeneration() uint64 {
 	return bundle.Generation
 }
 
+// IncrementGeneration function to increment bundle generation without changing its content.
+func (bundle *MinimalComplianceStatusBundle) IncrementGeneration() {
+	bundle.lock.Lock()
+	defer bundle.lock.Unlock()
+
+	bundle.Generation++
+}
+
 func (bundle *MinimalComplianceStatusBundle) getObjectIndexByUID(uid string) (int, error) {
 	for i, object := range bundle.Objects {
 		if object.PolicyID == uid {
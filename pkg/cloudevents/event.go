@@ -0,0 +1,57 @@
+package cloudevents
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+const (
+	// SpecVersion is the version of the CloudEvents specification the events conform to.
+	SpecVersion = "1.0"
+	// ContentType is the content type of an event in the structured content mode, encoded as json.
+	ContentType = "application/cloudevents+json"
+	// DataContentTypeJSON is the content type of json encoded event data, which is embedded in the event as is.
+	// data of other content types is embedded base64 encoded.
+	DataContentTypeJSON = "application/json"
+	// StatusBundleTypePrefix is the prefix of the type of the events that carry a status bundle, followed by the bundle
+	// type, e.g. io.open-cluster-management.hub-of-hubs.status.ManagedClusters.
+	StatusBundleTypePrefix = "io.open-cluster-management.hub-of-hubs.status."
+)
+
+// Event is a CloudEvents 1.0 event in the structured content mode, i.e. the attributes and the data are encoded
+// together in a single json object.
+type Event struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            string          `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	DataBase64      string          `json:"data_base64,omitempty"`
+}
+
+// NewEvent creates a new event with the given attributes and data of the given content type. the time of the event
+// is now.
+func NewEvent(id string, source string, eventType string, subject string, dataContentType string,
+	data []byte) *Event {
+	event := &Event{
+		SpecVersion:     SpecVersion,
+		ID:              id,
+		Source:          source,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            time.Now().UTC().Format(time.RFC3339Nano),
+		DataContentType: dataContentType,
+	}
+
+	if dataContentType == DataContentTypeJSON {
+		event.Data = data
+	} else {
+		event.DataBase64 = base64.StdEncoding.EncodeToString(data)
+	}
+
+	return event
+}
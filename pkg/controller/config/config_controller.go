@@ -2,6 +2,7 @@ package config
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	configv1 "github.com/open-cluster-management/hub-of-hubs-data-types/apis/config/v1"
 	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/controller/generic"
 	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/helpers"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -18,12 +20,19 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
+// ResyncAnnotation is a local annotation on the hub of hubs config. when its value changes, all the bundles are sent
+// again with a fresh generation.
+const ResyncAnnotation = "hub-of-hubs.open-cluster-management.io/resync"
+
 // AddConfigController creates a new instance of config controller and adds it to the manager.
-func AddConfigController(mgr ctrl.Manager, logName string, configObject *configv1.Config) error {
+// resync requests are dispatched as transport.CommandTypeResync commands using localCommands.
+func AddConfigController(mgr ctrl.Manager, logName string, configObject *configv1.Config,
+	localCommands *transport.CommandDispatcher) error {
 	hubOfHubsConfigCtrl := &hubOfHubsConfigController{
-		client:       mgr.GetClient(),
-		log:          ctrl.Log.WithName(logName),
-		configObject: configObject,
+		client:        mgr.GetClient(),
+		log:           ctrl.Log.WithName(logName),
+		configObject:  configObject,
+		localCommands: localCommands,
 	}
 
 	hohNamespacePredicate := predicate.NewPredicateFuncs(func(meta metav1.Object, object runtime.Object) bool {
@@ -44,9 +53,14 @@ func AddConfigController(mgr ctrl.Manager, logName string, configObject *configv
 }
 
 type hubOfHubsConfigController struct {
-	client       client.Client
-	log          logr.Logger
-	configObject *configv1.Config
+	client        client.Client
+	log           logr.Logger
+	configObject  *configv1.Config
+	localCommands *transport.CommandDispatcher
+	// lastResyncRequest is the value of the resync annotation that was handled last. the value that is found when
+	// the config is first read is not handled, so a restart doesn't resync again.
+	lastResyncRequest     string
+	resyncRequestObserved bool
 }
 
 func (c *hubOfHubsConfigController) Reconcile(request ctrl.Request) (ctrl.Result, error) {
@@ -62,7 +76,33 @@ func (c *hubOfHubsConfigController) Reconcile(request ctrl.Request) (ctrl.Result
 			fmt.Errorf("reconciliation failed: %w", err)
 	}
 
+	c.handleResyncRequest(reqLogger)
+
 	reqLogger.Info("Reconciliation complete.")
 
 	return ctrl.Result{}, nil
 }
+
+// handleResyncRequest dispatches a resync command if the value of the resync annotation changed since last handled.
+func (c *hubOfHubsConfigController) handleResyncRequest(log logr.Logger) {
+	resyncRequest := c.configObject.GetAnnotations()[ResyncAnnotation]
+	if !c.resyncRequestObserved || resyncRequest == "" || resyncRequest == c.lastResyncRequest {
+		c.resyncRequestObserved = true
+		c.lastResyncRequest = resyncRequest
+
+		return
+	}
+
+	c.lastResyncRequest = resyncRequest
+
+	log.Info(fmt.Sprintf("resync was requested using the %s annotation", ResyncAnnotation))
+
+	if err := c.localCommands.Dispatch(&transport.Command{
+		ID:   resyncRequest,
+		Type: transport.CommandTypeResync,
+	}); errors.Is(err, transport.ErrCommandIgnored) {
+		log.Info(fmt.Sprintf("resync was not done - %v", err))
+	} else if err != nil {
+		log.Error(err, "failed to resync")
+	}
+}
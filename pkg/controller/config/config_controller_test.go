@@ -0,0 +1,128 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	datatypes "github.com/open-cluster-management/hub-of-hubs-data-types"
+	configv1 "github.com/open-cluster-management/hub-of-hubs-data-types/apis/config/v1"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testConfigName = "hub-of-hubs-config"
+
+// newTestController returns a config controller of a config with the given resync annotation, whose resync
+// commands are recorded in the returned slice.
+func newTestController(t *testing.T, resyncRequest string) (*hubOfHubsConfigController, client.Client,
+	*[]*transport.Command) {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := configv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add the config types to the scheme: %v", err)
+	}
+
+	config := &configv1.Config{ObjectMeta: metav1.ObjectMeta{
+		Name:        testConfigName,
+		Namespace:   datatypes.HohSystemNamespace,
+		Annotations: map[string]string{ResyncAnnotation: resyncRequest},
+	}}
+
+	var commands []*transport.Command
+
+	localCommands := transport.NewCommandDispatcher()
+	localCommands.Subscribe(transport.CommandTypeResync, func(command *transport.Command) error {
+		commands = append(commands, command)
+		return nil
+	})
+
+	fakeClient := fake.NewFakeClientWithScheme(scheme, config)
+
+	return &hubOfHubsConfigController{
+		client:        fakeClient,
+		log:           logrtesting.NullLogger{},
+		configObject:  &configv1.Config{},
+		localCommands: localCommands,
+	}, fakeClient, &commands
+}
+
+func reconcile(t *testing.T, controller *hubOfHubsConfigController) {
+	t.Helper()
+
+	if _, err := controller.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{
+		Namespace: datatypes.HohSystemNamespace,
+		Name:      testConfigName,
+	}}); err != nil {
+		t.Fatalf("failed to reconcile the config: %v", err)
+	}
+}
+
+func setResyncAnnotation(t *testing.T, fakeClient client.Client, resyncRequest string) {
+	t.Helper()
+
+	ctx := context.Background()
+	config := &configv1.Config{}
+
+	if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: datatypes.HohSystemNamespace, Name: testConfigName},
+		config); err != nil {
+		t.Fatalf("failed to get the config: %v", err)
+	}
+
+	config.SetAnnotations(map[string]string{ResyncAnnotation: resyncRequest})
+
+	if err := fakeClient.Update(ctx, config); err != nil {
+		t.Fatalf("failed to update the config: %v", err)
+	}
+}
+
+func TestChangedResyncAnnotationDispatchesResync(t *testing.T) {
+	controller, fakeClient, commands := newTestController(t, "")
+
+	reconcile(t, controller)
+	setResyncAnnotation(t, fakeClient, "request-1")
+	reconcile(t, controller)
+
+	if len(*commands) != 1 || (*commands)[0].ID != "request-1" {
+		t.Fatalf("expected a single resync command of request-1, got %v", *commands)
+	}
+
+	reconcile(t, controller) // the annotation didn't change
+
+	setResyncAnnotation(t, fakeClient, "request-2")
+	reconcile(t, controller)
+
+	if len(*commands) != 2 || (*commands)[1].ID != "request-2" {
+		t.Fatalf("expected a second resync command of request-2, got %v", *commands)
+	}
+}
+
+func TestResyncAnnotationFoundOnFirstReadIsNotHandled(t *testing.T) {
+	// e.g. after a restart, the annotation that was handled before the restart is still set
+	controller, _, commands := newTestController(t, "request-1")
+
+	reconcile(t, controller)
+	reconcile(t, controller)
+
+	if len(*commands) != 0 {
+		t.Fatalf("expected no resync after the restart, got %d resync commands", len(*commands))
+	}
+}
+
+func TestRemovedResyncAnnotationDoesNotDispatchResync(t *testing.T) {
+	controller, fakeClient, commands := newTestController(t, "request-1")
+
+	reconcile(t, controller)
+	setResyncAnnotation(t, fakeClient, "")
+	reconcile(t, controller)
+
+	if len(*commands) != 0 {
+		t.Fatalf("expected no resync when the annotation is removed, got %d resync commands", len(*commands))
+	}
+}
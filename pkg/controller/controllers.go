@@ -11,6 +11,7 @@ import (
 	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/pkg/apis/policy/v1"
 	configv1 "github.com/open-cluster-management/hub-of-hubs-data-types/apis/config/v1"
 	configCtrl "github.com/open-cluster-management/leaf-hub-status-sync/pkg/controller/config"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/controller/generic"
 	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/controller/managedclusters"
 	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/controller/policies"
 	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
@@ -38,20 +39,31 @@ func AddToScheme(s *runtime.Scheme) error {
 }
 
 // AddControllers adds all the controllers to the Manager.
+// bundleEncoding is json or cloudevents, bundleCodec is the codec the bundles are encoded with, json or protobuf.
 func AddControllers(mgr ctrl.Manager, transportImpl transport.Transport, syncInterval time.Duration,
-	leafHubName string) error {
+	leafHubName string, bundleEncoding string, bundleCodec string) error {
+	bundleEncoder, err := generic.NewBundleEncoder(leafHubName, bundleEncoding, bundleCodec)
+	if err != nil {
+		return fmt.Errorf("failed to add controllers: %w", err)
+	}
+
 	config := &configv1.Config{}
+	localCommands := transport.NewCommandDispatcher()
 
-	if err := configCtrl.AddConfigController(mgr, "hub-of-hubs-config", config); err != nil {
+	if err := configCtrl.AddConfigController(mgr, "hub-of-hubs-config", config, localCommands); err != nil {
 		return fmt.Errorf("failed to add controller: %w", err)
 	}
 
-	addControllerFunctions := []func(ctrl.Manager, transport.Transport, time.Duration, string, *configv1.Config) error{
+	transportImpl = &localCommandsTransport{Transport: transportImpl, localCommands: localCommands}
+
+	addControllerFunctions := []func(ctrl.Manager, transport.Transport, time.Duration, string, *configv1.Config,
+		*generic.BundleEncoder) error{
 		managedclusters.AddClustersStatusController, policies.AddPoliciesStatusController,
 	}
 
 	for _, addControllerFunction := range addControllerFunctions {
-		if err := addControllerFunction(mgr, transportImpl, syncInterval, leafHubName, config); err != nil {
+		if err := addControllerFunction(mgr, transportImpl, syncInterval, leafHubName, config,
+			bundleEncoder); err != nil {
 			return fmt.Errorf("failed to add controller: %w", err)
 		}
 	}
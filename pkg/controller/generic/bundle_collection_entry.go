@@ -1,6 +1,8 @@
 package generic
 
 import (
+	"sync"
+
 	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/bundle"
 )
 
@@ -8,17 +10,58 @@ import (
 func NewBundleCollectionEntry(transportBundleKey string, bundle bundle.Bundle,
 	predicate func() bool) *BundleCollectionEntry {
 	return &BundleCollectionEntry{
-		transportBundleKey:       transportBundleKey,
-		bundle:                   bundle,
-		predicate:                predicate,
-		lastSentBundleGeneration: bundle.GetBundleGeneration(),
+		transportBundleKey:             transportBundleKey,
+		bundle:                         bundle,
+		predicate:                      predicate,
+		lastSentBundleGeneration:       bundle.GetBundleGeneration(),
+		lastDispatchedBundleGeneration: bundle.GetBundleGeneration(),
+		lock:                           sync.Mutex{},
 	}
 }
 
 // BundleCollectionEntry holds information about a specific bundle.
 type BundleCollectionEntry struct {
-	transportBundleKey       string
-	bundle                   bundle.Bundle
-	predicate                func() bool
+	transportBundleKey string
+	bundle             bundle.Bundle
+	predicate          func() bool
+	// lastSentBundleGeneration is the last generation that was delivered successfully by the transport.
 	lastSentBundleGeneration uint64
+	// lastDispatchedBundleGeneration is the last generation that was handed to the transport.
+	lastDispatchedBundleGeneration uint64
+	lock                           sync.Mutex
+}
+
+// dispatchIfChanged returns true if the given generation was not handed to the transport yet and marks it as
+// dispatched, otherwise returns false.
+func (entry *BundleCollectionEntry) dispatchIfChanged(bundleGeneration uint64) bool {
+	entry.lock.Lock()
+	defer entry.lock.Unlock()
+
+	if bundleGeneration <= entry.lastDispatchedBundleGeneration {
+		return false
+	}
+
+	entry.lastDispatchedBundleGeneration = bundleGeneration
+
+	return true
+}
+
+// handleDeliveryResult updates the entry according to the delivery result of the given generation.
+// if delivery failed, the entry is reset so the bundle is sent again in the next sync.
+func (entry *BundleCollectionEntry) handleDeliveryResult(bundleGeneration uint64, err error) {
+	entry.lock.Lock()
+	defer entry.lock.Unlock()
+
+	if err == nil {
+		if bundleGeneration > entry.lastSentBundleGeneration {
+			entry.lastSentBundleGeneration = bundleGeneration
+		}
+
+		return
+	}
+
+	// reset only if no newer generation was dispatched in the meantime, otherwise the newer one replaces this one.
+	if bundleGeneration == entry.lastDispatchedBundleGeneration {
+		entry.lastDispatchedBundleGeneration = entry.lastSentBundleGeneration
+	}
 }
@@ -0,0 +1,107 @@
+package generic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/bundle/codec"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/cloudevents"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+const (
+	// BundleEncodingJSON sends the encoded bundle as is.
+	BundleEncodingJSON = "json"
+	// BundleEncodingCloudEvents wraps the encoded bundle in a CloudEvents structured mode envelope.
+	BundleEncodingCloudEvents = "cloudevents"
+	// MetadataKeyContentType is the message metadata key that holds the content type of a CloudEvents envelope.
+	MetadataKeyContentType = "contentType"
+	// MetadataKeyCodec is the message metadata key that holds the codec the bundle was encoded with. it's set only if
+	// the codec is not json.
+	MetadataKeyCodec = "codec"
+)
+
+var errUnsupportedBundleEncoding = errors.New("unsupported bundle encoding")
+
+// BundleEncoder encodes the bundles that are sent to the transport using a codec, optionally wrapped in a CloudEvents
+// envelope. bundles of types that the codec doesn't support are encoded as json.
+type BundleEncoder struct {
+	codec       codec.Codec
+	jsonCodec   codec.Codec
+	cloudEvents bool
+	leafHubName string
+	instanceID  string
+}
+
+// NewBundleEncoder creates a new instance of BundleEncoder with the given bundle encoding and codec. each instance has
+// a random id, which keeps the CloudEvents ids unique after a restart resets the bundle generations.
+func NewBundleEncoder(leafHubName string, bundleEncoding string, codecName string) (*BundleEncoder, error) {
+	if bundleEncoding != BundleEncodingJSON && bundleEncoding != BundleEncodingCloudEvents {
+		return nil, fmt.Errorf("%w: %s (supported encodings: %s, %s)", errUnsupportedBundleEncoding, bundleEncoding,
+			BundleEncodingJSON, BundleEncodingCloudEvents)
+	}
+
+	bundleCodec, err := codec.NewCodec(codecName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bundle codec - %w", err)
+	}
+
+	jsonCodec, err := codec.NewCodec(codec.JSONName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bundle codec - %w", err)
+	}
+
+	return &BundleEncoder{
+		codec:       bundleCodec,
+		jsonCodec:   jsonCodec,
+		cloudEvents: bundleEncoding == BundleEncodingCloudEvents,
+		leafHubName: leafHubName,
+		instanceID:  string(uuid.NewUUID()),
+	}, nil
+}
+
+// encode encodes the bundle of the entry into the payload of the message, and sets the metadata of the message
+// accordingly.
+func (encoder *BundleEncoder) encode(entry *BundleCollectionEntry, generation uint64,
+	message *transport.Message) error {
+	bundleCodec := encoder.codec
+
+	payloadBytes, err := bundleCodec.Encode(entry.bundle)
+	if errors.Is(err, codec.ErrUnsupportedBundleType) {
+		bundleCodec = encoder.jsonCodec
+		payloadBytes, err = bundleCodec.Encode(entry.bundle)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to encode bundle - %w", err)
+	}
+
+	if bundleCodec.GetName() != codec.JSONName {
+		message.SetMetadata(MetadataKeyCodec, bundleCodec.GetName())
+	}
+
+	if !encoder.cloudEvents {
+		message.Payload = payloadBytes
+		return nil
+	}
+
+	// the bundle key is <leaf hub name>.<bundle type>, the event id is unique per bundle key, encoder instance and
+	// generation
+	bundleType := strings.TrimPrefix(entry.transportBundleKey, encoder.leafHubName+".")
+	eventID := fmt.Sprintf("%s.%s.%s", entry.transportBundleKey, encoder.instanceID,
+		strconv.FormatUint(generation, 10))
+	event := cloudevents.NewEvent(eventID, encoder.leafHubName, cloudevents.StatusBundleTypePrefix+bundleType,
+		entry.transportBundleKey, bundleCodec.GetContentType(), payloadBytes)
+
+	if message.Payload, err = json.Marshal(event); err != nil {
+		return fmt.Errorf("failed to marshal cloud event - %w", err)
+	}
+
+	message.SetMetadata(MetadataKeyContentType, cloudevents.ContentType)
+
+	return nil
+}
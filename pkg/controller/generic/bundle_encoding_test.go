@@ -0,0 +1,155 @@
+package generic
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/bundle"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/bundle/codec"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/cloudevents"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+)
+
+const (
+	testLeafHubName = "hub1"
+	testBundleType  = "ClustersPerPolicy"
+	testBundleKey   = testLeafHubName + "." + testBundleType
+	testGeneration  = 3
+)
+
+func newTestEncoder(t *testing.T, codecName string) *BundleEncoder {
+	t.Helper()
+
+	encoder, err := NewBundleEncoder(testLeafHubName, BundleEncodingCloudEvents, codecName)
+	if err != nil {
+		t.Fatalf("failed to create the bundle encoder: %v", err)
+	}
+
+	return encoder
+}
+
+// encodeEvent encodes a clusters per policy bundle and returns the message and the attributes of its envelope.
+func encodeEvent(t *testing.T, encoder *BundleEncoder) (*transport.Message, map[string]json.RawMessage) {
+	t.Helper()
+
+	entry := NewBundleCollectionEntry(testBundleKey, bundle.NewClustersPerPolicyBundle(testLeafHubName, 0), nil)
+	message := &transport.Message{ID: testBundleKey}
+
+	if err := encoder.encode(entry, testGeneration, message); err != nil {
+		t.Fatalf("failed to encode the bundle: %v", err)
+	}
+
+	envelope := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(message.Payload, &envelope); err != nil {
+		t.Fatalf("failed to parse the envelope: %v", err)
+	}
+
+	return message, envelope
+}
+
+func attribute(t *testing.T, envelope map[string]json.RawMessage, name string) string {
+	t.Helper()
+
+	var value string
+
+	if err := json.Unmarshal(envelope[name], &value); err != nil {
+		t.Fatalf("failed to read the %s attribute: %v", name, err)
+	}
+
+	return value
+}
+
+func TestCloudEventsEnvelope(t *testing.T) {
+	for name, test := range map[string]struct {
+		codecName               string
+		expectedDataContentType string
+		expectedDataAttribute   string
+		expectedCodecMetadata   string
+	}{
+		"json": {
+			codecName:               codec.JSONName,
+			expectedDataContentType: cloudevents.DataContentTypeJSON,
+			expectedDataAttribute:   "data",
+		},
+		"protobuf": {
+			codecName:               codec.ProtobufName,
+			expectedDataContentType: "application/x-protobuf",
+			expectedDataAttribute:   "data_base64",
+			expectedCodecMetadata:   codec.ProtobufName,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			encoder := newTestEncoder(t, test.codecName)
+			message, envelope := encodeEvent(t, encoder)
+
+			expectedAttributes := map[string]string{
+				"specversion":     cloudevents.SpecVersion,
+				"source":          testLeafHubName,
+				"type":            cloudevents.StatusBundleTypePrefix + testBundleType,
+				"subject":         testBundleKey,
+				"datacontenttype": test.expectedDataContentType,
+			}
+			for attributeName, expected := range expectedAttributes {
+				if actual := attribute(t, envelope, attributeName); actual != expected {
+					t.Fatalf("expected %s to be %q, got %q", attributeName, expected, actual)
+				}
+			}
+
+			if _, err := time.Parse(time.RFC3339Nano, attribute(t, envelope, "time")); err != nil {
+				t.Fatalf("expected an RFC 3339 time: %v", err)
+			}
+
+			if id := attribute(t, envelope, "id"); !strings.HasPrefix(id, testBundleKey+".") ||
+				!strings.HasSuffix(id, ".3") {
+				t.Fatalf("expected the id to be <bundle key>.<instance id>.<generation>, got %q", id)
+			}
+
+			// the attributes above, the id and time, and exactly one of data and data_base64
+			if len(envelope) != len(expectedAttributes)+3 || envelope[test.expectedDataAttribute] == nil {
+				t.Fatalf("expected only the %s data attribute, got %d attributes", test.expectedDataAttribute,
+					len(envelope))
+			}
+
+			entry := NewBundleCollectionEntry(testBundleKey, bundle.NewClustersPerPolicyBundle(testLeafHubName, 0),
+				nil)
+			expectedData, _ := encoder.codec.Encode(entry.bundle)
+
+			if !bytes.Equal(eventData(t, envelope), expectedData) {
+				t.Fatal("expected the event data to be the encoded bundle")
+			}
+
+			if message.Metadata[MetadataKeyContentType] != cloudevents.ContentType ||
+				message.Metadata[MetadataKeyCodec] != test.expectedCodecMetadata {
+				t.Fatalf("unexpected message metadata %v", message.Metadata)
+			}
+		})
+	}
+}
+
+func eventData(t *testing.T, envelope map[string]json.RawMessage) []byte {
+	t.Helper()
+
+	if data, found := envelope["data"]; found {
+		return data
+	}
+
+	data, err := base64.StdEncoding.DecodeString(attribute(t, envelope, "data_base64"))
+	if err != nil {
+		t.Fatalf("failed to decode data_base64: %v", err)
+	}
+
+	return data
+}
+
+func TestCloudEventsIDIsUniqueAfterRestart(t *testing.T) {
+	_, envelope := encodeEvent(t, newTestEncoder(t, codec.JSONName))
+	_, envelopeAfterRestart := encodeEvent(t, newTestEncoder(t, codec.JSONName)) // the generation starts over
+
+	if attribute(t, envelope, "id") == attribute(t, envelopeAfterRestart, "id") {
+		t.Fatal("expected the ids of the same generation to differ between encoder instances")
+	}
+}
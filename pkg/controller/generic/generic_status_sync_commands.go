@@ -0,0 +1,97 @@
+package generic
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	datatypes "github.com/open-cluster-management/hub-of-hubs-data-types"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+)
+
+var errInvalidSyncInterval = errors.New("sync interval must be positive")
+
+// subscribeToCommands subscribes the controller to the hub commands it handles.
+func (c *genericStatusSyncController) subscribeToCommands() {
+	c.transport.Subscribe(transport.CommandTypeResendBundle, c.whenRunning(c.handleResendBundleCommand))
+	c.transport.Subscribe(transport.CommandTypeResync, c.whenRunning(c.handleResyncCommand))
+	c.transport.Subscribe(transport.CommandTypeChangeSyncInterval, c.handleChangeSyncIntervalCommand)
+}
+
+// whenRunning wraps a handler that sends bundles, so commands are ignored unless the periodic sync runs. otherwise
+// an instance that isn't the leader, or didn't populate its bundles yet, would send empty bundles. an ignored command
+// is not acknowledged, so the leader can handle it.
+func (c *genericStatusSyncController) whenRunning(handler transport.CommandHandler) transport.CommandHandler {
+	return func(command *transport.Command) error {
+		if !c.isRunning() {
+			return fmt.Errorf("%w: periodic sync is not running", transport.ErrCommandIgnored)
+		}
+
+		return handler(command)
+	}
+}
+
+// handleResendBundleCommand resends the current content of the requested bundle, if it belongs to this controller.
+func (c *genericStatusSyncController) handleResendBundleCommand(command *transport.Command) error {
+	resendBundleCommand := &transport.ResendBundleCommand{}
+	if err := command.UnmarshalPayload(resendBundleCommand); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, entry := range c.orderedBundleCollection {
+		if entry.transportBundleKey == resendBundleCommand.BundleID && entry.predicate() {
+			c.log.Info(fmt.Sprintf("resending bundle %s as requested by the hub", entry.transportBundleKey))
+			c.resendBundle(entry)
+		}
+	}
+
+	return nil
+}
+
+// handleResyncCommand resends the current content of all the bundles of this controller with a fresh generation.
+func (c *genericStatusSyncController) handleResyncCommand(*transport.Command) error {
+	c.resync()
+	return nil
+}
+
+// handleChangeSyncIntervalCommand changes the periodic sync interval, starting from the next sync.
+func (c *genericStatusSyncController) handleChangeSyncIntervalCommand(command *transport.Command) error {
+	changeSyncIntervalCommand := &transport.ChangeSyncIntervalCommand{}
+	if err := command.UnmarshalPayload(changeSyncIntervalCommand); err != nil {
+		return err
+	}
+
+	syncInterval, err := time.ParseDuration(changeSyncIntervalCommand.SyncInterval)
+	if err != nil {
+		return fmt.Errorf("failed to parse sync interval - %w", err)
+	}
+
+	if syncInterval <= 0 {
+		return fmt.Errorf("%w: %s", errInvalidSyncInterval, changeSyncIntervalCommand.SyncInterval)
+	}
+
+	c.lock.Lock()
+	c.periodicSyncInterval = syncInterval
+	c.lock.Unlock()
+
+	select {
+	case c.syncIntervalChangedChan <- struct{}{}:
+	default:
+	}
+
+	c.log.Info(fmt.Sprintf("sync interval changed to %s as requested by the hub", syncInterval))
+
+	return nil
+}
+
+// resendBundle sends the current generation of the bundle, even if it was already sent. must be called with the
+// controller lock held.
+func (c *genericStatusSyncController) resendBundle(entry *BundleCollectionEntry) {
+	bundleGeneration := entry.bundle.GetBundleGeneration()
+	entry.dispatchIfChanged(bundleGeneration) // mark the generation as dispatched, in case it wasn't yet
+
+	c.syncToTransport(entry, datatypes.StatusBundle, bundleGeneration)
+}
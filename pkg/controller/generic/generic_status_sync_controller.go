@@ -2,7 +2,7 @@ package generic
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"sync"
@@ -16,6 +16,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
@@ -30,7 +31,7 @@ type CreateObjectFunction func() bundle.Object
 // NewGenericStatusSyncController creates a new instnace of genericStatusSyncController and adds it to the manager.
 func NewGenericStatusSyncController(mgr ctrl.Manager, logName string, transport transport.Transport,
 	finalizerName string, orderedBundleCollection []*BundleCollectionEntry, createObjFunc CreateObjectFunction,
-	syncInterval time.Duration, predicate predicate.Predicate) error {
+	syncInterval time.Duration, predicate predicate.Predicate, bundleEncoder *BundleEncoder) error {
 	statusSyncCtrl := &genericStatusSyncController{
 		client:                  mgr.GetClient(),
 		log:                     ctrl.Log.WithName(logName),
@@ -39,9 +40,16 @@ func NewGenericStatusSyncController(mgr ctrl.Manager, logName string, transport
 		finalizerName:           finalizerName,
 		createObjFunc:           createObjFunc,
 		periodicSyncInterval:    syncInterval,
+		syncIntervalChangedChan: make(chan struct{}, 1),
+		bundleEncoder:           bundleEncoder,
 		lock:                    sync.Mutex{},
 	}
-	statusSyncCtrl.init()
+
+	statusSyncCtrl.subscribeToCommands()
+
+	if err := mgr.Add(manager.RunnableFunc(statusSyncCtrl.periodicSync)); err != nil {
+		return fmt.Errorf("failed to add periodic sync to the manager - %w", err)
+	}
 
 	controllerBuilder := ctrl.NewControllerManagedBy(mgr).For(createObjFunc())
 	if predicate != nil {
@@ -63,14 +71,11 @@ type genericStatusSyncController struct {
 	finalizerName           string
 	createObjFunc           CreateObjectFunction
 	periodicSyncInterval    time.Duration
-	startOnce               sync.Once
-	lock                    sync.Mutex
-}
-
-func (c *genericStatusSyncController) init() {
-	c.startOnce.Do(func() {
-		go c.periodicSync()
-	})
+	syncIntervalChangedChan chan struct{}
+	bundleEncoder           *BundleEncoder
+	// running is true while the periodic sync runs, i.e. this instance is the leader and its bundles are populated.
+	running bool
+	lock    sync.Mutex
 }
 
 func (c *genericStatusSyncController) Reconcile(request ctrl.Request) (ctrl.Result, error) {
@@ -174,13 +179,64 @@ func (c *genericStatusSyncController) removeFinalizer(ctx context.Context, objec
 	return nil
 }
 
-func (c *genericStatusSyncController) periodicSync() {
-	ticker := time.NewTicker(c.periodicSyncInterval)
+// periodicSync syncs the bundles every sync interval until stopChan is closed, and then syncs them one last time.
+func (c *genericStatusSyncController) periodicSync(stopChan <-chan struct{}) error {
+	ticker := time.NewTicker(c.getSyncInterval())
+	defer ticker.Stop()
+
+	c.setRunning(true)
+	defer c.setRunning(false)
 
 	for {
-		<-ticker.C // wait for next time interval
-		c.syncBundles()
+		select {
+		case <-stopChan:
+			c.log.Info("stopping periodic sync, syncing bundles before shutdown")
+			c.syncBundles()
+
+			return nil
+		case <-c.syncIntervalChangedChan:
+			ticker.Reset(c.getSyncInterval())
+		case <-ticker.C: // wait for next time interval
+			c.syncBundles()
+		}
+	}
+}
+
+func (c *genericStatusSyncController) setRunning(running bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.running = running
+}
+
+func (c *genericStatusSyncController) isRunning() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.running
+}
+
+func (c *genericStatusSyncController) getSyncInterval() time.Duration {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.periodicSyncInterval
+}
+
+// resync increments the generation of all the bundles and syncs them, so every bundle is sent again with its current
+// content even if it didn't change, e.g. after the hub of hubs database was restored.
+func (c *genericStatusSyncController) resync() {
+	c.lock.Lock()
+
+	c.log.Info("resyncing all bundles")
+
+	for _, entry := range c.orderedBundleCollection { // in order, dependent bundles refer to the new base generation
+		entry.bundle.IncrementGeneration()
 	}
+
+	c.lock.Unlock()
+
+	c.syncBundles()
 }
 
 func (c *genericStatusSyncController) syncBundles() {
@@ -195,24 +251,38 @@ func (c *genericStatusSyncController) syncBundles() {
 		bundleGeneration := entry.bundle.GetBundleGeneration()
 
 		// send to transport only if bundle has changed
-		if bundleGeneration > entry.lastSentBundleGeneration {
-			c.syncToTransport(entry.transportBundleKey, datatypes.StatusBundle,
-				strconv.FormatUint(bundleGeneration, 10), entry.bundle)
-
-			entry.lastSentBundleGeneration = bundleGeneration
+		if entry.dispatchIfChanged(bundleGeneration) {
+			c.syncToTransport(entry, datatypes.StatusBundle, bundleGeneration)
 		}
 	}
 }
 
-func (c *genericStatusSyncController) syncToTransport(id string, objType string, generation string,
-	payload bundle.Bundle) {
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
+func (c *genericStatusSyncController) syncToTransport(entry *BundleCollectionEntry, objType string,
+	generation uint64) {
+	id := entry.transportBundleKey
+
+	message := &transport.Message{
+		ID:      id,
+		MsgType: objType,
+		Version: strconv.FormatUint(generation, 10),
+		DeliveryCallback: func(err error) {
+			if err != nil && !errors.Is(err, transport.ErrMessageSuperseded) {
+				c.log.Info(fmt.Sprintf("failed to deliver object from type %s with id %s and generation %d, "+
+					"will retry in next sync - %s", objType, id, generation, err))
+			}
+
+			entry.handleDeliveryResult(generation, err)
+		},
+	}
+
+	if err := c.bundleEncoder.encode(entry, generation, message); err != nil {
 		c.log.Info(fmt.Sprintf("failed to sync object from type %s with id %s- %s", objType, id, err))
+		entry.handleDeliveryResult(generation, err)
+
 		return
 	}
 
-	c.transport.SendAsync(id, objType, generation, payloadBytes)
+	c.transport.SendAsync(message)
 }
 
 func cleanObject(object bundle.Object) {
@@ -0,0 +1,177 @@
+package generic
+
+import (
+	"errors"
+	"testing"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/bundle"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/bundle/codec"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/transporttest"
+)
+
+var errDeliveryFailed = errors.New("delivery failed")
+
+// newTestController returns a controller that syncs a single bundle to a fake transport, and the bundle.
+func newTestController(t *testing.T) (*genericStatusSyncController, bundle.Bundle, *transporttest.Transport) {
+	t.Helper()
+
+	encoder, err := NewBundleEncoder(testLeafHubName, BundleEncodingJSON, codec.JSONName)
+	if err != nil {
+		t.Fatalf("failed to create the bundle encoder: %v", err)
+	}
+
+	testBundle := bundle.NewClustersPerPolicyBundle(testLeafHubName, 0)
+	fake := transporttest.NewTransport()
+
+	return &genericStatusSyncController{
+		log:       logrtesting.NullLogger{},
+		transport: fake,
+		orderedBundleCollection: []*BundleCollectionEntry{
+			NewBundleCollectionEntry(testBundleKey, testBundle, func() bool { return true }),
+		},
+		bundleEncoder: encoder,
+	}, testBundle, fake
+}
+
+// lastSent returns the last message that was sent to the fake transport, after checking the number of sent messages.
+func lastSent(t *testing.T, fake *transporttest.Transport, expectedCount int) *transport.Message {
+	t.Helper()
+
+	sent := fake.Sent()
+	if len(sent) != expectedCount {
+		t.Fatalf("expected %d sent messages, got %d", expectedCount, len(sent))
+	}
+
+	return sent[len(sent)-1]
+}
+
+func TestFailedDeliveryIsResentInTheNextSync(t *testing.T) {
+	controller, testBundle, fake := newTestController(t)
+
+	testBundle.IncrementGeneration()
+	controller.syncBundles()
+	lastSent(t, fake, 1).ReportDeliveryResult(errDeliveryFailed)
+
+	controller.syncBundles()
+
+	if message := lastSent(t, fake, 2); message.Version != "1" {
+		t.Fatalf("expected generation 1 to be resent, got version %s", message.Version)
+	}
+}
+
+func TestDeliveredBundleIsNotResent(t *testing.T) {
+	controller, testBundle, fake := newTestController(t)
+
+	testBundle.IncrementGeneration()
+	controller.syncBundles()
+	lastSent(t, fake, 1).ReportDeliveryResult(nil)
+
+	controller.syncBundles()
+	lastSent(t, fake, 1)
+}
+
+func TestUndeliveredBundleIsNotResentBeforeTheResult(t *testing.T) {
+	controller, testBundle, fake := newTestController(t)
+
+	testBundle.IncrementGeneration()
+	controller.syncBundles()
+
+	controller.syncBundles() // the delivery result was not reported yet
+	lastSent(t, fake, 1)
+}
+
+func TestSupersededBundleIsNotResent(t *testing.T) {
+	controller, testBundle, fake := newTestController(t)
+
+	testBundle.IncrementGeneration()
+	controller.syncBundles()
+	superseded := lastSent(t, fake, 1)
+
+	testBundle.IncrementGeneration()
+	controller.syncBundles()
+	newer := lastSent(t, fake, 2)
+
+	// the queue replaced the first generation with the second one
+	superseded.ReportDeliveryResult(transport.ErrMessageSuperseded)
+	controller.syncBundles()
+	lastSent(t, fake, 2)
+
+	newer.ReportDeliveryResult(nil)
+	controller.syncBundles()
+	lastSent(t, fake, 2)
+}
+
+func TestFailureOfAnOlderGenerationDoesNotResendIt(t *testing.T) {
+	controller, testBundle, fake := newTestController(t)
+
+	testBundle.IncrementGeneration()
+	controller.syncBundles()
+	older := lastSent(t, fake, 1)
+
+	testBundle.IncrementGeneration()
+	controller.syncBundles()
+	newer := lastSent(t, fake, 2)
+
+	older.ReportDeliveryResult(errDeliveryFailed)
+	newer.ReportDeliveryResult(nil)
+
+	controller.syncBundles()
+	lastSent(t, fake, 2)
+}
+
+func TestResyncCommandResendsAllBundlesWithANewGeneration(t *testing.T) {
+	controller, testBundle, fake := newTestController(t)
+	otherBundle := bundle.NewClustersPerPolicyBundle(testLeafHubName, 0)
+	controller.orderedBundleCollection = append(controller.orderedBundleCollection,
+		NewBundleCollectionEntry(testLeafHubName+".other", otherBundle, func() bool { return true }))
+
+	controller.subscribeToCommands()
+	controller.setRunning(true)
+
+	testBundle.IncrementGeneration()
+	otherBundle.IncrementGeneration()
+	controller.syncBundles()
+
+	for _, message := range fake.Sent() {
+		message.ReportDeliveryResult(nil)
+	}
+
+	if err := fake.Dispatch(&transport.Command{ID: "1", Type: transport.CommandTypeResync}); err != nil {
+		t.Fatalf("failed to handle the resync command: %v", err)
+	}
+
+	sent := fake.Sent()
+	if len(sent) != 4 {
+		t.Fatalf("expected both bundles to be resent, got %d sent messages", len(sent))
+	}
+
+	for _, message := range sent[2:] {
+		if message.Version != "2" {
+			t.Fatalf("expected bundle %s to be resent with generation 2, got version %s", message.ID,
+				message.Version)
+		}
+	}
+
+	controller.syncBundles() // the resent generations were dispatched already
+	lastSent(t, fake, 4)
+}
+
+func TestResyncCommandIsIgnoredWhenNotRunning(t *testing.T) {
+	controller, testBundle, fake := newTestController(t)
+	controller.subscribeToCommands()
+
+	if err := fake.Dispatch(&transport.Command{ID: "1", Type: transport.CommandTypeResync}); !errors.Is(err,
+		transport.ErrCommandIgnored) {
+		t.Fatalf("expected %v, got %v", transport.ErrCommandIgnored, err)
+	}
+
+	if generation := testBundle.GetBundleGeneration(); generation != 0 {
+		t.Fatalf("expected the generation not to change, got %d", generation)
+	}
+
+	if sent := fake.Sent(); len(sent) != 0 {
+		t.Fatalf("expected no bundle to be sent, got %d sent messages", len(sent))
+	}
+}
@@ -0,0 +1,18 @@
+package controller
+
+import (
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+)
+
+// localCommandsTransport wraps a transport and subscribes handlers also to commands that are dispatched locally,
+// e.g. by the config controller, so the status controllers handle them like commands received from the hub.
+type localCommandsTransport struct {
+	transport.Transport
+	localCommands *transport.CommandDispatcher
+}
+
+// Subscribe subscribes the handler to commands received by the wrapped transport and to local commands.
+func (t *localCommandsTransport) Subscribe(commandType string, handler transport.CommandHandler) {
+	t.Transport.Subscribe(commandType, handler)
+	t.localCommands.Subscribe(commandType, handler)
+}
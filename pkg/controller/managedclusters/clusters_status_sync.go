@@ -24,7 +24,7 @@ const (
 
 // AddClustersStatusController adds managed clusters status controller to the manager.
 func AddClustersStatusController(mgr ctrl.Manager, transport transport.Transport, syncInterval time.Duration,
-	leafHubName string, hubOfHubsConfig *configv1.Config) error {
+	leafHubName string, hubOfHubsConfig *configv1.Config, bundleEncoder *generic.BundleEncoder) error {
 	createObjFunction := func() bundle.Object { return &clusterv1.ManagedCluster{} }
 	transportBundleKey := fmt.Sprintf("%s.%s", leafHubName, datatypes.ManagedClustersMsgKey)
 
@@ -38,7 +38,7 @@ func AddClustersStatusController(mgr ctrl.Manager, transport transport.Transport
 	}
 
 	if err := generic.NewGenericStatusSyncController(mgr, clusterStatusSyncLogName, transport,
-		managedClusterCleanupFinalizer, bundleCollection, createObjFunction, syncInterval, nil); err != nil {
+		managedClusterCleanupFinalizer, bundleCollection, createObjFunction, syncInterval, nil, bundleEncoder); err != nil {
 		return fmt.Errorf("failed to add controller to the manager - %w", err)
 	}
 
@@ -27,7 +27,7 @@ const (
 
 // AddPoliciesStatusController adds policies status controller to the manager.
 func AddPoliciesStatusController(mgr ctrl.Manager, transport transport.Transport, syncInterval time.Duration,
-	leafHubName string, hubOfHubsConfig *configv1.Config) error {
+	leafHubName string, hubOfHubsConfig *configv1.Config, bundleEncoder *generic.BundleEncoder) error {
 	createObjFunction := func() bundle.Object { return &policiesv1.Policy{} }
 
 	// clusters per policy (base bundle)
@@ -65,7 +65,7 @@ func AddPoliciesStatusController(mgr ctrl.Manager, transport transport.Transport
 	// initialize policy status controller (contains multiple bundles)
 	if err := generic.NewGenericStatusSyncController(mgr, policiesStatusSyncLog, transport, policyCleanupFinalizer,
 		bundleCollection, createObjFunction, syncInterval,
-		predicate.And(hohNamespacePredicate, ownerRefAnnotationPredicate)); err != nil {
+		predicate.And(hohNamespacePredicate, ownerRefAnnotationPredicate), bundleEncoder); err != nil {
 		return fmt.Errorf("failed to add controller to the manager - %w", err)
 	}
 
@@ -0,0 +1,232 @@
+package chaos
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+)
+
+// MiddlewareName is the name the chaos middleware is registered under. it's configured using environment variables.
+const MiddlewareName = "chaos"
+
+// ErrDropped is reported as the delivery result of a message that was dropped by FaultDrop.
+var ErrDropped = errors.New("message dropped by chaos transport")
+
+func init() {
+	transport.RegisterMiddleware(MiddlewareName, func(next transport.Transport, log logr.Logger) (transport.Transport,
+		error) {
+		config, err := readEnvVars()
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize chaos transport - %w", err)
+		}
+
+		return NewTransport(next, config, log)
+	})
+}
+
+// Transport wraps a transport and injects faults into the calls passed to it, for resilience testing. a message that
+// is delayed or held back is passed on by Stop at the latest.
+type Transport struct {
+	transport     transport.Transport
+	config        Config
+	log           logr.Logger
+	random        *rand.Rand
+	sendScript    []Fault
+	versionScript []Fault
+	// heldMessage is the message held back by FaultReorder, it's passed on after the next message.
+	heldMessage *transport.Message
+	// versions holds the current and the preceding version per key, as returned by the wrapped transport.
+	versions       map[string]versionHistory
+	injectedFaults map[Fault]int
+	delayedSends   sync.WaitGroup
+	lock           sync.Mutex
+	stopOnce       sync.Once
+}
+
+type versionHistory struct {
+	current  string
+	previous string
+}
+
+// NewTransport creates a new instance of Transport that wraps the given transport and injects faults according to
+// the given config.
+func NewTransport(transportToWrap transport.Transport, config *Config, log logr.Logger) (*Transport, error) {
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("failed to initialize chaos transport - %w", err)
+	}
+
+	return &Transport{
+		transport:      transportToWrap,
+		config:         *config,
+		log:            log,
+		random:         rand.New(rand.NewSource(config.Seed)),
+		sendScript:     append([]Fault{}, config.SendScript...),
+		versionScript:  append([]Fault{}, config.VersionScript...),
+		versions:       make(map[string]versionHistory),
+		injectedFaults: make(map[Fault]int),
+	}, nil
+}
+
+// Start function starts the chaos transport.
+func (t *Transport) Start() {}
+
+// Stop function passes on the held back message and waits for the delayed messages to be passed on.
+func (t *Transport) Stop() {
+	t.stopOnce.Do(func() {
+		t.lock.Lock()
+		heldMessage := t.heldMessage
+		t.heldMessage = nil
+		t.lock.Unlock()
+
+		if heldMessage != nil {
+			t.transport.SendAsync(heldMessage)
+		}
+
+		t.delayedSends.Wait()
+	})
+}
+
+// Injected returns the number of times the given fault was injected.
+func (t *Transport) Injected(fault Fault) int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.injectedFaults[fault]
+}
+
+// SendAsync passes the message on to the wrapped transport, after injecting the next send fault.
+func (t *Transport) SendAsync(message *transport.Message) {
+	t.lock.Lock()
+	fault := t.nextFault(&t.sendScript, []Fault{FaultDrop, FaultLose, FaultDelay, FaultDuplicate, FaultReorder},
+		[]float64{t.config.DropProbability, t.config.LoseProbability, t.config.DelayProbability,
+			t.config.DuplicateProbability, t.config.ReorderProbability})
+	delay := time.Duration(t.random.Int63n(int64(t.config.MaxDelay) + 1))
+
+	heldMessage := t.heldMessage
+	if fault == FaultReorder && heldMessage == nil {
+		t.heldMessage = message
+	} else {
+		t.heldMessage = nil
+	}
+	t.lock.Unlock()
+
+	t.logFault(fault, message.ID, message.MsgType, message.Version)
+
+	switch fault {
+	case FaultDrop:
+		message.ReportDeliveryResult(ErrDropped)
+	case FaultLose:
+		message.ReportDeliveryResult(nil)
+	case FaultDelay:
+		t.delayedSends.Add(1)
+
+		time.AfterFunc(delay, func() {
+			defer t.delayedSends.Done()
+			t.transport.SendAsync(message)
+		})
+	case FaultDuplicate:
+		t.transport.SendAsync(duplicate(message))
+		t.transport.SendAsync(message)
+	case FaultReorder:
+		if heldMessage == nil {
+			return // held back until the next message
+		}
+
+		t.transport.SendAsync(message) // a message is already held back, this one is passed on before it
+	default:
+		t.transport.SendAsync(message)
+	}
+
+	if heldMessage != nil {
+		t.transport.SendAsync(heldMessage)
+	}
+}
+
+// GetVersion returns the version of the given id and msgType from the wrapped transport, after injecting the next
+// version fault.
+func (t *Transport) GetVersion(id string, msgType string) string {
+	version := t.transport.GetVersion(id, msgType)
+	key := fmt.Sprintf("%s.%s", id, msgType)
+
+	t.lock.Lock()
+	history := t.versions[key]
+	if version != history.current {
+		history = versionHistory{current: version, previous: history.current}
+		t.versions[key] = history
+	}
+
+	fault := t.nextFault(&t.versionScript, []Fault{FaultStaleVersion, FaultGarbageVersion},
+		[]float64{t.config.StaleVersionProbability, t.config.GarbageVersionProbability})
+	garbage := fmt.Sprintf("garbage-%x", t.random.Uint64())
+	t.lock.Unlock()
+
+	t.logFault(fault, id, msgType, version)
+
+	switch fault {
+	case FaultStaleVersion:
+		return history.previous
+	case FaultGarbageVersion:
+		return garbage
+	default:
+		return version
+	}
+}
+
+// Subscribe subscribes the handler to commands received by the wrapped transport.
+func (t *Transport) Subscribe(commandType string, handler transport.CommandHandler) {
+	t.transport.Subscribe(commandType, handler)
+}
+
+// nextFault returns the next fault of the script, or if the script is exhausted a fault chosen randomly by the given
+// probabilities, and counts it. must be called with the lock held.
+func (t *Transport) nextFault(script *[]Fault, faults []Fault, probabilities []float64) Fault {
+	fault := FaultNone
+
+	if len(*script) > 0 {
+		fault, *script = (*script)[0], (*script)[1:]
+	} else {
+		randomValue := t.random.Float64()
+
+		for i, probability := range probabilities {
+			if randomValue < probability {
+				fault = faults[i]
+				break
+			}
+
+			randomValue -= probability
+		}
+	}
+
+	if fault != FaultNone {
+		t.injectedFaults[fault]++
+	}
+
+	return fault
+}
+
+func (t *Transport) logFault(fault Fault, id string, msgType string, version string) {
+	if fault != FaultNone {
+		t.log.V(1).Info("injecting fault", "fault", fault, "id", id, "type", msgType, "version", version)
+	}
+}
+
+// duplicate returns a copy of the message that doesn't report its delivery result.
+func duplicate(message *transport.Message) *transport.Message {
+	duplicateMessage := &transport.Message{
+		ID:      message.ID,
+		MsgType: message.MsgType,
+		Version: message.Version,
+		Payload: append([]byte{}, message.Payload...),
+	}
+
+	for key, value := range message.Metadata {
+		duplicateMessage.SetMetadata(key, value)
+	}
+
+	return duplicateMessage
+}
@@ -0,0 +1,156 @@
+package chaos
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/transporttest"
+)
+
+const (
+	testMsgType  = "StatusBundle"
+	testMessages = 200
+)
+
+func newTestTransport(t *testing.T, config *Config) (*Transport, *transporttest.Transport) {
+	t.Helper()
+
+	fake := transporttest.NewTransport()
+
+	chaosTransport, err := NewTransport(fake, config, logrtesting.NullLogger{})
+	if err != nil {
+		t.Fatalf("failed to create the chaos transport: %v", err)
+	}
+
+	t.Cleanup(chaosTransport.Stop)
+
+	return chaosTransport, fake
+}
+
+// sendAll sends testMessages messages and returns the outcome of each: dropped, lost, duplicated or passed.
+func sendAll(t *testing.T, config *Config) []string {
+	t.Helper()
+
+	chaosTransport, fake := newTestTransport(t, config)
+	outcomes := make([]string, 0, testMessages)
+
+	for i := 0; i < testMessages; i++ {
+		var result error
+
+		reported := false
+		sentBefore := len(fake.Sent())
+
+		chaosTransport.SendAsync(&transport.Message{
+			ID:      fmt.Sprintf("hub1.bundle%d", i),
+			MsgType: testMsgType,
+			Version: "1",
+			DeliveryCallback: func(err error) {
+				reported = true
+				result = err
+			},
+		})
+
+		switch passedOn := len(fake.Sent()) - sentBefore; {
+		case reported && errors.Is(result, ErrDropped):
+			outcomes = append(outcomes, "dropped")
+		case reported:
+			outcomes = append(outcomes, "lost")
+		case passedOn == 2:
+			outcomes = append(outcomes, "duplicated")
+		default:
+			outcomes = append(outcomes, "passed")
+		}
+	}
+
+	return outcomes
+}
+
+func newSeededConfig(seed int64) *Config {
+	return &Config{
+		DropProbability:      0.2,
+		LoseProbability:      0.2,
+		DuplicateProbability: 0.2,
+		Seed:                 seed,
+	}
+}
+
+func TestSameSeedInjectsTheSameFaults(t *testing.T) {
+	first := sendAll(t, newSeededConfig(42))
+	second := sendAll(t, newSeededConfig(42))
+
+	if fmt.Sprint(first) != fmt.Sprint(second) {
+		t.Fatalf("expected the same faults for the same seed, got\n%v\nand\n%v", first, second)
+	}
+
+	if fmt.Sprint(first) == fmt.Sprint(sendAll(t, newSeededConfig(43))) {
+		t.Fatal("expected different faults for a different seed")
+	}
+}
+
+func TestFaultsAreInjectedByTheirProbabilities(t *testing.T) {
+	counts := make(map[string]int)
+
+	for _, outcome := range sendAll(t, newSeededConfig(42)) {
+		counts[outcome]++
+	}
+
+	// each fault has a probability of 0.2, allow a wide margin around the expected 40 of 200
+	for _, outcome := range []string{"dropped", "lost", "duplicated"} {
+		if counts[outcome] < 20 || counts[outcome] > 60 {
+			t.Fatalf("expected about 40 %s messages, got %v", outcome, counts)
+		}
+	}
+}
+
+func TestScriptIsConsumedBeforeTheProbabilities(t *testing.T) {
+	outcomes := sendAll(t, &Config{
+		SendScript:      []Fault{FaultDrop, FaultNone, FaultDuplicate, FaultLose},
+		DropProbability: 1,
+	})
+
+	expected := []string{"dropped", "passed", "duplicated", "lost", "dropped"}
+	if fmt.Sprint(outcomes[:len(expected)]) != fmt.Sprint(expected) {
+		t.Fatalf("expected %v, got %v", expected, outcomes[:len(expected)])
+	}
+}
+
+func TestReorderedMessageIsPassedOnAfterTheNextOne(t *testing.T) {
+	chaosTransport, fake := newTestTransport(t, &Config{SendScript: []Fault{FaultReorder}})
+
+	for _, id := range []string{"hub1.first", "hub1.second"} {
+		chaosTransport.SendAsync(&transport.Message{ID: id, MsgType: testMsgType, Version: "1"})
+	}
+
+	sent := fake.Sent()
+	if len(sent) != 2 || sent[0].ID != "hub1.second" || sent[1].ID != "hub1.first" {
+		t.Fatalf("expected the first message to be passed on after the second one, got %v", sent)
+	}
+}
+
+func TestVersionFaults(t *testing.T) {
+	chaosTransport, fake := newTestTransport(t, &Config{
+		VersionScript: []Fault{FaultNone, FaultNone, FaultStaleVersion, FaultGarbageVersion},
+	})
+
+	fake.SetVersion("hub1.policies", testMsgType, "1")
+	chaosTransport.GetVersion("hub1.policies", testMsgType)
+
+	fake.SetVersion("hub1.policies", testMsgType, "2")
+
+	for _, expected := range []string{"2", "1"} {
+		if version := chaosTransport.GetVersion("hub1.policies", testMsgType); version != expected {
+			t.Fatalf("expected version %s, got %s", expected, version)
+		}
+	}
+
+	if version := chaosTransport.GetVersion("hub1.policies", testMsgType); version == "2" || version == "1" {
+		t.Fatalf("expected a garbage version, got %s", version)
+	}
+
+	if injected := chaosTransport.Injected(FaultStaleVersion); injected != 1 {
+		t.Fatalf("expected 1 stale version, got %d", injected)
+	}
+}
@@ -0,0 +1,142 @@
+package chaos
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	envVarChaosDropProbability           = "CHAOS_DROP_PROBABILITY"
+	envVarChaosLoseProbability           = "CHAOS_LOSE_PROBABILITY"
+	envVarChaosDelayProbability          = "CHAOS_DELAY_PROBABILITY"
+	envVarChaosMaxDelay                  = "CHAOS_MAX_DELAY"
+	envVarChaosDuplicateProbability      = "CHAOS_DUPLICATE_PROBABILITY"
+	envVarChaosReorderProbability        = "CHAOS_REORDER_PROBABILITY"
+	envVarChaosStaleVersionProbability   = "CHAOS_STALE_VERSION_PROBABILITY"
+	envVarChaosGarbageVersionProbability = "CHAOS_GARBAGE_VERSION_PROBABILITY"
+	envVarChaosSendScript                = "CHAOS_SEND_SCRIPT"
+	envVarChaosVersionScript             = "CHAOS_VERSION_SCRIPT"
+	envVarChaosSeed                      = "CHAOS_SEED"
+	defaultMaxDelay                      = 5 * time.Second
+)
+
+var (
+	errEnvVarWrongType    = errors.New("wrong type of environment variable")
+	errIllegalConfig      = errors.New("illegal chaos configuration")
+	errIllegalProbability = fmt.Errorf("%w: probabilities must be between 0 and 1", errIllegalConfig)
+)
+
+// Config configures the faults that are injected. the scripts are consumed first, one fault per call, and once a
+// script is exhausted the faults are chosen randomly by their probabilities. the probabilities of the send faults
+// and the probabilities of the version faults must each add up to at most 1.
+type Config struct {
+	DropProbability           float64
+	LoseProbability           float64
+	DelayProbability          float64
+	MaxDelay                  time.Duration
+	DuplicateProbability      float64
+	ReorderProbability        float64
+	StaleVersionProbability   float64
+	GarbageVersionProbability float64
+	// SendScript holds the faults injected into the first SendAsync calls, in order.
+	SendScript []Fault
+	// VersionScript holds the faults injected into the first GetVersion calls, in order.
+	VersionScript []Fault
+	// Seed seeds the random choice of the faults and delays, so a run can be repeated.
+	Seed int64
+}
+
+// validate validates the config and sets the defaults.
+func (config *Config) validate() error {
+	if config.MaxDelay == 0 {
+		config.MaxDelay = defaultMaxDelay
+	}
+
+	if config.MaxDelay < 0 {
+		return fmt.Errorf("%w: max delay must not be negative", errIllegalConfig)
+	}
+
+	sendProbabilities := []float64{config.DropProbability, config.LoseProbability, config.DelayProbability,
+		config.DuplicateProbability, config.ReorderProbability}
+	versionProbabilities := []float64{config.StaleVersionProbability, config.GarbageVersionProbability}
+
+	for _, probabilities := range [][]float64{sendProbabilities, versionProbabilities} {
+		sum := 0.0
+
+		for _, probability := range probabilities {
+			if probability < 0 || probability > 1 {
+				return errIllegalProbability
+			}
+
+			sum += probability
+		}
+
+		if sum > 1 {
+			return fmt.Errorf("%w: the send and the version probabilities must each add up to at most 1",
+				errIllegalConfig)
+		}
+	}
+
+	for _, fault := range config.SendScript {
+		if !isOneOf(fault, sendFaults) {
+			return fmt.Errorf("%w: %s in send script", errUnknownFault, fault)
+		}
+	}
+
+	for _, fault := range config.VersionScript {
+		if !isOneOf(fault, versionFaults) {
+			return fmt.Errorf("%w: %s in version script", errUnknownFault, fault)
+		}
+	}
+
+	return nil
+}
+
+func readEnvVars() (*Config, error) {
+	config := &Config{Seed: time.Now().UnixNano()}
+
+	for envVar, probability := range map[string]*float64{
+		envVarChaosDropProbability:           &config.DropProbability,
+		envVarChaosLoseProbability:           &config.LoseProbability,
+		envVarChaosDelayProbability:          &config.DelayProbability,
+		envVarChaosDuplicateProbability:      &config.DuplicateProbability,
+		envVarChaosReorderProbability:        &config.ReorderProbability,
+		envVarChaosStaleVersionProbability:   &config.StaleVersionProbability,
+		envVarChaosGarbageVersionProbability: &config.GarbageVersionProbability,
+	} {
+		if value := os.Getenv(envVar); value != "" {
+			var err error
+			if *probability, err = strconv.ParseFloat(value, 64); err != nil {
+				return nil, fmt.Errorf("%w: %s must be a number", errEnvVarWrongType, envVar)
+			}
+		}
+	}
+
+	if maxDelayStr := os.Getenv(envVarChaosMaxDelay); maxDelayStr != "" {
+		var err error
+		if config.MaxDelay, err = time.ParseDuration(maxDelayStr); err != nil {
+			return nil, fmt.Errorf("%w: %s must be a duration", errEnvVarWrongType, envVarChaosMaxDelay)
+		}
+	}
+
+	if seedStr := os.Getenv(envVarChaosSeed); seedStr != "" {
+		var err error
+		if config.Seed, err = strconv.ParseInt(seedStr, 10, 64); err != nil {
+			return nil, fmt.Errorf("%w: %s must be an integer", errEnvVarWrongType, envVarChaosSeed)
+		}
+	}
+
+	var err error
+	if config.SendScript, err = parseScript(os.Getenv(envVarChaosSendScript), sendFaults); err != nil {
+		return nil, fmt.Errorf("failed to parse %s - %w", envVarChaosSendScript, err)
+	}
+
+	if config.VersionScript, err = parseScript(os.Getenv(envVarChaosVersionScript), versionFaults); err != nil {
+		return nil, fmt.Errorf("failed to parse %s - %w", envVarChaosVersionScript, err)
+	}
+
+	return config, nil
+}
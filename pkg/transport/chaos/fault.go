@@ -0,0 +1,76 @@
+package chaos
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Fault is a fault that is injected into a single SendAsync or GetVersion call.
+type Fault string
+
+const (
+	// FaultNone passes the call on as is.
+	FaultNone Fault = "none"
+	// FaultDrop drops the message and reports the delivery as failed.
+	FaultDrop Fault = "drop"
+	// FaultLose drops the message and reports it as delivered, like a transport that loses a message silently.
+	FaultLose Fault = "lose"
+	// FaultDelay passes the message on after a random delay up to the max delay.
+	FaultDelay Fault = "delay"
+	// FaultDuplicate passes the message on twice. the delivery result is reported once.
+	FaultDuplicate Fault = "duplicate"
+	// FaultReorder holds the message back and passes it on after the next message.
+	FaultReorder Fault = "reorder"
+	// FaultStaleVersion makes GetVersion return the version that preceded the current one, or "" if there is none.
+	FaultStaleVersion Fault = "staleVersion"
+	// FaultGarbageVersion makes GetVersion return a version that is not a valid version.
+	FaultGarbageVersion Fault = "garbageVersion"
+)
+
+var errUnknownFault = errors.New("unknown fault")
+
+// sendFaults are the faults that apply to SendAsync, versionFaults are the faults that apply to GetVersion.
+var (
+	sendFaults    = []Fault{FaultNone, FaultDrop, FaultLose, FaultDelay, FaultDuplicate, FaultReorder}
+	versionFaults = []Fault{FaultNone, FaultStaleVersion, FaultGarbageVersion}
+)
+
+// parseScript parses a comma separated list of faults, e.g. "none,drop,duplicate". only the given faults are allowed.
+func parseScript(script string, allowedFaults []Fault) ([]Fault, error) {
+	faults := make([]Fault, 0)
+
+	for _, name := range strings.Split(script, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if !isOneOf(Fault(name), allowedFaults) {
+			return nil, fmt.Errorf("%w: %s (allowed faults: %s)", errUnknownFault, name, joinFaults(allowedFaults))
+		}
+
+		faults = append(faults, Fault(name))
+	}
+
+	return faults, nil
+}
+
+func isOneOf(fault Fault, faults []Fault) bool {
+	for _, f := range faults {
+		if f == fault {
+			return true
+		}
+	}
+
+	return false
+}
+
+func joinFaults(faults []Fault) string {
+	names := make([]string, 0, len(faults))
+	for _, fault := range faults {
+		names = append(names, string(fault))
+	}
+
+	return strings.Join(names, ", ")
+}
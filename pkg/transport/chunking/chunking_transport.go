@@ -0,0 +1,202 @@
+package chunking
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+)
+
+const (
+	// EnvVarBundleMaxChunkSize is the environment variable that holds the max size in bytes of a message payload.
+	// bigger payloads are split into chunks.
+	EnvVarBundleMaxChunkSize = "BUNDLE_MAX_CHUNK_SIZE"
+	// ChunkMsgTypeSuffix is appended to the message type of a bundle to get the message type of its chunks.
+	ChunkMsgTypeSuffix = "Chunk"
+	// MetadataKeyChunked is the message metadata key that marks a message whose payload is a manifest.
+	MetadataKeyChunked = "chunked"
+	// MetadataKeyChunkIndex is the message metadata key that holds the index of a chunk, starting from 0.
+	MetadataKeyChunkIndex = "chunkIndex"
+	// MetadataKeyChunkCount is the message metadata key that holds the number of chunks of the bundle.
+	MetadataKeyChunkCount = "chunkCount"
+)
+
+var errEnvVarIllegalValue = errors.New("illegal value of environment variable")
+
+// MiddlewareName is the name the chunking middleware is registered under.
+const MiddlewareName = "chunking"
+
+func init() {
+	transport.RegisterMiddleware(MiddlewareName, func(next transport.Transport, log logr.Logger) (transport.Transport,
+		error) {
+		return NewTransport(next)
+	})
+}
+
+// Transport wraps a transport and splits messages with a payload bigger than the max chunk size into chunks that are
+// followed by a manifest.
+type Transport struct {
+	transport    transport.Transport
+	maxChunkSize int
+}
+
+// NewTransport creates a new instance of Transport that wraps the given transport.
+func NewTransport(transportToWrap transport.Transport) (*Transport, error) {
+	maxChunkSize, err := readEnvVars()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize chunking - %w", err)
+	}
+
+	return &Transport{
+		transport:    transportToWrap,
+		maxChunkSize: maxChunkSize,
+	}, nil
+}
+
+func readEnvVars() (int, error) {
+	maxChunkSize, err := strconv.Atoi(os.Getenv(EnvVarBundleMaxChunkSize))
+	if err != nil || maxChunkSize <= 0 {
+		return 0, fmt.Errorf("%w: %s must be a positive number of bytes", errEnvVarIllegalValue,
+			EnvVarBundleMaxChunkSize)
+	}
+
+	return maxChunkSize, nil
+}
+
+// SendAsync sends the message using the wrapped transport, split into chunks and a manifest if its payload is bigger
+// than the max chunk size. each chunk is sent once the previous chunk was delivered, so the chunks of a bundle never
+// fill the queue of the wrapped transport, and the manifest is sent once all the chunks were delivered. the delivery
+// result of a split message is reported once the manifest was delivered, or with the first error.
+func (t *Transport) SendAsync(message *transport.Message) {
+	if len(message.Payload) <= t.maxChunkSize {
+		t.transport.SendAsync(message)
+		return
+	}
+
+	chunks := t.split(message.Payload)
+	manifest := &Manifest{
+		ID:       message.ID,
+		MsgType:  message.MsgType,
+		Version:  message.Version,
+		Size:     len(message.Payload),
+		Checksum: checksum(message.Payload),
+		Chunks:   make([]Chunk, 0, len(chunks)),
+	}
+
+	for i, chunk := range chunks {
+		manifest.Chunks = append(manifest.Chunks, Chunk{
+			ID:       chunkID(message.ID, i),
+			Size:     len(chunk),
+			Checksum: checksum(chunk),
+		})
+	}
+
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		message.ReportDeliveryResult(fmt.Errorf("failed to marshal manifest - %w", err))
+		return
+	}
+
+	// the manifest keeps the id, type, version and metadata of the message, so GetVersion works as without chunking.
+	// it's a copy, the message of the caller is not changed.
+	manifestMessage := &transport.Message{
+		ID:               message.ID,
+		MsgType:          message.MsgType,
+		Version:          message.Version,
+		Payload:          manifestBytes,
+		DeliveryCallback: message.DeliveryCallback,
+	}
+
+	for key, value := range message.Metadata {
+		manifestMessage.SetMetadata(key, value)
+	}
+
+	manifestMessage.SetMetadata(MetadataKeyChunked, strconv.FormatBool(true))
+
+	sender := &chunkSender{
+		transport: t.transport,
+		message:   manifestMessage,
+		chunks:    chunks,
+		manifest:  manifest,
+	}
+
+	sender.sendNext(nil)
+}
+
+// GetVersion returns the version from the wrapped transport.
+func (t *Transport) GetVersion(id string, msgType string) string {
+	return t.transport.GetVersion(id, msgType)
+}
+
+// Subscribe subscribes the handler to commands received by the wrapped transport.
+func (t *Transport) Subscribe(commandType string, handler transport.CommandHandler) {
+	t.transport.Subscribe(commandType, handler)
+}
+
+func (t *Transport) split(payload []byte) [][]byte {
+	chunks := make([][]byte, 0, (len(payload)+t.maxChunkSize-1)/t.maxChunkSize)
+
+	for start := 0; start < len(payload); start += t.maxChunkSize {
+		end := start + t.maxChunkSize
+		if end > len(payload) {
+			end = len(payload)
+		}
+
+		chunks = append(chunks, payload[start:end])
+	}
+
+	return chunks
+}
+
+func chunkID(id string, index int) string {
+	return fmt.Sprintf("%s-chunk-%d", id, index)
+}
+
+func checksum(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// chunkSender sends the chunks of a message one after the other, followed by the manifest.
+type chunkSender struct {
+	transport transport.Transport
+	message   *transport.Message
+	chunks    [][]byte
+	manifest  *Manifest
+	next      int
+}
+
+// sendNext is the delivery callback of the previous chunk. sends the next chunk, or the manifest after the last chunk.
+// if a chunk was not delivered, e.g. superseded by a chunk of a newer version, its error is reported and the rest of
+// the message is not sent.
+func (sender *chunkSender) sendNext(err error) {
+	if err != nil {
+		sender.message.ReportDeliveryResult(err)
+		return
+	}
+
+	if sender.next == len(sender.chunks) {
+		sender.transport.SendAsync(sender.message)
+		return
+	}
+
+	chunkMessage := &transport.Message{
+		ID:               sender.manifest.Chunks[sender.next].ID,
+		MsgType:          sender.message.MsgType + ChunkMsgTypeSuffix,
+		Version:          sender.message.Version,
+		Payload:          sender.chunks[sender.next],
+		DeliveryCallback: sender.sendNext,
+	}
+	chunkMessage.SetMetadata(MetadataKeyChunkIndex, strconv.Itoa(sender.next))
+	chunkMessage.SetMetadata(MetadataKeyChunkCount, strconv.Itoa(len(sender.chunks)))
+
+	sender.next++
+
+	sender.transport.SendAsync(chunkMessage)
+}
@@ -0,0 +1,158 @@
+package chunking
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/transporttest"
+)
+
+const (
+	testMsgType       = "StatusBundle"
+	testID            = "hub1.policies"
+	testChunkSize     = 4
+	testChunkCount    = 10
+	testQueueCapacity = 2 // smaller than the number of chunks of the test bundle
+	testTimeout       = 5 * time.Second
+)
+
+var errDeliveryFailed = errors.New("delivery failed")
+
+func sendBundle(t *testing.T, chunkingTransport *Transport, payload []byte) error {
+	t.Helper()
+
+	resultChan := make(chan error, 1)
+
+	chunkingTransport.SendAsync(&transport.Message{
+		ID:               testID,
+		MsgType:          testMsgType,
+		Version:          "1",
+		Payload:          payload,
+		DeliveryCallback: func(err error) { resultChan <- err },
+	})
+
+	select {
+	case err := <-resultChan:
+		return err
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for the delivery result")
+		return nil
+	}
+}
+
+func TestChunksDoNotOverflowTheQueue(t *testing.T) {
+	fake := transporttest.NewDeliveringTransport(t, testQueueCapacity)
+	chunkingTransport := &Transport{transport: fake, maxChunkSize: testChunkSize}
+	payload := bytes.Repeat([]byte("x"), testChunkSize*testChunkCount)
+
+	if err := sendBundle(t, chunkingTransport, payload); err != nil {
+		t.Fatalf("failed to send the bundle: %v", err)
+	}
+
+	delivered := fake.Delivered()
+	if len(delivered) != testChunkCount+1 {
+		t.Fatalf("expected %d chunks and the manifest, got %d messages", testChunkCount, len(delivered))
+	}
+
+	var reassembled []byte
+
+	for i, message := range delivered[:testChunkCount] {
+		if message.ID != chunkID(testID, i) || message.Metadata[MetadataKeyChunkIndex] != strconv.Itoa(i) {
+			t.Fatalf("expected chunk %d, got %s", i, message.ID)
+		}
+
+		reassembled = append(reassembled, message.Payload...)
+	}
+
+	if !bytes.Equal(reassembled, payload) {
+		t.Fatal("the reassembled chunks differ from the payload")
+	}
+
+	manifestMessage := delivered[testChunkCount]
+	manifest := &Manifest{}
+
+	if err := json.Unmarshal(manifestMessage.Payload, manifest); err != nil {
+		t.Fatalf("failed to parse the manifest: %v", err)
+	}
+
+	if manifestMessage.ID != testID || manifestMessage.Metadata[MetadataKeyChunked] != "true" ||
+		len(manifest.Chunks) != testChunkCount || manifest.Checksum != checksum(payload) {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+
+	if dropped := fake.DroppedCount(); dropped != 0 {
+		t.Fatalf("expected no dropped messages, %d were dropped", dropped)
+	}
+}
+
+func TestFailedChunkStopsTheBundle(t *testing.T) {
+	fake := transporttest.NewDeliveringTransport(t, testQueueCapacity)
+	fake.FailDelivery(chunkID(testID, 3), errDeliveryFailed)
+	chunkingTransport := &Transport{transport: fake, maxChunkSize: testChunkSize}
+
+	if err := sendBundle(t, chunkingTransport, bytes.Repeat([]byte("x"), testChunkSize*testChunkCount)); !errors.Is(
+		err, errDeliveryFailed) {
+		t.Fatalf("expected %v, got %v", errDeliveryFailed, err)
+	}
+
+	if delivered := fake.Delivered(); len(delivered) != 3 {
+		t.Fatalf("expected only the chunks before the failed chunk to be sent, got %d messages", len(delivered))
+	}
+}
+
+func TestSmallPayloadIsNotSplit(t *testing.T) {
+	fake := transporttest.NewDeliveringTransport(t, testQueueCapacity)
+	chunkingTransport := &Transport{transport: fake, maxChunkSize: testChunkSize}
+
+	if err := sendBundle(t, chunkingTransport, []byte("abc")); err != nil {
+		t.Fatalf("failed to send the bundle: %v", err)
+	}
+
+	if delivered := fake.Delivered(); len(delivered) != 1 || delivered[0].Metadata != nil {
+		t.Fatalf("expected the message to be sent as is, got %d messages", len(delivered))
+	}
+}
+
+func TestSplitMessageOfTheCallerIsNotChanged(t *testing.T) {
+	fake := transporttest.NewDeliveringTransport(t, testQueueCapacity)
+	chunkingTransport := &Transport{transport: fake, maxChunkSize: testChunkSize}
+	payload := bytes.Repeat([]byte("x"), testChunkSize*testChunkCount)
+	resultChan := make(chan error, 1)
+
+	message := &transport.Message{
+		ID:               testID,
+		MsgType:          testMsgType,
+		Version:          "1",
+		Metadata:         map[string]string{"codec": "json"},
+		Payload:          payload,
+		DeliveryCallback: func(err error) { resultChan <- err },
+	}
+
+	chunkingTransport.SendAsync(message)
+
+	select {
+	case err := <-resultChan:
+		if err != nil {
+			t.Fatalf("failed to send the bundle: %v", err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for the delivery result")
+	}
+
+	if !bytes.Equal(message.Payload, payload) {
+		t.Fatal("expected the payload of the message not to be replaced by the manifest")
+	}
+
+	if len(message.Metadata) != 1 || message.Metadata[MetadataKeyChunked] != "" {
+		t.Fatalf("expected the metadata of the message not to change, got %v", message.Metadata)
+	}
+
+	if manifestMessage := fake.Delivered()[testChunkCount]; manifestMessage.Metadata["codec"] != "json" {
+		t.Fatalf("expected the manifest to keep the metadata of the message, got %v", manifestMessage.Metadata)
+	}
+}
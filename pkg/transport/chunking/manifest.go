@@ -0,0 +1,24 @@
+package chunking
+
+// Manifest describes a bundle that was split into chunks. it is sent with the id, type and version of the bundle
+// after all of its chunks, so the receiver can reassemble the bundle and detect missing chunks.
+type Manifest struct {
+	ID      string `json:"id"`
+	MsgType string `json:"msgType"`
+	Version string `json:"version"`
+	// Size is the size in bytes of the whole payload.
+	Size int `json:"size"`
+	// Checksum is the hex encoded sha256 of the whole payload.
+	Checksum string  `json:"checksum"`
+	Chunks   []Chunk `json:"chunks"`
+}
+
+// Chunk describes a single chunk of a bundle. chunks are sent with the version of the bundle and the chunk message
+// type, and are listed in the manifest in the order they have to be concatenated.
+type Chunk struct {
+	ID string `json:"id"`
+	// Size is the size in bytes of the chunk.
+	Size int `json:"size"`
+	// Checksum is the hex encoded sha256 of the chunk.
+	Checksum string `json:"checksum"`
+}
@@ -0,0 +1,119 @@
+package transport
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// CircuitBreakerState is the state of a circuit breaker.
+type CircuitBreakerState int
+
+const (
+	// CircuitBreakerClosed means requests are allowed.
+	CircuitBreakerClosed CircuitBreakerState = iota
+	// CircuitBreakerHalfOpen means a single trial request is allowed after the cooldown period.
+	CircuitBreakerHalfOpen
+	// CircuitBreakerOpen means requests are not allowed until the cooldown period passes.
+	CircuitBreakerOpen
+)
+
+func (state CircuitBreakerState) String() string {
+	switch state {
+	case CircuitBreakerClosed:
+		return "closed"
+	case CircuitBreakerHalfOpen:
+		return "half-open"
+	case CircuitBreakerOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// NewCircuitBreaker creates a new instance of CircuitBreaker. name identifies the breaker in logs and metrics.
+func NewCircuitBreaker(name string, failureThreshold int, cooldown time.Duration,
+	log logr.Logger) *CircuitBreaker {
+	circuitBreakerStateGauge.WithLabelValues(name).Set(float64(CircuitBreakerClosed))
+
+	return &CircuitBreaker{
+		name:             name,
+		failureThreshold: failureThreshold,
+		cooldown:         cooldown,
+		state:            CircuitBreakerClosed,
+		log:              log,
+		lock:             sync.Mutex{},
+	}
+}
+
+// CircuitBreaker stops requests to a failing destination after a number of consecutive failures, and allows a trial
+// request once a cooldown period passes.
+type CircuitBreaker struct {
+	name                string
+	failureThreshold    int
+	cooldown            time.Duration
+	state               CircuitBreakerState
+	consecutiveFailures int
+	openedAt            time.Time
+	log                 logr.Logger
+	lock                sync.Mutex
+}
+
+// WaitDuration returns 0 if a request is allowed, otherwise the time left until a trial request is allowed.
+func (cb *CircuitBreaker) WaitDuration() time.Duration {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	if cb.state != CircuitBreakerOpen {
+		return 0
+	}
+
+	if waitDuration := time.Until(cb.openedAt.Add(cb.cooldown)); waitDuration > 0 {
+		return waitDuration
+	}
+
+	cb.setState(CircuitBreakerHalfOpen)
+
+	return 0
+}
+
+// RecordResult updates the breaker state according to the result of a request.
+func (cb *CircuitBreaker) RecordResult(err error) {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	if err == nil {
+		cb.consecutiveFailures = 0
+		cb.setState(CircuitBreakerClosed)
+
+		return
+	}
+
+	cb.consecutiveFailures++
+
+	if cb.state == CircuitBreakerHalfOpen || cb.consecutiveFailures >= cb.failureThreshold {
+		cb.openedAt = time.Now()
+		cb.setState(CircuitBreakerOpen)
+	}
+}
+
+// State returns the current state of the breaker.
+func (cb *CircuitBreaker) State() CircuitBreakerState {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	return cb.state
+}
+
+func (cb *CircuitBreaker) setState(state CircuitBreakerState) {
+	if cb.state == state {
+		return
+	}
+
+	cb.log.Info(fmt.Sprintf("circuit breaker '%s' changed state from '%s' to '%s'", cb.name, cb.state, state),
+		"consecutive failures", cb.consecutiveFailures)
+	cb.state = state
+	circuitBreakerStateGauge.WithLabelValues(cb.name).Set(float64(state))
+}
@@ -0,0 +1,87 @@
+package transport
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+)
+
+const testCooldown = 50 * time.Millisecond
+
+var errRequestFailed = errors.New("request failed")
+
+func newTestCircuitBreaker(t *testing.T, failureThreshold int) *CircuitBreaker {
+	t.Helper()
+
+	return NewCircuitBreaker(t.Name(), failureThreshold, testCooldown, logrtesting.NullLogger{})
+}
+
+func expectState(t *testing.T, circuitBreaker *CircuitBreaker, expected CircuitBreakerState) {
+	t.Helper()
+
+	if state := circuitBreaker.State(); state != expected {
+		t.Fatalf("expected the circuit breaker to be %s, got %s", expected, state)
+	}
+}
+
+func TestCircuitBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	circuitBreaker := newTestCircuitBreaker(t, 3)
+
+	circuitBreaker.RecordResult(errRequestFailed)
+	circuitBreaker.RecordResult(errRequestFailed)
+	circuitBreaker.RecordResult(nil) // resets the consecutive failures
+	circuitBreaker.RecordResult(errRequestFailed)
+	circuitBreaker.RecordResult(errRequestFailed)
+	expectState(t, circuitBreaker, CircuitBreakerClosed)
+
+	circuitBreaker.RecordResult(errRequestFailed)
+	expectState(t, circuitBreaker, CircuitBreakerOpen)
+
+	if waitDuration := circuitBreaker.WaitDuration(); waitDuration <= 0 || waitDuration > testCooldown {
+		t.Fatalf("expected to wait up to the cooldown while open, got %s", waitDuration)
+	}
+}
+
+func TestCircuitBreakerClosesAfterASuccessfulTrial(t *testing.T) {
+	circuitBreaker := newTestCircuitBreaker(t, 1)
+
+	circuitBreaker.RecordResult(errRequestFailed)
+	expectState(t, circuitBreaker, CircuitBreakerOpen)
+
+	time.Sleep(testCooldown)
+
+	if waitDuration := circuitBreaker.WaitDuration(); waitDuration != 0 {
+		t.Fatalf("expected a trial request to be allowed after the cooldown, got a wait of %s", waitDuration)
+	}
+
+	expectState(t, circuitBreaker, CircuitBreakerHalfOpen)
+
+	circuitBreaker.RecordResult(nil)
+	expectState(t, circuitBreaker, CircuitBreakerClosed)
+
+	if waitDuration := circuitBreaker.WaitDuration(); waitDuration != 0 {
+		t.Fatalf("expected requests to be allowed when closed, got a wait of %s", waitDuration)
+	}
+}
+
+func TestCircuitBreakerReopensAfterAFailedTrial(t *testing.T) {
+	circuitBreaker := newTestCircuitBreaker(t, 3)
+
+	for i := 0; i < 3; i++ {
+		circuitBreaker.RecordResult(errRequestFailed)
+	}
+
+	time.Sleep(testCooldown)
+	circuitBreaker.WaitDuration()
+	expectState(t, circuitBreaker, CircuitBreakerHalfOpen)
+
+	// a single failed trial reopens the breaker, regardless of the failure threshold
+	circuitBreaker.RecordResult(errRequestFailed)
+	expectState(t, circuitBreaker, CircuitBreakerOpen)
+
+	if waitDuration := circuitBreaker.WaitDuration(); waitDuration <= 0 {
+		t.Fatal("expected the cooldown to start again after the failed trial")
+	}
+}
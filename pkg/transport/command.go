@@ -0,0 +1,117 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	// CommandTypeResendBundle asks to resend a single bundle, the payload is ResendBundleCommand.
+	CommandTypeResendBundle = "ResendBundle"
+	// CommandTypeResync asks to resend all the bundles, the command has no payload.
+	CommandTypeResync = "Resync"
+	// CommandTypeChangeSyncInterval asks to change the periodic sync interval, the payload is
+	// ChangeSyncIntervalCommand.
+	CommandTypeChangeSyncInterval = "ChangeSyncInterval"
+)
+
+var (
+	// ErrNoCommandHandler is returned when a command is received and no handler is subscribed to its type.
+	ErrNoCommandHandler = errors.New("no handler is subscribed to the command type")
+	// ErrCommandIgnored is returned by a handler that didn't handle the command, e.g. since this instance isn't the
+	// leader. transports don't acknowledge a command that all the handlers ignored, so it can be handled later.
+	ErrCommandIgnored = errors.New("command was ignored")
+)
+
+// Command is a control message sent by the hub of hubs to the leaf hub.
+type Command struct {
+	ID   string `json:"id,omitempty"`
+	Type string `json:"type"`
+	// Payload holds the json encoded arguments of the command, according to its type.
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// ResendBundleCommand is the payload of a CommandTypeResendBundle command.
+type ResendBundleCommand struct {
+	// BundleID is the transport id of the bundle to resend, <leaf hub name>.<bundle type>.
+	BundleID string `json:"bundleId"`
+}
+
+// ChangeSyncIntervalCommand is the payload of a CommandTypeChangeSyncInterval command.
+type ChangeSyncIntervalCommand struct {
+	// SyncInterval is the new periodic sync interval as a duration string, e.g. 5s.
+	SyncInterval string `json:"syncInterval"`
+}
+
+// CommandHandler handles a command received from the hub of hubs.
+type CommandHandler func(command *Command) error
+
+// CommandDispatcher dispatches received commands to the handlers that are subscribed to their type. transports that
+// can receive commands use it to implement Subscribe.
+type CommandDispatcher struct {
+	handlers map[string][]CommandHandler
+	lock     sync.RWMutex
+}
+
+// NewCommandDispatcher creates a new instance of CommandDispatcher.
+func NewCommandDispatcher() *CommandDispatcher {
+	return &CommandDispatcher{
+		handlers: make(map[string][]CommandHandler),
+		lock:     sync.RWMutex{},
+	}
+}
+
+// Subscribe adds a handler for commands of the given type.
+func (d *CommandDispatcher) Subscribe(commandType string, handler CommandHandler) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.handlers[commandType] = append(d.handlers[commandType], handler)
+}
+
+// Dispatch invokes all the handlers that are subscribed to the command type. returns ErrNoCommandHandler if there
+// are none, ErrCommandIgnored if all of them ignored the command, otherwise the first error returned by a handler
+// that handled it.
+func (d *CommandDispatcher) Dispatch(command *Command) error {
+	d.lock.RLock()
+	handlers := d.handlers[command.Type]
+	d.lock.RUnlock()
+
+	if len(handlers) == 0 {
+		return fmt.Errorf("%w: %s", ErrNoCommandHandler, command.Type)
+	}
+
+	handled := false
+
+	var firstErr error
+
+	for _, handler := range handlers {
+		err := handler(command)
+		if errors.Is(err, ErrCommandIgnored) {
+			continue
+		}
+
+		handled = true
+
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if !handled {
+		return fmt.Errorf("%w: %s", ErrCommandIgnored, command.Type)
+	}
+
+	return firstErr
+}
+
+// UnmarshalPayload decodes the command payload into the given typed command, e.g. ResendBundleCommand.
+func (command *Command) UnmarshalPayload(typedCommand interface{}) error {
+	if err := json.Unmarshal(command.Payload, typedCommand); err != nil {
+		return fmt.Errorf("failed to parse payload of command %s - %w", command.Type, err)
+	}
+
+	return nil
+}
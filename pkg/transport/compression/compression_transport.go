@@ -0,0 +1,121 @@
+package compression
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+)
+
+const (
+	// EnvVarBundleCompression is the environment variable that holds the compression type per bundle type, in the
+	// format <bundle type>=<compression type>,... (e.g. ManagedClusters=gzip,PolicyCompliance=zstd). the bundle type
+	// '*' sets the compression of all the bundle types that are not listed.
+	EnvVarBundleCompression = "BUNDLE_COMPRESSION"
+	// MetadataKey is the message metadata key that holds the compression type of the payload.
+	MetadataKey = "compression"
+
+	allBundleTypes = "*"
+)
+
+var errEnvVarIllegalValue = errors.New("illegal value of environment variable")
+
+// MiddlewareName is the name the payload compression middleware is registered under.
+const MiddlewareName = "compression"
+
+func init() {
+	transport.RegisterMiddleware(MiddlewareName, func(next transport.Transport, log logr.Logger) (transport.Transport,
+		error) {
+		return NewTransport(next)
+	})
+}
+
+// Transport wraps a transport and compresses the payload of the messages according to their bundle type.
+type Transport struct {
+	transport   transport.Transport
+	compressors map[string]Compressor // key is bundle type
+}
+
+// NewTransport creates a new instance of Transport that wraps the given transport.
+func NewTransport(transportToWrap transport.Transport) (*Transport, error) {
+	compressors, err := readEnvVars()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize compression - %w", err)
+	}
+
+	return &Transport{
+		transport:   transportToWrap,
+		compressors: compressors,
+	}, nil
+}
+
+func readEnvVars() (map[string]Compressor, error) {
+	compressors := make(map[string]Compressor)
+
+	for _, entry := range strings.Split(os.Getenv(EnvVarBundleCompression), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+
+		keyValue := strings.Split(entry, "=")
+		if len(keyValue) != 2 { //nolint:gomnd // bundle type and compression type
+			return nil, fmt.Errorf("%w: %s must be in the format <bundle type>=<compression type>,...",
+				errEnvVarIllegalValue, EnvVarBundleCompression)
+		}
+
+		compressor, err := NewCompressor(strings.TrimSpace(keyValue[1]))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s - %v", errEnvVarIllegalValue, EnvVarBundleCompression, err)
+		}
+
+		compressors[strings.TrimSpace(keyValue[0])] = compressor
+	}
+
+	return compressors, nil
+}
+
+// SendAsync compresses the message payload if compression is enabled for its bundle type and sends it using the
+// wrapped transport.
+func (t *Transport) SendAsync(message *transport.Message) {
+	compressor := t.getCompressor(message.ID)
+	if compressor == nil {
+		t.transport.SendAsync(message)
+		return
+	}
+
+	compressedPayload, err := compressor.Compress(message.Payload)
+	if err != nil {
+		message.ReportDeliveryResult(err)
+		return
+	}
+
+	message.Payload = compressedPayload
+	message.SetMetadata(MetadataKey, compressor.GetType())
+
+	t.transport.SendAsync(message)
+}
+
+// GetVersion returns the version from the wrapped transport.
+func (t *Transport) GetVersion(id string, msgType string) string {
+	return t.transport.GetVersion(id, msgType)
+}
+
+// Subscribe subscribes the handler to commands received by the wrapped transport.
+func (t *Transport) Subscribe(commandType string, handler transport.CommandHandler) {
+	t.transport.Subscribe(commandType, handler)
+}
+
+// getCompressor returns the compressor of the bundle type of the given message id, or nil if not compressed.
+// message ids are in the format <leaf hub name>.<bundle type>.
+func (t *Transport) getCompressor(id string) Compressor {
+	bundleType := id[strings.LastIndex(id, ".")+1:]
+
+	if compressor, found := t.compressors[bundleType]; found {
+		return compressor
+	}
+
+	return t.compressors[allBundleTypes]
+}
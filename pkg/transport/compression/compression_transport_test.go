@@ -0,0 +1,125 @@
+package compression
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/transporttest"
+)
+
+const testMsgType = "StatusBundle"
+
+var testPayload = bytes.Repeat([]byte(`{"policy":"policy1","compliance":"Compliant"}`), 100)
+
+func setEnvVar(t *testing.T, name string, value string) {
+	t.Helper()
+
+	if err := os.Setenv(name, value); err != nil {
+		t.Fatalf("failed to set %s: %v", name, err)
+	}
+
+	t.Cleanup(func() { _ = os.Unsetenv(name) })
+}
+
+func newTestTransport(t *testing.T, bundleCompression string) (*Transport, *transporttest.Transport) {
+	t.Helper()
+
+	setEnvVar(t, EnvVarBundleCompression, bundleCompression)
+
+	fake := transporttest.NewTransport()
+
+	compressionTransport, err := NewTransport(fake)
+	if err != nil {
+		t.Fatalf("failed to create the compression transport: %v", err)
+	}
+
+	return compressionTransport, fake
+}
+
+func send(compressionTransport *Transport, id string) {
+	compressionTransport.SendAsync(&transport.Message{
+		ID:      id,
+		MsgType: testMsgType,
+		Version: "1",
+		Payload: append([]byte(nil), testPayload...),
+	})
+}
+
+func TestCompressedPayloadIsDecompressed(t *testing.T) {
+	for _, compressionType := range []string{GzipType, ZstdType} {
+		t.Run(compressionType, func(t *testing.T) {
+			compressionTransport, fake := newTestTransport(t, "*="+compressionType)
+			send(compressionTransport, "hub1.policies")
+
+			message := fake.Sent()[0]
+			if message.Metadata[MetadataKey] != compressionType {
+				t.Fatalf("expected compression metadata %s, got %q", compressionType, message.Metadata[MetadataKey])
+			}
+
+			if len(message.Payload) >= len(testPayload) {
+				t.Fatalf("expected the payload to be compressed, got %d bytes", len(message.Payload))
+			}
+
+			compressor, err := NewCompressor(message.Metadata[MetadataKey])
+			if err != nil {
+				t.Fatalf("failed to create the compressor: %v", err)
+			}
+
+			payload, err := compressor.Decompress(message.Payload)
+			if err != nil {
+				t.Fatalf("failed to decompress the payload: %v", err)
+			}
+
+			if !bytes.Equal(payload, testPayload) {
+				t.Fatal("the decompressed payload differs from the sent payload")
+			}
+		})
+	}
+}
+
+func TestCompressionIsSelectedPerBundleType(t *testing.T) {
+	for name, test := range map[string]struct {
+		bundleCompression   string
+		id                  string
+		expectedCompression string
+	}{
+		"listed":           {bundleCompression: "policies=zstd,*=gzip", id: "hub1.policies", expectedCompression: ZstdType},
+		"all bundle types": {bundleCompression: "policies=zstd,*=gzip", id: "hub1.clusters", expectedCompression: GzipType},
+		"not listed":       {bundleCompression: "policies=zstd", id: "hub1.clusters"},
+		"leaf hub name":    {bundleCompression: "hub1=gzip", id: "hub1.clusters"},
+		"not set":          {id: "hub1.policies"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			compressionTransport, fake := newTestTransport(t, test.bundleCompression)
+			send(compressionTransport, test.id)
+
+			message := fake.Sent()[0]
+			if compression, found := message.Metadata[MetadataKey]; compression != test.expectedCompression ||
+				found != (test.expectedCompression != "") {
+				t.Fatalf("expected compression %q, got %q", test.expectedCompression, compression)
+			}
+
+			if test.expectedCompression == "" && !bytes.Equal(message.Payload, testPayload) {
+				t.Fatal("expected the payload to be sent as is")
+			}
+		})
+	}
+}
+
+func TestReadEnvVarsRaisesIllegalValue(t *testing.T) {
+	for name, bundleCompression := range map[string]string{
+		"missing compression type": "policies",
+		"unsupported type":         "policies=lz4",
+	} {
+		t.Run(name, func(t *testing.T) {
+			setEnvVar(t, EnvVarBundleCompression, bundleCompression)
+
+			if _, err := NewTransport(transporttest.NewTransport()); !errors.Is(err, errEnvVarIllegalValue) {
+				t.Fatalf("expected %v, got %v", errEnvVarIllegalValue, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package compression
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// GzipType is the compression type of the gzip compressor.
+	GzipType = "gzip"
+	// ZstdType is the compression type of the zstd compressor.
+	ZstdType = "zstd"
+)
+
+var errUnsupportedCompressionType = errors.New("unsupported compression type")
+
+// Compressor compresses and decompresses payloads.
+type Compressor interface {
+	// GetType returns the compression type, which is recorded in the message metadata.
+	GetType() string
+	// Compress compresses the given payload.
+	Compress(payload []byte) ([]byte, error)
+	// Decompress decompresses the given payload.
+	Decompress(compressedPayload []byte) ([]byte, error)
+}
+
+// NewCompressor returns a compressor of the given compression type.
+func NewCompressor(compressionType string) (Compressor, error) {
+	switch compressionType {
+	case GzipType:
+		return &gzipCompressor{}, nil
+	case ZstdType:
+		return newZstdCompressor()
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedCompressionType, compressionType)
+	}
+}
@@ -0,0 +1,45 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+)
+
+type gzipCompressor struct{}
+
+func (compressor *gzipCompressor) GetType() string {
+	return GzipType
+}
+
+func (compressor *gzipCompressor) Compress(payload []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	writer := gzip.NewWriter(&buffer)
+
+	if _, err := writer.Write(payload); err != nil {
+		return nil, fmt.Errorf("failed to compress payload - %w", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress payload - %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (compressor *gzipCompressor) Decompress(compressedPayload []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(compressedPayload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress payload - %w", err)
+	}
+	defer reader.Close()
+
+	payload, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress payload - %w", err)
+	}
+
+	return payload, nil
+}
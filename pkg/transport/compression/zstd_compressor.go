@@ -0,0 +1,45 @@
+package compression
+
+import (
+	"fmt"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// zstdCompressor uses a pure Go implementation of zstd, so the binary is built without cgo. the encoder and decoder
+// are safe for concurrent use when compressing and decompressing whole payloads.
+type zstdCompressor struct {
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+func newZstdCompressor() (*zstdCompressor, error) {
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zstd encoder - %w", err)
+	}
+
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zstd decoder - %w", err)
+	}
+
+	return &zstdCompressor{encoder: encoder, decoder: decoder}, nil
+}
+
+func (compressor *zstdCompressor) GetType() string {
+	return ZstdType
+}
+
+func (compressor *zstdCompressor) Compress(payload []byte) ([]byte, error) {
+	return compressor.encoder.EncodeAll(payload, nil), nil
+}
+
+func (compressor *zstdCompressor) Decompress(compressedPayload []byte) ([]byte, error) {
+	payload, err := compressor.decoder.DecodeAll(compressedPayload, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress payload - %w", err)
+	}
+
+	return payload, nil
+}
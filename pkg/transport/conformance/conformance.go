@@ -0,0 +1,276 @@
+// Package conformance checks that a transport.Transport implementation behaves like the other transports. the checks
+// run in go tests, given a *testing.T, as well as in the transport-conformance command.
+package conformance
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+)
+
+const (
+	// MsgType is the message type of the messages sent by the checks.
+	MsgType = "ConformanceCheck"
+
+	defaultDeliveryTimeout   = 30 * time.Second
+	defaultSenders           = 10
+	defaultVersionsPerSender = 10
+)
+
+var errDeliveryTimeout = errors.New("timed out waiting for the delivery result")
+
+// TestingT is the part of testing.T that is used by the checks.
+type TestingT interface {
+	Helper()
+	Logf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// Constructor creates the transport to check. every check gets a new transport. a transport that implements
+// transport.Service is started before the check and stopped after it.
+type Constructor func() (transport.Transport, error)
+
+// Options tunes the checks. the zero value of a field means its default.
+type Options struct {
+	// DeliveryTimeout is how long to wait for the delivery result of a message (default 30s).
+	DeliveryTimeout time.Duration
+	// Senders is the number of goroutines that send concurrently (default 10).
+	Senders int
+	// VersionsPerSender is the number of versions each goroutine sends (default 10).
+	VersionsPerSender int
+}
+
+type check struct {
+	name string
+	run  func(t TestingT, transportToCheck transport.Transport, options *Options, idPrefix string)
+}
+
+var checks = []check{
+	{name: "UnknownKeyHasNoVersion", run: checkUnknownKeyHasNoVersion},
+	{name: "VersionsAreMonotonic", run: checkVersionsAreMonotonic},
+	{name: "ConcurrentSendAsync", run: checkConcurrentSendAsync},
+}
+
+// Run runs all the checks against transports created by newTransport. returns true if all the checks passed.
+// the ids of the messages are unique per run, so the checks can run against a backend that keeps state between runs.
+func Run(t TestingT, newTransport Constructor, options *Options) bool {
+	t.Helper()
+
+	options = withDefaults(options)
+	runID := strconv.FormatInt(time.Now().UnixNano(), 36)
+	passed := true
+
+	for _, check := range checks {
+		checkT := &namedT{TestingT: t, name: check.name}
+
+		t.Logf("=== RUN %s", check.name)
+		runCheck(checkT, check, newTransport, options, fmt.Sprintf("conformance-%s-%s", runID, check.name))
+
+		if checkT.failed {
+			t.Logf("--- FAIL: %s", check.name)
+			passed = false
+		} else {
+			t.Logf("--- PASS: %s", check.name)
+		}
+	}
+
+	return passed
+}
+
+func runCheck(t *namedT, check check, newTransport Constructor, options *Options, idPrefix string) {
+	transportToCheck, err := newTransport()
+	if err != nil {
+		t.Errorf("failed to create transport - %v", err)
+		return
+	}
+
+	if service, ok := transportToCheck.(transport.Service); ok {
+		service.Start()
+		defer service.Stop()
+	}
+
+	check.run(t, transportToCheck, options, idPrefix)
+}
+
+func withDefaults(options *Options) *Options {
+	result := Options{}
+	if options != nil {
+		result = *options
+	}
+
+	if result.DeliveryTimeout <= 0 {
+		result.DeliveryTimeout = defaultDeliveryTimeout
+	}
+
+	if result.Senders <= 0 {
+		result.Senders = defaultSenders
+	}
+
+	if result.VersionsPerSender <= 0 {
+		result.VersionsPerSender = defaultVersionsPerSender
+	}
+
+	return &result
+}
+
+// checkUnknownKeyHasNoVersion checks that GetVersion returns "" for an id that was never sent.
+func checkUnknownKeyHasNoVersion(t TestingT, transportToCheck transport.Transport, _ *Options, idPrefix string) {
+	if version := transportToCheck.GetVersion(idPrefix+"-unknown", MsgType); version != "" {
+		t.Errorf("GetVersion of an unknown key returned %q, expected \"\"", version)
+	}
+}
+
+// checkVersionsAreMonotonic sends increasing versions of an id one by one. after each delivery GetVersion must return
+// the delivered version, and the versions observed meanwhile by a concurrent reader must never decrease.
+func checkVersionsAreMonotonic(t TestingT, transportToCheck transport.Transport, options *Options, idPrefix string) {
+	id := idPrefix + "-id"
+	stopChan := make(chan struct{})
+	readerDoneChan := make(chan struct{})
+
+	go func() {
+		defer close(readerDoneChan)
+		readVersionsUntilStopped(t, transportToCheck, id, stopChan)
+	}()
+
+	defer func() {
+		close(stopChan)
+		<-readerDoneChan
+	}()
+
+	for version := 1; version <= options.VersionsPerSender; version++ {
+		versionStr := strconv.Itoa(version)
+
+		if err := sendAndWait(transportToCheck, id, versionStr, options.DeliveryTimeout); err != nil {
+			t.Errorf("failed to deliver version %s - %v", versionStr, err)
+			return
+		}
+
+		if currentVersion := transportToCheck.GetVersion(id, MsgType); currentVersion != versionStr {
+			t.Errorf("GetVersion returned %q after version %s was delivered", currentVersion, versionStr)
+			return
+		}
+	}
+}
+
+func readVersionsUntilStopped(t TestingT, transportToCheck transport.Transport, id string, stopChan chan struct{}) {
+	lastVersion := ""
+
+	for {
+		select {
+		case <-stopChan:
+			return
+		default:
+		}
+
+		version := transportToCheck.GetVersion(id, MsgType)
+		if lastVersion != "" && (version == "" || transport.CompareVersions(version, lastVersion) < 0) {
+			t.Errorf("GetVersion returned %q after it returned %q", version, lastVersion)
+			return
+		}
+
+		lastVersion = version
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// checkConcurrentSendAsync sends increasing versions of several ids concurrently without waiting. the delivery
+// result of every message must be reported exactly once, either as delivered or as superseded by a newer version of
+// the same id. the last version of every id must be delivered and returned by GetVersion.
+func checkConcurrentSendAsync(t TestingT, transportToCheck transport.Transport, options *Options, idPrefix string) {
+	results := newDeliveryResults(options.Senders * options.VersionsPerSender)
+	waitGroup := sync.WaitGroup{}
+
+	for sender := 0; sender < options.Senders; sender++ {
+		waitGroup.Add(1)
+
+		go func(id string) {
+			defer waitGroup.Done()
+
+			for version := 1; version <= options.VersionsPerSender; version++ {
+				transportToCheck.SendAsync(results.newMessage(id, strconv.Itoa(version)))
+			}
+		}(fmt.Sprintf("%s-%d", idPrefix, sender))
+	}
+
+	waitGroup.Wait()
+
+	if !results.wait(options.DeliveryTimeout) {
+		t.Errorf("%v, %d of %d delivery results were reported", errDeliveryTimeout, results.reportedCount(),
+			options.Senders*options.VersionsPerSender)
+		return
+	}
+
+	lastVersion := strconv.Itoa(options.VersionsPerSender)
+
+	for sender := 0; sender < options.Senders; sender++ {
+		id := fmt.Sprintf("%s-%d", idPrefix, sender)
+
+		for version := 1; version <= options.VersionsPerSender; version++ {
+			versionStr := strconv.Itoa(version)
+
+			for _, err := range results.get(id, versionStr) {
+				if err != nil && (versionStr == lastVersion || !errors.Is(err, transport.ErrMessageSuperseded)) {
+					t.Errorf("failed to deliver %s version %s - %v", id, versionStr, err)
+				}
+			}
+
+			if count := len(results.get(id, versionStr)); count != 1 {
+				t.Errorf("the delivery result of %s version %s was reported %d times", id, versionStr, count)
+			}
+		}
+
+		if version := transportToCheck.GetVersion(id, MsgType); version != lastVersion {
+			t.Errorf("GetVersion of %s returned %q, expected %q", id, version, lastVersion)
+		}
+	}
+}
+
+func sendAndWait(transportToSend transport.Transport, id string, version string, timeout time.Duration) error {
+	resultChan := make(chan error, 1)
+
+	transportToSend.SendAsync(newMessage(id, version, func(err error) {
+		resultChan <- err
+	}))
+
+	select {
+	case err := <-resultChan:
+		return err
+	case <-time.After(timeout):
+		return errDeliveryTimeout
+	}
+}
+
+func newMessage(id string, version string, deliveryCallback transport.DeliveryCallback) *transport.Message {
+	return &transport.Message{
+		ID:               id,
+		MsgType:          MsgType,
+		Version:          version,
+		Payload:          []byte(fmt.Sprintf(`{"id":%q,"version":%q}`, id, version)),
+		DeliveryCallback: deliveryCallback,
+	}
+}
+
+// namedT prefixes the messages with the name of the check and records whether the check failed.
+type namedT struct {
+	TestingT
+	name   string
+	failed bool
+	lock   sync.Mutex
+}
+
+func (t *namedT) Logf(format string, args ...interface{}) {
+	t.TestingT.Logf("%s: %s", t.name, fmt.Sprintf(format, args...))
+}
+
+func (t *namedT) Errorf(format string, args ...interface{}) {
+	t.lock.Lock()
+	t.failed = true
+	t.lock.Unlock()
+
+	t.TestingT.Errorf("%s: %s", t.name, fmt.Sprintf(format, args...))
+}
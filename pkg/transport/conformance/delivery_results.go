@@ -0,0 +1,75 @@
+package conformance
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+)
+
+// deliveryResults collects the delivery results of messages, per id and version.
+type deliveryResults struct {
+	expectedCount int
+	count         int
+	results       map[string][]error
+	doneChan      chan struct{}
+	lock          sync.Mutex
+}
+
+func newDeliveryResults(expectedCount int) *deliveryResults {
+	return &deliveryResults{
+		expectedCount: expectedCount,
+		results:       make(map[string][]error),
+		doneChan:      make(chan struct{}),
+	}
+}
+
+// newMessage returns a message whose delivery result is collected.
+func (results *deliveryResults) newMessage(id string, version string) *transport.Message {
+	return newMessage(id, version, func(err error) {
+		results.report(id, version, err)
+	})
+}
+
+func (results *deliveryResults) report(id string, version string, err error) {
+	results.lock.Lock()
+	defer results.lock.Unlock()
+
+	key := resultKey(id, version)
+	results.results[key] = append(results.results[key], err)
+	results.count++
+
+	if results.count == results.expectedCount {
+		close(results.doneChan)
+	}
+}
+
+// wait waits until the expected number of results was reported. returns false if the timeout expired.
+func (results *deliveryResults) wait(timeout time.Duration) bool {
+	select {
+	case <-results.doneChan:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func (results *deliveryResults) reportedCount() int {
+	results.lock.Lock()
+	defer results.lock.Unlock()
+
+	return results.count
+}
+
+// get returns the results reported for the given id and version.
+func (results *deliveryResults) get(id string, version string) []error {
+	results.lock.Lock()
+	defer results.lock.Unlock()
+
+	return append([]error{}, results.results[resultKey(id, version)]...)
+}
+
+func resultKey(id string, version string) string {
+	return fmt.Sprintf("%s/%s", id, version)
+}
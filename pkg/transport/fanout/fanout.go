@@ -0,0 +1,380 @@
+package fanout
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+)
+
+const (
+	// TransportType is the transport type the fan-out transport is registered under.
+	TransportType = "fanout"
+	// VersionPolicyPrimary returns the version of the primary transport.
+	VersionPolicyPrimary = "primary"
+	// VersionPolicyMax returns the highest version of all the transports.
+	VersionPolicyMax = "max"
+
+	envVarFanoutTransportTypes                 = "FANOUT_TRANSPORT_TYPES"
+	envVarFanoutVersionPolicy                  = "FANOUT_VERSION_POLICY"
+	envVarFanoutCircuitBreakerFailureThreshold = "FANOUT_CIRCUIT_BREAKER_FAILURE_THRESHOLD"
+	envVarFanoutCircuitBreakerCooldown         = "FANOUT_CIRCUIT_BREAKER_COOLDOWN"
+	envVarFanoutResendInterval                 = "FANOUT_RESEND_INTERVAL"
+
+	defaultVersionPolicy                  = VersionPolicyPrimary
+	defaultCircuitBreakerFailureThreshold = 5
+	defaultCircuitBreakerCooldown         = time.Minute
+	defaultResendInterval                 = 30 * time.Second
+)
+
+var (
+	errEnvVarNotFound     = errors.New("not found environment variable")
+	errEnvVarWrongType    = errors.New("wrong type of environment variable")
+	errEnvVarIllegalValue = errors.New("illegal value of environment variable")
+	// ErrCircuitOpen is reported for a child transport that was skipped since its circuit breaker is open.
+	ErrCircuitOpen = errors.New("circuit breaker of the transport is open")
+)
+
+func init() {
+	transport.Register(TransportType, func(log logr.Logger) (transport.Service, error) {
+		return NewFanout(log)
+	})
+}
+
+// child is a transport the fan-out transport forwards messages to, with its own circuit breaker.
+type child struct {
+	transportType  string
+	service        transport.Service
+	circuitBreaker *transport.CircuitBreaker
+	// latestMessages holds the latest message per id and type that was sent to a secondary child and wasn't
+	// delivered yet, failedMessages holds the ones of them that failed and have to be resent.
+	latestMessages map[string]*transport.Message
+	failedMessages map[string]*transport.Message
+	lock           sync.Mutex
+}
+
+// Fanout is a composite transport that forwards every message to several child transports, e.g. while migrating
+// from one transport to another. the first child is the primary transport, its delivery result is the one reported
+// to the message delivery callback. failures of the other children are logged and counted, and the latest message
+// per id and type that a secondary child failed to deliver is resent to it periodically, unless a newer message
+// was sent in the meantime. each child has its own circuit breaker so a failing child doesn't affect the others.
+type Fanout struct {
+	children       []*child
+	versionPolicy  string
+	resendInterval time.Duration
+	stopChan       chan struct{}
+	startOnce      sync.Once
+	stopOnce       sync.Once
+	log            logr.Logger
+}
+
+// NewFanout creates a new instance of Fanout. the child transports are created using the transport registry.
+func NewFanout(log logr.Logger) (*Fanout, error) {
+	transportTypes, versionPolicy, failureThreshold, cooldown, resendInterval, err := readEnvVars()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize fan-out transport - %w", err)
+	}
+
+	children := make([]*child, 0, len(transportTypes))
+
+	for _, transportType := range transportTypes {
+		service, err := transport.NewService(transportType, log.WithName(transportType))
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize fan-out transport - %w", err)
+		}
+
+		children = append(children, &child{
+			transportType: transportType,
+			service:       service,
+			circuitBreaker: transport.NewCircuitBreaker(fmt.Sprintf("%s/%s", TransportType, transportType),
+				failureThreshold, cooldown, log),
+			latestMessages: make(map[string]*transport.Message),
+			failedMessages: make(map[string]*transport.Message),
+		})
+	}
+
+	return &Fanout{
+		children:       children,
+		versionPolicy:  versionPolicy,
+		resendInterval: resendInterval,
+		stopChan:       make(chan struct{}),
+		log:            log,
+	}, nil
+}
+
+func readEnvVars() ([]string, string, int, time.Duration, time.Duration, error) {
+	transportTypesStr := os.Getenv(envVarFanoutTransportTypes)
+	if transportTypesStr == "" {
+		return nil, "", 0, 0, 0, fmt.Errorf("%w: %s", errEnvVarNotFound, envVarFanoutTransportTypes)
+	}
+
+	transportTypes := make([]string, 0)
+	seen := make(map[string]struct{})
+
+	for _, transportType := range strings.Split(transportTypesStr, ",") {
+		transportType = strings.TrimSpace(transportType)
+
+		if _, found := seen[transportType]; found || transportType == "" || transportType == TransportType {
+			return nil, "", 0, 0, 0, fmt.Errorf("%w: %s must be a list of distinct transport types other than %s",
+				errEnvVarIllegalValue, envVarFanoutTransportTypes, TransportType)
+		}
+
+		seen[transportType] = struct{}{}
+		transportTypes = append(transportTypes, transportType)
+	}
+
+	versionPolicy := os.Getenv(envVarFanoutVersionPolicy)
+	if versionPolicy == "" {
+		versionPolicy = defaultVersionPolicy
+	}
+
+	if versionPolicy != VersionPolicyPrimary && versionPolicy != VersionPolicyMax {
+		return nil, "", 0, 0, 0, fmt.Errorf("%w: %s must be one of %s, %s", errEnvVarIllegalValue,
+			envVarFanoutVersionPolicy, VersionPolicyPrimary, VersionPolicyMax)
+	}
+
+	failureThreshold := defaultCircuitBreakerFailureThreshold
+
+	if failureThresholdStr := os.Getenv(envVarFanoutCircuitBreakerFailureThreshold); failureThresholdStr != "" {
+		var err error
+		if failureThreshold, err = strconv.Atoi(failureThresholdStr); err != nil {
+			return nil, "", 0, 0, 0, fmt.Errorf("%w: %s must be an integer", errEnvVarWrongType,
+				envVarFanoutCircuitBreakerFailureThreshold)
+		}
+	}
+
+	cooldown := defaultCircuitBreakerCooldown
+
+	if cooldownStr := os.Getenv(envVarFanoutCircuitBreakerCooldown); cooldownStr != "" {
+		var err error
+		if cooldown, err = time.ParseDuration(cooldownStr); err != nil {
+			return nil, "", 0, 0, 0, fmt.Errorf("%w: %s must be a duration", errEnvVarWrongType,
+				envVarFanoutCircuitBreakerCooldown)
+		}
+	}
+
+	resendInterval := defaultResendInterval
+
+	if resendIntervalStr := os.Getenv(envVarFanoutResendInterval); resendIntervalStr != "" {
+		var err error
+		if resendInterval, err = time.ParseDuration(resendIntervalStr); err != nil || resendInterval <= 0 {
+			return nil, "", 0, 0, 0, fmt.Errorf("%w: %s must be a positive duration", errEnvVarIllegalValue,
+				envVarFanoutResendInterval)
+		}
+	}
+
+	return transportTypes, versionPolicy, failureThreshold, cooldown, resendInterval, nil
+}
+
+// Start function starts all the child transports and resending failed messages to the secondary transports.
+func (f *Fanout) Start() {
+	f.startOnce.Do(func() {
+		for _, child := range f.children {
+			child.service.Start()
+		}
+
+		go f.resendPeriodically()
+	})
+}
+
+// Stop function stops all the child transports in parallel, so their drain timeouts don't add up.
+func (f *Fanout) Stop() {
+	f.stopOnce.Do(func() {
+		close(f.stopChan)
+
+		var waitGroup sync.WaitGroup
+
+		for _, child := range f.children {
+			waitGroup.Add(1)
+
+			go func(service transport.Service) {
+				defer waitGroup.Done()
+				service.Stop()
+			}(child.service)
+		}
+
+		waitGroup.Wait()
+	})
+}
+
+// SendAsync function forwards a copy of the message to each child transport. children whose circuit breaker is open
+// are skipped.
+func (f *Fanout) SendAsync(message *transport.Message) {
+	for i, child := range f.children {
+		f.sendToChild(child, message, i == 0)
+	}
+}
+
+// GetVersion returns the version according to the version policy, either the version of the primary transport or
+// the highest version of all the transports.
+func (f *Fanout) GetVersion(id string, msgType string) string {
+	if f.versionPolicy == VersionPolicyPrimary {
+		return f.children[0].service.GetVersion(id, msgType)
+	}
+
+	highestVersion := ""
+
+	for _, child := range f.children {
+		if version := child.service.GetVersion(id, msgType); transport.CompareVersions(version, highestVersion) > 0 {
+			highestVersion = version
+		}
+	}
+
+	return highestVersion
+}
+
+// Subscribe subscribes the handler to commands received by any of the child transports.
+func (f *Fanout) Subscribe(commandType string, handler transport.CommandHandler) {
+	for _, child := range f.children {
+		child.service.Subscribe(commandType, handler)
+	}
+}
+
+func (f *Fanout) sendToChild(child *child, message *transport.Message, primary bool) {
+	childMessage := copyMessage(message)
+	childMessage.DeliveryCallback = f.childDeliveryCallback(child, message, primary)
+
+	if !primary {
+		child.track(message)
+	}
+
+	if child.circuitBreaker.WaitDuration() > 0 {
+		childMessage.ReportDeliveryResult(ErrCircuitOpen)
+		return
+	}
+
+	child.service.SendAsync(childMessage)
+}
+
+func (f *Fanout) resendPeriodically() {
+	ticker := time.NewTicker(f.resendInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.stopChan:
+			return
+		case <-ticker.C:
+			f.resendFailedMessages()
+		}
+	}
+}
+
+// resendFailedMessages resends the failed messages to the secondary children whose circuit breaker is closed.
+func (f *Fanout) resendFailedMessages() {
+	for _, child := range f.children[1:] {
+		if child.circuitBreaker.WaitDuration() > 0 {
+			continue
+		}
+
+		for _, message := range child.takeFailedMessages() {
+			f.log.Info(fmt.Sprintf("resending message '%s' from type '%s' with version '%s' to transport '%s'",
+				message.ID, message.MsgType, message.Version, child.transportType))
+			f.sendToChild(child, message, false)
+		}
+	}
+}
+
+// childDeliveryCallback returns the delivery callback of the copy of the message that is sent to the given child.
+// only the result of the primary child is reported to the callback of the original message.
+func (f *Fanout) childDeliveryCallback(child *child, message *transport.Message,
+	primary bool) transport.DeliveryCallback {
+	return func(err error) {
+		if !primary {
+			child.handleDeliveryResult(message, err)
+		}
+
+		switch {
+		case errors.Is(err, ErrCircuitOpen):
+			childMessagesCounter.WithLabelValues(child.transportType, resultSkipped).Inc()
+		case errors.Is(err, transport.ErrMessageSuperseded):
+			childMessagesCounter.WithLabelValues(child.transportType, resultSuperseded).Inc()
+		case err != nil:
+			childMessagesCounter.WithLabelValues(child.transportType, resultFailed).Inc()
+			child.circuitBreaker.RecordResult(err)
+
+			if !primary {
+				f.log.Info(fmt.Sprintf("failed to deliver message '%s' from type '%s' with version '%s' to "+
+					"transport '%s' - %s", message.ID, message.MsgType, message.Version, child.transportType, err))
+			}
+		default:
+			childMessagesCounter.WithLabelValues(child.transportType, resultDelivered).Inc()
+			child.circuitBreaker.RecordResult(nil)
+		}
+
+		if primary {
+			message.ReportDeliveryResult(err)
+		}
+	}
+}
+
+// track marks the message as the latest message of its id and type that was sent to the child. a failed older
+// message of the same id and type is not resent.
+func (child *child) track(message *transport.Message) {
+	child.lock.Lock()
+	defer child.lock.Unlock()
+
+	key := messageKey(message)
+	child.latestMessages[key] = message
+	delete(child.failedMessages, key)
+}
+
+// handleDeliveryResult keeps the message for resending if it failed and is still the latest message of its id and
+// type. superseded messages are not kept, the message that superseded them is tracked instead.
+func (child *child) handleDeliveryResult(message *transport.Message, err error) {
+	child.lock.Lock()
+	defer child.lock.Unlock()
+
+	key := messageKey(message)
+	if child.latestMessages[key] != message {
+		return
+	}
+
+	switch {
+	case err == nil:
+		delete(child.latestMessages, key)
+	case !errors.Is(err, transport.ErrMessageSuperseded):
+		child.failedMessages[key] = message
+	}
+}
+
+// takeFailedMessages returns the failed messages and stops tracking them as failed.
+func (child *child) takeFailedMessages() []*transport.Message {
+	child.lock.Lock()
+	defer child.lock.Unlock()
+
+	messages := make([]*transport.Message, 0, len(child.failedMessages))
+
+	for key, message := range child.failedMessages {
+		messages = append(messages, message)
+		delete(child.failedMessages, key)
+	}
+
+	return messages
+}
+
+func messageKey(message *transport.Message) string {
+	return fmt.Sprintf("%s.%s", message.MsgType, message.ID)
+}
+
+// copyMessage returns a copy of the message with its own metadata, so children can't affect each other.
+func copyMessage(message *transport.Message) *transport.Message {
+	messageCopy := &transport.Message{
+		ID:      message.ID,
+		MsgType: message.MsgType,
+		Version: message.Version,
+		Payload: message.Payload,
+	}
+
+	for key, value := range message.Metadata {
+		messageCopy.SetMetadata(key, value)
+	}
+
+	return messageCopy
+}
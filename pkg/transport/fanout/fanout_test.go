@@ -0,0 +1,179 @@
+package fanout
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+)
+
+const (
+	testMsgType          = "StatusBundle"
+	testID               = "hub1.policies"
+	testFailureThreshold = 100
+	testTimeout          = 5 * time.Second
+)
+
+var errUnreachable = errors.New("transport is unreachable")
+
+// fakeService delivers the messages synchronously, or fails them while it is unreachable.
+type fakeService struct {
+	lock        sync.Mutex
+	unreachable bool
+	delivered   []string // versions of the delivered messages
+}
+
+func (f *fakeService) Start() {}
+
+func (f *fakeService) Stop() {}
+
+func (f *fakeService) SendAsync(message *transport.Message) {
+	f.lock.Lock()
+	unreachable := f.unreachable
+
+	if !unreachable {
+		f.delivered = append(f.delivered, message.Version)
+	}
+	f.lock.Unlock()
+
+	if unreachable {
+		message.ReportDeliveryResult(errUnreachable)
+	} else {
+		message.ReportDeliveryResult(nil)
+	}
+}
+
+func (f *fakeService) GetVersion(string, string) string {
+	return ""
+}
+
+func (f *fakeService) Subscribe(string, transport.CommandHandler) {}
+
+func (f *fakeService) setUnreachable(unreachable bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.unreachable = unreachable
+}
+
+func (f *fakeService) deliveredVersions() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return append([]string(nil), f.delivered...)
+}
+
+func newTestFanout(resendInterval time.Duration) (*Fanout, *fakeService, *fakeService) {
+	primary, secondary := &fakeService{}, &fakeService{}
+	children := make([]*child, 0, 2)
+
+	for i, service := range []*fakeService{primary, secondary} {
+		transportType := fmt.Sprintf("fake-%d", i)
+		children = append(children, &child{
+			transportType: transportType,
+			service:       service,
+			circuitBreaker: transport.NewCircuitBreaker(transportType, testFailureThreshold, time.Minute,
+				logrtesting.NullLogger{}),
+			latestMessages: make(map[string]*transport.Message),
+			failedMessages: make(map[string]*transport.Message),
+		})
+	}
+
+	return &Fanout{
+		children:       children,
+		versionPolicy:  VersionPolicyPrimary,
+		resendInterval: resendInterval,
+		stopChan:       make(chan struct{}),
+		log:            logrtesting.NullLogger{},
+	}, primary, secondary
+}
+
+func send(t *testing.T, fanout *Fanout, version string) {
+	t.Helper()
+
+	var result error
+
+	fanout.SendAsync(&transport.Message{
+		ID:               testID,
+		MsgType:          testMsgType,
+		Version:          version,
+		DeliveryCallback: func(err error) { result = err },
+	})
+
+	if result != nil {
+		t.Fatalf("expected the primary result to be reported, got %v", result)
+	}
+}
+
+func assertVersions(t *testing.T, expected []string, actual []string) {
+	t.Helper()
+
+	if fmt.Sprint(expected) != fmt.Sprint(actual) {
+		t.Fatalf("expected versions %v to be delivered, got %v", expected, actual)
+	}
+}
+
+func TestFailedSecondaryMessageIsResent(t *testing.T) {
+	fanout, primary, secondary := newTestFanout(time.Minute)
+
+	secondary.setUnreachable(true)
+	send(t, fanout, "1")
+
+	fanout.resendFailedMessages() // still unreachable, kept for the next resend
+	secondary.setUnreachable(false)
+	fanout.resendFailedMessages()
+	fanout.resendFailedMessages() // delivered, not resent again
+
+	assertVersions(t, []string{"1"}, primary.deliveredVersions())
+	assertVersions(t, []string{"1"}, secondary.deliveredVersions())
+}
+
+func TestOnlyLatestFailedMessageIsResent(t *testing.T) {
+	fanout, _, secondary := newTestFanout(time.Minute)
+
+	secondary.setUnreachable(true)
+	send(t, fanout, "1")
+	send(t, fanout, "2")
+
+	secondary.setUnreachable(false)
+	fanout.resendFailedMessages()
+
+	assertVersions(t, []string{"2"}, secondary.deliveredVersions())
+}
+
+func TestNewerMessageReplacesFailedMessage(t *testing.T) {
+	fanout, _, secondary := newTestFanout(time.Minute)
+
+	secondary.setUnreachable(true)
+	send(t, fanout, "1")
+
+	secondary.setUnreachable(false)
+	send(t, fanout, "2")
+	fanout.resendFailedMessages()
+
+	assertVersions(t, []string{"2"}, secondary.deliveredVersions())
+}
+
+func TestFailedMessagesAreResentPeriodically(t *testing.T) {
+	fanout, _, secondary := newTestFanout(10 * time.Millisecond)
+
+	secondary.setUnreachable(true)
+	send(t, fanout, "1")
+
+	fanout.Start()
+	defer fanout.Stop()
+
+	secondary.setUnreachable(false)
+
+	for start := time.Now(); len(secondary.deliveredVersions()) == 0; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > testTimeout {
+			t.Fatal("timed out waiting for the failed message to be resent")
+		}
+	}
+
+	assertVersions(t, []string{"1"}, secondary.deliveredVersions())
+}
@@ -0,0 +1,22 @@
+package fanout
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	resultDelivered  = "delivered"
+	resultFailed     = "failed"
+	resultSuperseded = "superseded"
+	resultSkipped    = "skipped"
+)
+
+var childMessagesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "leaf_hub_status_sync_fanout_messages_total",
+	Help: "Number of messages handled by each child transport of the fan-out transport, by result.",
+}, []string{"transport", "result"})
+
+func init() {
+	metrics.Registry.MustRegister(childMessagesCounter)
+}
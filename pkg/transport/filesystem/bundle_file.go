@@ -0,0 +1,43 @@
+package filesystem
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// BundleFile is the content of a file written by the filesystem transport.
+type BundleFile struct {
+	ID       string            `json:"id"`
+	MsgType  string            `json:"msgType"`
+	Version  string            `json:"version"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Payload holds the payload if it's valid json, so the file stays human readable.
+	Payload json.RawMessage `json:"payload,omitempty"`
+	// EncodedPayload holds the base64 encoded payload if it's not valid json (e.g. compressed or encrypted).
+	EncodedPayload []byte `json:"encodedPayload,omitempty"`
+}
+
+// GetPayload returns the payload of the bundle file.
+func (file *BundleFile) GetPayload() []byte {
+	if file.Payload != nil {
+		return file.Payload
+	}
+
+	return file.EncodedPayload
+}
+
+// ReadBundleFile reads a file that was written by the filesystem transport.
+func ReadBundleFile(path string) (*BundleFile, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle file - %w", err)
+	}
+
+	bundleFile := &BundleFile{}
+	if err := json.Unmarshal(content, bundleFile); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle file %s - %w", path, err)
+	}
+
+	return bundleFile, nil
+}
@@ -0,0 +1,257 @@
+package filesystem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+)
+
+const (
+	envVarFilesystemTransportDir = "FILESYSTEM_TRANSPORT_DIR"
+
+	bundleFileSuffix = ".json"
+	tempFilePrefix   = "."
+	tempFileSuffix   = ".tmp"
+	dirPermissions   = 0o755
+	filePermissions  = 0o644
+)
+
+var (
+	errEnvVarNotFound  = errors.New("not found environment variable")
+	errInvalidFileName = errors.New("invalid bundle file name")
+)
+
+// TransportType is the transport type the filesystem transport is registered under.
+const TransportType = "filesystem"
+
+func init() {
+	transport.Register(TransportType, func(log logr.Logger) (transport.Service, error) {
+		return NewFilesystem(log)
+	})
+}
+
+// Filesystem abstracts a transport that writes each bundle to a directory as <id>.<msgType>.<version>.json.
+// files are written atomically, and only the latest version of each id and type is kept.
+type Filesystem struct {
+	dir       string
+	queue     *transport.MessageQueue
+	drainChan chan struct{}
+	doneChan  chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+	log       logr.Logger
+}
+
+// NewFilesystem creates a new instance of Filesystem.
+func NewFilesystem(log logr.Logger) (*Filesystem, error) {
+	dir := os.Getenv(envVarFilesystemTransportDir)
+	if dir == "" {
+		return nil, fmt.Errorf("failed to initialize filesystem transport - %w: %s", errEnvVarNotFound,
+			envVarFilesystemTransportDir)
+	}
+
+	if err := os.MkdirAll(dir, dirPermissions); err != nil {
+		return nil, fmt.Errorf("failed to create filesystem transport directory - %w", err)
+	}
+
+	queueCapacity, err := transport.ReadMessageQueueCapacity()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize filesystem transport - %w", err)
+	}
+
+	return &Filesystem{
+		dir:       dir,
+		queue:     transport.NewMessageQueue("filesystem", queueCapacity),
+		drainChan: make(chan struct{}),
+		doneChan:  make(chan struct{}),
+		log:       log,
+	}, nil
+}
+
+// Start function starts the filesystem transport.
+func (fs *Filesystem) Start() {
+	fs.startOnce.Do(func() {
+		go fs.writeMessages()
+	})
+}
+
+// Stop function stops the filesystem transport after the messages that are waiting in the queue are written.
+func (fs *Filesystem) Stop() {
+	fs.stopOnce.Do(func() {
+		fs.queue.Close() // messages that are sent from now on are reported as failed
+		close(fs.drainChan)
+
+		if transport.WasStarted(&fs.startOnce) {
+			<-fs.doneChan
+		}
+	})
+}
+
+// SendAsync function writes a message to the directory asynchronously.
+func (fs *Filesystem) SendAsync(message *transport.Message) {
+	fs.queue.Push(message)
+}
+
+// GetVersion returns the highest version of the bundle files in the directory with the given id and type. if no
+// such file exists or an error occurred returns an empty string.
+func (fs *Filesystem) GetVersion(id string, msgType string) string {
+	versions, err := fs.listVersions(id, msgType)
+	if err != nil {
+		fs.log.Error(err, "Failed to read the filesystem transport directory")
+		return ""
+	}
+
+	highestVersion := ""
+
+	for _, version := range versions {
+		if highestVersion == "" || transport.CompareVersions(version, highestVersion) > 0 {
+			highestVersion = version
+		}
+	}
+
+	return highestVersion
+}
+
+// Subscribe function does nothing, receiving commands is not supported by the filesystem transport.
+func (fs *Filesystem) Subscribe(string, transport.CommandHandler) {}
+
+func (fs *Filesystem) writeMessages() {
+	defer close(fs.doneChan)
+
+	for {
+		msg := fs.queue.Pop(fs.drainChan)
+		if msg == nil { // drained
+			return
+		}
+
+		msg.ReportDeliveryResult(fs.writeMessage(msg))
+	}
+}
+
+func (fs *Filesystem) writeMessage(msg *transport.Message) error {
+	fileName := bundleFileName(msg.ID, msg.MsgType, msg.Version)
+	if filepath.Base(fileName) != fileName || strings.HasPrefix(fileName, tempFilePrefix) {
+		return fmt.Errorf("%w: %s", errInvalidFileName, fileName)
+	}
+
+	bundleFile := &BundleFile{
+		ID:       msg.ID,
+		MsgType:  msg.MsgType,
+		Version:  msg.Version,
+		Metadata: msg.Metadata,
+	}
+
+	if json.Valid(msg.Payload) {
+		bundleFile.Payload = msg.Payload
+	} else {
+		bundleFile.EncodedPayload = msg.Payload
+	}
+
+	content, err := json.MarshalIndent(bundleFile, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal bundle file - %w", err)
+	}
+
+	if err := fs.writeFileAtomically(fileName, content); err != nil {
+		fs.log.Error(err, "Failed to write the bundle file", "file", fileName)
+		return err
+	}
+
+	fs.removeOlderVersions(msg.ID, msg.MsgType, msg.Version)
+
+	fs.log.Info(fmt.Sprintf("Message '%s' from type '%s' with version '%s' sent", msg.ID, msg.MsgType, msg.Version))
+
+	return nil
+}
+
+// writeFileAtomically writes the content to a hidden temp file in the directory and renames it to the file name, so
+// readers never see a partially written file.
+func (fs *Filesystem) writeFileAtomically(fileName string, content []byte) error {
+	tempFile, err := ioutil.TempFile(fs.dir, tempFilePrefix+fileName+"-*"+tempFileSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to create temp file - %w", err)
+	}
+
+	tempPath := tempFile.Name()
+	defer os.Remove(tempPath) // no-op once renamed
+
+	if _, err := tempFile.Write(content); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("failed to write temp file - %w", err)
+	}
+
+	if err := tempFile.Sync(); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("failed to sync temp file - %w", err)
+	}
+
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file - %w", err)
+	}
+
+	if err := os.Chmod(tempPath, filePermissions); err != nil {
+		return fmt.Errorf("failed to set permissions of temp file - %w", err)
+	}
+
+	if err := os.Rename(tempPath, filepath.Join(fs.dir, fileName)); err != nil {
+		return fmt.Errorf("failed to rename temp file - %w", err)
+	}
+
+	return nil
+}
+
+func (fs *Filesystem) removeOlderVersions(id string, msgType string, version string) {
+	versions, err := fs.listVersions(id, msgType)
+	if err != nil {
+		fs.log.Error(err, "Failed to read the filesystem transport directory")
+		return
+	}
+
+	for _, olderVersion := range versions {
+		if transport.CompareVersions(olderVersion, version) >= 0 {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(fs.dir, bundleFileName(id, msgType, olderVersion))); err != nil &&
+			!os.IsNotExist(err) {
+			fs.log.Error(err, "Failed to remove older bundle file", "id", id, "type", msgType,
+				"version", olderVersion)
+		}
+	}
+}
+
+// listVersions returns the versions of the bundle files in the directory with the given id and type.
+func (fs *Filesystem) listVersions(id string, msgType string) ([]string, error) {
+	files, err := ioutil.ReadDir(fs.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory - %w", err)
+	}
+
+	prefix := fmt.Sprintf("%s.%s.", id, msgType)
+	versions := make([]string, 0, 1)
+
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, bundleFileSuffix) {
+			continue
+		}
+
+		if version := strings.TrimSuffix(strings.TrimPrefix(name, prefix), bundleFileSuffix); version != "" {
+			versions = append(versions, version)
+		}
+	}
+
+	return versions, nil
+}
+
+func bundleFileName(id string, msgType string, version string) string {
+	return fmt.Sprintf("%s.%s.%s%s", id, msgType, version, bundleFileSuffix)
+}
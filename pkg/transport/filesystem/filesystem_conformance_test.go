@@ -0,0 +1,31 @@
+package filesystem
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/conformance"
+)
+
+func TestConformance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filesystem-transport")
+	if err != nil {
+		t.Fatalf("failed to create a temporary directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	if err := os.Setenv(envVarFilesystemTransportDir, dir); err != nil {
+		t.Fatalf("failed to set %s: %v", envVarFilesystemTransportDir, err)
+	}
+
+	defer os.Unsetenv(envVarFilesystemTransportDir)
+
+	conformance.Run(t, func() (transport.Transport, error) {
+		return NewFilesystem(logrtesting.NullLogger{})
+	}, &conformance.Options{DeliveryTimeout: 10 * time.Second})
+}
@@ -0,0 +1,643 @@
+package grpc
+
+//go:generate protoc --go_out=paths=source_relative:. --go-grpc_out=paths=source_relative:. status_sync.proto
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	envVarGRPCHubAddress     = "GRPC_HUB_ADDRESS"
+	envVarGRPCInsecure       = "GRPC_INSECURE"
+	envVarGRPCCACertPath     = "GRPC_CA_CERT_PATH"
+	envVarGRPCClientCertPath = "GRPC_CLIENT_CERT_PATH"
+	envVarGRPCClientKeyPath  = "GRPC_CLIENT_KEY_PATH"
+	envVarGRPCAckTimeout     = "GRPC_ACK_TIMEOUT"
+	envVarGRPCDrainTimeout   = "GRPC_DRAIN_TIMEOUT"
+	envVarLeafHubID          = "LH_ID"
+
+	// LeafHubIDMetadataKey is the request metadata key that identifies the leaf hub.
+	LeafHubIDMetadataKey = "x-leaf-hub-id"
+
+	defaultAckTimeout   = 30 * time.Second
+	defaultDrainTimeout = 10 * time.Second
+	maxResumeWait       = 5 * time.Second
+	keepaliveTime       = 30 * time.Second
+	keepaliveTimeout    = 15 * time.Second
+)
+
+var (
+	errEnvVarNotFound     = errors.New("not found environment variable")
+	errEnvVarWrongType    = errors.New("wrong type of environment variable")
+	errEnvVarIllegalValue = errors.New("illegal value of environment variable")
+	errFailedToLoadCACert = errors.New("failed to append CA certificate to the pool")
+	errStreamEnded        = errors.New("the hub ended the stream")
+	errBundleRejected     = errors.New("the hub rejected the bundle")
+	errAckTimeout         = errors.New("timed out waiting for the hub to ack the bundle")
+	errGRPCStopped        = errors.New("grpc was stopped")
+)
+
+// TransportType is the transport type the gRPC transport is registered under.
+const TransportType = "grpc"
+
+func init() {
+	transport.Register(TransportType, func(log logr.Logger) (transport.Service, error) {
+		return NewGRPC(log)
+	})
+}
+
+// GRPC abstracts a transport that streams bundles to the hub over the bidirectional StatusSync.SyncBundles gRPC
+// method of status_sync.proto, and reports the delivery result of each bundle when the hub acks it. the versions
+// the hub acked back GetVersion. a broken stream is reopened, the hub then resends its acks and the bundles that
+// were not acked yet are sent again.
+type GRPC struct {
+	conn           *grpc.ClientConn
+	client         StatusSyncClient
+	leafHubID      string
+	ackTimeout     time.Duration
+	retryPolicy    *transport.RetryPolicy
+	queue          *transport.MessageQueue
+	stream         *bundleStream
+	pending        map[string]*pendingBundle
+	versions       map[string]string
+	resumedChan    chan struct{}
+	resumedOnce    sync.Once
+	hubUnreachable bool // the last attempt to open the stream failed, or the stream broke
+	lock           sync.Mutex
+	drainTimeout   time.Duration
+	drainChan      chan struct{}
+	doneChan       chan struct{}
+	stopChan       chan struct{}
+	startOnce      sync.Once
+	stopOnce       sync.Once
+	log            logr.Logger
+}
+
+// pendingBundle is a bundle that was sent and is waiting for its ack.
+type pendingBundle struct {
+	message  *transport.Message
+	sentTime time.Time
+}
+
+// NewGRPC creates a new instance of GRPC, configured using environment variables.
+func NewGRPC(log logr.Logger) (*GRPC, error) {
+	address, leafHubID, plaintext, ackTimeout, drainTimeout, err := readEnvVars()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize grpc - %w", err)
+	}
+
+	transportCredentials := insecure.NewCredentials() // plaintext http/2
+
+	if !plaintext {
+		tlsConfig, err := readTLSEnvVars()
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize grpc - %w", err)
+		}
+
+		transportCredentials = credentials.NewTLS(tlsConfig)
+	}
+
+	queueCapacity, err := transport.ReadMessageQueueCapacity()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize grpc - %w", err)
+	}
+
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(transportCredentials),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{ // ping the hub to detect broken connections
+			Time:                keepaliveTime,
+			Timeout:             keepaliveTimeout,
+			PermitWithoutStream: true,
+		}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize grpc - %w", err)
+	}
+
+	return NewGRPCWithConn(conn, leafHubID, ackTimeout, queueCapacity, drainTimeout, log), nil
+}
+
+// NewGRPCWithConn creates a new instance of GRPC that streams to the hub using the given client connection. the
+// connection is closed when GRPC is stopped.
+func NewGRPCWithConn(conn *grpc.ClientConn, leafHubID string, ackTimeout time.Duration, queueCapacity int,
+	drainTimeout time.Duration, log logr.Logger) *GRPC {
+	return &GRPC{
+		conn:       conn,
+		client:     NewStatusSyncClient(conn),
+		leafHubID:  leafHubID,
+		ackTimeout: ackTimeout,
+		retryPolicy: &transport.RetryPolicy{
+			InitialBackoff: time.Second,
+			MaxBackoff:     time.Minute,
+		},
+		queue:        transport.NewMessageQueue("grpc", queueCapacity),
+		pending:      make(map[string]*pendingBundle),
+		versions:     make(map[string]string),
+		resumedChan:  make(chan struct{}),
+		drainTimeout: drainTimeout,
+		drainChan:    make(chan struct{}),
+		doneChan:     make(chan struct{}),
+		stopChan:     make(chan struct{}),
+		log:          log,
+	}
+}
+
+func readEnvVars() (string, string, bool, time.Duration, time.Duration, error) {
+	address := os.Getenv(envVarGRPCHubAddress)
+	if address == "" {
+		return "", "", false, 0, 0, fmt.Errorf("%w: %s", errEnvVarNotFound, envVarGRPCHubAddress)
+	}
+
+	leafHubID := os.Getenv(envVarLeafHubID)
+	if leafHubID == "" {
+		return "", "", false, 0, 0, fmt.Errorf("%w: %s", errEnvVarNotFound, envVarLeafHubID)
+	}
+
+	plaintext := false
+
+	if insecureStr := os.Getenv(envVarGRPCInsecure); insecureStr != "" {
+		var err error
+		if plaintext, err = strconv.ParseBool(insecureStr); err != nil {
+			return "", "", false, 0, 0, fmt.Errorf("%w: %s must be a boolean", errEnvVarWrongType,
+				envVarGRPCInsecure)
+		}
+	}
+
+	ackTimeout, err := readDurationEnvVar(envVarGRPCAckTimeout, defaultAckTimeout)
+	if err != nil {
+		return "", "", false, 0, 0, err
+	}
+
+	drainTimeout, err := readDurationEnvVar(envVarGRPCDrainTimeout, defaultDrainTimeout)
+	if err != nil {
+		return "", "", false, 0, 0, err
+	}
+
+	return address, leafHubID, plaintext, ackTimeout, drainTimeout, nil
+}
+
+// readDurationEnvVar returns the default value if the environment variable is not set.
+func readDurationEnvVar(name string, defaultValue time.Duration) (time.Duration, error) {
+	valueStr := os.Getenv(name)
+	if valueStr == "" {
+		return defaultValue, nil
+	}
+
+	value, err := time.ParseDuration(valueStr)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("%w: %s must be a positive duration", errEnvVarWrongType, name)
+	}
+
+	return value, nil
+}
+
+func readTLSEnvVars() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caCertPath := os.Getenv(envVarGRPCCACertPath); caCertPath != "" {
+		caCert, err := ioutil.ReadFile(caCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate - %w", err)
+		}
+
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("%w: %s", errFailedToLoadCACert, caCertPath)
+		}
+
+		tlsConfig.RootCAs = certPool
+	}
+
+	clientCertPath := os.Getenv(envVarGRPCClientCertPath)
+	clientKeyPath := os.Getenv(envVarGRPCClientKeyPath)
+
+	if (clientCertPath == "") != (clientKeyPath == "") {
+		return nil, fmt.Errorf("%w: %s and %s must be set together", errEnvVarIllegalValue,
+			envVarGRPCClientCertPath, envVarGRPCClientKeyPath)
+	}
+
+	if clientCertPath != "" {
+		clientCert, err := tls.LoadX509KeyPair(clientCertPath, clientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate - %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	return tlsConfig, nil
+}
+
+// Start function starts grpc. the stream to the hub is opened in the background and reopened when it breaks.
+func (g *GRPC) Start() {
+	g.startOnce.Do(func() {
+		go g.maintainStream()
+		go g.expirePendingBundles()
+		go g.sendMessages()
+	})
+}
+
+// Stop function stops grpc. messages that are waiting in the queue are sent and acked until the drain timeout
+// expires, the messages that were not acked by then are reported as failed.
+func (g *GRPC) Stop() {
+	g.stopOnce.Do(func() {
+		g.queue.Close() // messages that are sent from now on are reported as failed
+		close(g.drainChan)
+
+		if !transport.WasStarted(&g.startOnce) {
+			g.log.Info("grpc was not started")
+		} else if g.waitForDrain() {
+			g.log.Info("all messages were sent")
+		} else {
+			g.log.Info(fmt.Sprintf("drain timeout expired, %d messages were not sent", g.queue.Len()+
+				g.pendingCount()))
+		}
+
+		close(g.stopChan)
+
+		g.lock.Lock()
+		stream := g.stream
+		g.stream = nil
+		pending := g.pending
+		g.pending = make(map[string]*pendingBundle)
+		g.lock.Unlock()
+
+		if stream != nil {
+			stream.close()
+		}
+
+		for _, pendingBundle := range pending {
+			pendingBundle.message.ReportDeliveryResult(errGRPCStopped)
+		}
+
+		if err := g.conn.Close(); err != nil {
+			g.log.Error(err, "Failed to close the connection to the hub")
+		}
+	})
+}
+
+// SendAsync function streams a message to the hub asynchronously. the delivery result is reported when the hub acks
+// the message.
+func (g *GRPC) SendAsync(message *transport.Message) {
+	g.queue.Push(message)
+}
+
+// GetVersion returns the version of the bundle with the given id and type that the hub acked last. if the hub didn't
+// resend its acks yet, waits for them up to the ack timeout or 5 seconds, whichever is shorter, or not at all while
+// the hub can't be reached. if the hub has no such bundle, or its acks didn't arrive, returns an empty string, so the
+// bundle is sent again.
+func (g *GRPC) GetVersion(id string, msgType string) string {
+	select {
+	case <-g.resumedChan:
+	default:
+		if g.isHubUnreachable() {
+			g.log.Info("the hub can't be reached, its acks were not resent yet", "id", id, "type", msgType)
+			return ""
+		}
+
+		select {
+		case <-g.resumedChan:
+		case <-time.After(minDuration(g.ackTimeout, maxResumeWait)):
+			g.log.Info("timed out waiting for the hub to resend its acks", "id", id, "type", msgType)
+		}
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.versions[bundleKey(id, msgType)]
+}
+
+// Subscribe function does nothing, receiving commands is not supported by the grpc transport.
+func (g *GRPC) Subscribe(string, transport.CommandHandler) {}
+
+func (g *GRPC) sendMessages() {
+	defer close(g.doneChan)
+
+	for {
+		msg := g.queue.Pop(g.drainChan)
+		if msg == nil { // drained
+			return
+		}
+
+		select {
+		case <-g.stopChan: // drain timeout expired
+			msg.ReportDeliveryResult(errGRPCStopped)
+			return
+		default:
+		}
+
+		g.sendMessage(msg)
+	}
+}
+
+// sendMessage adds the message to the pending bundles and streams it to the hub. if there is no stream, the message
+// is sent once the stream is reopened.
+func (g *GRPC) sendMessage(msg *transport.Message) {
+	key := bundleKey(msg.ID, msg.MsgType)
+
+	g.lock.Lock()
+	supersededBundle := g.pending[key]
+	g.pending[key] = &pendingBundle{message: msg, sentTime: time.Now()}
+	stream := g.stream
+	g.lock.Unlock()
+
+	if supersededBundle != nil {
+		supersededBundle.message.ReportDeliveryResult(transport.ErrMessageSuperseded)
+	}
+
+	if stream == nil {
+		return
+	}
+
+	if err := stream.send(msg); err != nil {
+		g.log.Error(err, "Failed to stream the message to the hub, reopening the stream")
+		stream.close() // the receiver of the stream notices and the stream is reopened
+	}
+}
+
+// maintainStream opens the stream to the hub and reopens it with backoff when it breaks, until grpc is stopped.
+func (g *GRPC) maintainStream() {
+	attempt := 0
+
+	for {
+		stream, err := openBundleStream(g.client, g.leafHubID)
+		if err != nil {
+			g.log.Error(err, "Failed to open the stream to the hub")
+			attempt++
+
+			g.setHubUnreachable(true)
+		} else {
+			g.log.Info("opened the stream to the hub")
+			attempt = 0
+
+			g.lock.Lock()
+			g.stream = stream
+			g.hubUnreachable = false
+			g.lock.Unlock()
+
+			go g.resume(stream)
+
+			err = g.receiveAcks(stream) // returns once the stream breaks
+			g.clearStream(stream)
+			g.setHubUnreachable(true)
+
+			select {
+			case <-g.stopChan:
+				return
+			default:
+				g.log.Error(err, "The stream to the hub broke")
+			}
+		}
+
+		select {
+		case <-g.stopChan:
+			return
+		case <-time.After(g.retryPolicy.Backoff(attempt)):
+		}
+	}
+}
+
+// resume sends the bundles that were not acked yet on the new stream.
+func (g *GRPC) resume(stream *bundleStream) {
+	g.lock.Lock()
+
+	messages := make([]*transport.Message, 0, len(g.pending))
+
+	for _, pendingBundle := range g.pending {
+		pendingBundle.sentTime = time.Now()
+		messages = append(messages, pendingBundle.message)
+	}
+	g.lock.Unlock()
+
+	for _, msg := range messages {
+		if err := stream.send(msg); err != nil {
+			g.log.Error(err, "Failed to resend the message to the hub")
+			stream.close()
+
+			return
+		}
+	}
+}
+
+// clearStream closes the stream and clears the current stream if it is still the given stream.
+func (g *GRPC) clearStream(stream *bundleStream) {
+	g.lock.Lock()
+	if g.stream == stream {
+		g.stream = nil
+	}
+	g.lock.Unlock()
+
+	stream.close()
+}
+
+// receiveAcks handles the acks of the stream until it breaks.
+func (g *GRPC) receiveAcks(stream *bundleStream) error {
+	for {
+		ack, err := stream.receive()
+		if err != nil {
+			return err
+		}
+
+		if ack.GetId() == "" { // end of the resume acks
+			g.resumedOnce.Do(func() { close(g.resumedChan) })
+			continue
+		}
+
+		g.handleAck(ack)
+	}
+}
+
+func (g *GRPC) handleAck(ack *BundleAck) {
+	key := bundleKey(ack.GetId(), ack.GetType())
+
+	g.lock.Lock()
+
+	if ack.GetError() == "" {
+		g.versions[key] = ack.GetVersion()
+	}
+
+	pendingBundle, found := g.pending[key]
+	if found && pendingBundle.message.Version == ack.GetVersion() {
+		delete(g.pending, key)
+	} else {
+		pendingBundle = nil // an ack of a resumed or superseded bundle
+	}
+	g.lock.Unlock()
+
+	if pendingBundle == nil {
+		return
+	}
+
+	if ack.GetError() != "" {
+		pendingBundle.message.ReportDeliveryResult(fmt.Errorf("%w: %s", errBundleRejected, ack.GetError()))
+		return
+	}
+
+	g.log.Info(fmt.Sprintf("Message '%s' from type '%s' with version '%s' sent", ack.GetId(), ack.GetType(),
+		ack.GetVersion()))
+	pendingBundle.message.ReportDeliveryResult(nil)
+}
+
+// expirePendingBundles reports the bundles that were not acked within the ack timeout as failed, so they are sent
+// again.
+func (g *GRPC) expirePendingBundles() {
+	ticker := time.NewTicker(g.ackTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-g.stopChan:
+			return
+		case <-ticker.C:
+		}
+
+		var expired []*pendingBundle
+
+		g.lock.Lock()
+
+		for key, pendingBundle := range g.pending {
+			if time.Since(pendingBundle.sentTime) >= g.ackTimeout {
+				expired = append(expired, pendingBundle)
+				delete(g.pending, key)
+			}
+		}
+		g.lock.Unlock()
+
+		for _, pendingBundle := range expired {
+			pendingBundle.message.ReportDeliveryResult(errAckTimeout)
+		}
+	}
+}
+
+// waitForDrain waits until the queue is drained and all the bundles are acked. returns false if the drain timeout
+// expired before.
+func (g *GRPC) waitForDrain() bool {
+	deadline := time.After(g.drainTimeout)
+
+	select {
+	case <-g.doneChan:
+	case <-deadline:
+		return false
+	}
+
+	ticker := time.NewTicker(time.Second / 10)
+	defer ticker.Stop()
+
+	for g.pendingCount() > 0 {
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			return false
+		}
+	}
+
+	return true
+}
+
+func (g *GRPC) pendingCount() int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return len(g.pending)
+}
+
+func (g *GRPC) isHubUnreachable() bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.hubUnreachable
+}
+
+func (g *GRPC) setHubUnreachable(unreachable bool) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.hubUnreachable = unreachable
+}
+
+func minDuration(a time.Duration, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func bundleKey(id string, msgType string) string {
+	return fmt.Sprintf("%s.%s", msgType, id)
+}
+
+// bundleStream is an open SyncBundles call.
+type bundleStream struct {
+	stream     StatusSync_SyncBundlesClient
+	cancelFunc context.CancelFunc
+	sendLock   sync.Mutex
+}
+
+func openBundleStream(client StatusSyncClient, leafHubID string) (*bundleStream, error) {
+	ctx, cancelFunc := context.WithCancel(metadata.AppendToOutgoingContext(context.Background(),
+		LeafHubIDMetadataKey, leafHubID))
+
+	stream, err := client.SyncBundles(ctx)
+	if err != nil {
+		cancelFunc()
+		return nil, fmt.Errorf("failed to call the hub - %w", err)
+	}
+
+	return &bundleStream{stream: stream, cancelFunc: cancelFunc}, nil
+}
+
+// send sends a bundle on the stream. the stream is shared by the sender of the queued messages and the resume of the
+// pending bundles, and grpc streams must not be sent on concurrently.
+func (s *bundleStream) send(msg *transport.Message) error {
+	s.sendLock.Lock()
+	defer s.sendLock.Unlock()
+
+	if err := s.stream.Send(&Bundle{
+		Id:       msg.ID,
+		Type:     msg.MsgType,
+		Version:  msg.Version,
+		Metadata: msg.Metadata,
+		Payload:  msg.Payload,
+	}); err != nil {
+		return fmt.Errorf("failed to send the bundle - %w", err)
+	}
+
+	return nil
+}
+
+func (s *bundleStream) receive() (*BundleAck, error) {
+	ack, err := s.stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return nil, errStreamEnded
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errStreamEnded, status.Convert(err))
+	}
+
+	return ack, nil
+}
+
+// close cancels the call, which ends the stream on both sides.
+func (s *bundleStream) close() {
+	s.cancelFunc()
+}
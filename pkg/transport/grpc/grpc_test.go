@@ -0,0 +1,356 @@
+package grpc
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	testLeafHubID     = "hub1"
+	testMsgType       = "ClustersPerPolicy"
+	testTimeout       = 5 * time.Second
+	testAckTimeout    = 2 * time.Second
+	testDrainTimeout  = 2 * time.Second
+	testRejectVersion = "reject"
+)
+
+// fakeHub is an in-process StatusSync server that stands in for the hub. it keeps the latest accepted bundle per id
+// and type, acks every bundle, and rejects bundles with the version testRejectVersion. if dropNextBundle is set, the
+// next bundle is neither kept nor acked and the stream is ended, as if the hub failed. if holdAcks is set, no acks
+// are sent.
+type fakeHub struct {
+	UnimplementedStatusSyncServer
+	bundles        map[string]*Bundle
+	received       []*Bundle
+	leafHubIDs     []string
+	dropNextBundle bool
+	holdAcks       bool
+	lock           sync.Mutex
+	receivedChan   chan *Bundle
+}
+
+func newFakeHub() *fakeHub {
+	return &fakeHub{
+		bundles:      make(map[string]*Bundle),
+		receivedChan: make(chan *Bundle, 100),
+	}
+}
+
+func (h *fakeHub) SyncBundles(stream StatusSync_SyncBundlesServer) error {
+	requestMetadata, _ := metadata.FromIncomingContext(stream.Context())
+
+	h.lock.Lock()
+	h.leafHubIDs = append(h.leafHubIDs, requestMetadata.Get(LeafHubIDMetadataKey)...)
+
+	resumeAcks := make([]*BundleAck, 0, len(h.bundles)+1)
+
+	for _, heldBundle := range h.bundles {
+		resumeAcks = append(resumeAcks, &BundleAck{Id: heldBundle.Id, Type: heldBundle.Type,
+			Version: heldBundle.Version})
+	}
+	h.lock.Unlock()
+
+	resumeAcks = append(resumeAcks, &BundleAck{}) // end of the resume acks
+
+	for _, ack := range resumeAcks {
+		if err := stream.Send(ack); err != nil {
+			return err
+		}
+	}
+
+	for {
+		receivedBundle, err := stream.Recv()
+		if err != nil {
+			return nil //nolint:nilerr // the leaf hub ended the stream
+		}
+
+		ack := &BundleAck{Id: receivedBundle.Id, Type: receivedBundle.Type, Version: receivedBundle.Version}
+
+		h.lock.Lock()
+		h.received = append(h.received, receivedBundle)
+		dropBundle := h.dropNextBundle
+		h.dropNextBundle = false
+		holdAcks := h.holdAcks
+
+		switch {
+		case dropBundle:
+		case receivedBundle.Version == testRejectVersion:
+			ack.Error = "rejected by test"
+		default:
+			h.bundles[bundleKey(receivedBundle.Id, receivedBundle.Type)] = receivedBundle
+		}
+		h.lock.Unlock()
+
+		h.receivedChan <- receivedBundle
+
+		if dropBundle {
+			return status.Error(codes.Unavailable, "dropped by test")
+		}
+
+		if holdAcks {
+			continue
+		}
+
+		if err := stream.Send(ack); err != nil {
+			return err
+		}
+	}
+}
+
+func (h *fakeHub) dropNext() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.dropNextBundle = true
+}
+
+func (h *fakeHub) waitForBundle(t *testing.T) *Bundle {
+	t.Helper()
+
+	select {
+	case receivedBundle := <-h.receivedChan:
+		return receivedBundle
+	case <-time.After(testTimeout):
+		t.Fatal("the hub did not receive a bundle")
+		return nil
+	}
+}
+
+// startFakeHub serves a fake hub on a local port, returns the hub and its address.
+func startFakeHub(t *testing.T) (*fakeHub, string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	hub := newFakeHub()
+	server := grpc.NewServer()
+	RegisterStatusSyncServer(server, hub)
+
+	go func() { _ = server.Serve(listener) }()
+
+	t.Cleanup(server.Stop)
+
+	return hub, listener.Addr().String()
+}
+
+func newTestGRPC(t *testing.T, address string) *GRPC {
+	t.Helper()
+
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to create the client connection: %v", err)
+	}
+
+	grpcTransport := NewGRPCWithConn(conn, testLeafHubID, testAckTimeout, transport.DefaultMessageQueueCapacity,
+		testDrainTimeout, logrtesting.NullLogger{})
+	grpcTransport.retryPolicy.InitialBackoff = 10 * time.Millisecond
+	grpcTransport.retryPolicy.MaxBackoff = 100 * time.Millisecond
+
+	return grpcTransport
+}
+
+func sendAndWait(t *testing.T, grpcTransport *GRPC, id string, version string,
+	metadata map[string]string) error {
+	t.Helper()
+
+	resultChan := make(chan error, 1)
+
+	grpcTransport.SendAsync(&transport.Message{
+		ID:               id,
+		MsgType:          testMsgType,
+		Version:          version,
+		Payload:          []byte(`{"generation":1}`),
+		Metadata:         metadata,
+		DeliveryCallback: func(err error) { resultChan <- err },
+	})
+
+	select {
+	case err := <-resultChan:
+		return err
+	case <-time.After(testTimeout):
+		t.Fatalf("no delivery result for %s version %s", id, version)
+		return nil
+	}
+}
+
+func TestSendIsAckedAndBacksGetVersion(t *testing.T) {
+	hub, address := startFakeHub(t)
+
+	grpcTransport := newTestGRPC(t, address)
+	grpcTransport.Start()
+	defer grpcTransport.Stop()
+
+	if version := grpcTransport.GetVersion("hub1.policies", testMsgType); version != "" {
+		t.Fatalf("expected no version before sending, got %q", version)
+	}
+
+	if err := sendAndWait(t, grpcTransport, "hub1.policies", "3", map[string]string{"codec": "protobuf"}); err != nil {
+		t.Fatalf("expected the bundle to be acked, got %v", err)
+	}
+
+	if version := grpcTransport.GetVersion("hub1.policies", testMsgType); version != "3" {
+		t.Fatalf("expected version 3 from the ack, got %q", version)
+	}
+
+	hub.lock.Lock()
+	defer hub.lock.Unlock()
+
+	expectedBundle := &Bundle{
+		Id:       "hub1.policies",
+		Type:     testMsgType,
+		Version:  "3",
+		Metadata: map[string]string{"codec": "protobuf"},
+		Payload:  []byte(`{"generation":1}`),
+	}
+
+	if received := hub.received[0]; !proto.Equal(received, expectedBundle) {
+		t.Fatalf("expected %v to be received by the hub, got %v", expectedBundle, received)
+	}
+
+	if len(hub.leafHubIDs) == 0 || hub.leafHubIDs[0] != testLeafHubID {
+		t.Fatalf("expected leaf hub id %s in the request metadata, got %q", testLeafHubID, hub.leafHubIDs[0])
+	}
+}
+
+func TestRejectedBundleIsReported(t *testing.T) {
+	_, address := startFakeHub(t)
+
+	grpcTransport := newTestGRPC(t, address)
+	grpcTransport.Start()
+	defer grpcTransport.Stop()
+
+	if err := sendAndWait(t, grpcTransport, "hub1.policies", "1", nil); err != nil {
+		t.Fatalf("expected the bundle to be acked, got %v", err)
+	}
+
+	if err := sendAndWait(t, grpcTransport, "hub1.policies", testRejectVersion, nil); !errors.Is(err,
+		errBundleRejected) {
+		t.Fatalf("expected %v, got %v", errBundleRejected, err)
+	}
+
+	if version := grpcTransport.GetVersion("hub1.policies", testMsgType); version != "1" {
+		t.Fatalf("expected the accepted version 1 to be kept, got %q", version)
+	}
+}
+
+func TestGetVersionIsResumedFromTheHub(t *testing.T) {
+	_, address := startFakeHub(t)
+
+	grpcTransport := newTestGRPC(t, address)
+	grpcTransport.Start()
+
+	if err := sendAndWait(t, grpcTransport, "hub1.policies", "7", nil); err != nil {
+		t.Fatalf("expected the bundle to be acked, got %v", err)
+	}
+
+	grpcTransport.Stop()
+
+	restartedTransport := newTestGRPC(t, address)
+	restartedTransport.Start()
+	defer restartedTransport.Stop()
+
+	if version := restartedTransport.GetVersion("hub1.policies", testMsgType); version != "7" {
+		t.Fatalf("expected version 7 from the resume acks, got %q", version)
+	}
+}
+
+func TestUnackedBundleIsResentOnReconnect(t *testing.T) {
+	hub, address := startFakeHub(t)
+
+	grpcTransport := newTestGRPC(t, address)
+	grpcTransport.Start()
+	defer grpcTransport.Stop()
+
+	hub.dropNext()
+
+	resultChan := make(chan error, 1)
+
+	grpcTransport.SendAsync(&transport.Message{
+		ID:               "hub1.policies",
+		MsgType:          testMsgType,
+		Version:          "5",
+		DeliveryCallback: func(err error) { resultChan <- err },
+	})
+
+	hub.waitForBundle(t) // dropped and the stream ended
+
+	if resentBundle := hub.waitForBundle(t); resentBundle.Version != "5" {
+		t.Fatalf("expected version 5 to be resent, got %q", resentBundle.Version)
+	}
+
+	select {
+	case err := <-resultChan:
+		if err != nil {
+			t.Fatalf("expected the resent bundle to be acked, got %v", err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("no delivery result for the resent bundle")
+	}
+}
+
+func TestUnackedBundleTimesOut(t *testing.T) {
+	hub, address := startFakeHub(t)
+	hub.holdAcks = true
+
+	grpcTransport := newTestGRPC(t, address)
+	grpcTransport.Start()
+	defer grpcTransport.Stop()
+
+	start := time.Now()
+
+	if err := sendAndWait(t, grpcTransport, "hub1.policies", "1", nil); !errors.Is(err, errAckTimeout) {
+		t.Fatalf("expected %v, got %v", errAckTimeout, err)
+	}
+
+	if elapsed := time.Since(start); elapsed < testAckTimeout {
+		t.Fatalf("expected the ack timeout of %s to pass, failed after %s", testAckTimeout, elapsed)
+	}
+}
+
+func TestGetVersionDoesNotWaitWhileTheHubIsUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	address := listener.Addr().String()
+	listener.Close() // nothing listens on the address anymore
+
+	grpcTransport := newTestGRPC(t, address)
+	grpcTransport.Start()
+	defer grpcTransport.Stop()
+
+	deadline := time.Now().Add(testTimeout)
+	for !grpcTransport.isHubUnreachable() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the failed attempt to open the stream")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	start := time.Now()
+
+	if version := grpcTransport.GetVersion("hub1.policies", testMsgType); version != "" {
+		t.Fatalf("expected no version, got %q", version)
+	}
+
+	if elapsed := time.Since(start); elapsed >= testAckTimeout {
+		t.Fatalf("expected GetVersion to return without waiting for the acks, returned after %s", elapsed)
+	}
+}
@@ -0,0 +1,151 @@
+// The service the gRPC transport (transport type "grpc") streams the status bundles to.
+// The Go stubs in status_sync.pb.go and status_sync_grpc.pb.go are generated from this file using protoc-gen-go and
+// protoc-gen-go-grpc, run go generate after changing it. Hub side servers can generate their stubs from this file.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: status_sync.proto
+
+package grpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	StatusSync_SyncBundles_FullMethodName = "/hubofhubs.status.v1.StatusSync/SyncBundles"
+)
+
+// StatusSyncClient is the client API for StatusSync service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type StatusSyncClient interface {
+	// SyncBundles streams bundles from a leaf hub, identified by the x-leaf-hub-id request metadata, and replies with an
+	// ack per bundle. when the stream opens the hub first sends an ack for every bundle it holds from the leaf hub,
+	// followed by an ack with an empty id that ends the resume acks.
+	SyncBundles(ctx context.Context, opts ...grpc.CallOption) (StatusSync_SyncBundlesClient, error)
+}
+
+type statusSyncClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewStatusSyncClient(cc grpc.ClientConnInterface) StatusSyncClient {
+	return &statusSyncClient{cc}
+}
+
+func (c *statusSyncClient) SyncBundles(ctx context.Context, opts ...grpc.CallOption) (StatusSync_SyncBundlesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &StatusSync_ServiceDesc.Streams[0], StatusSync_SyncBundles_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &statusSyncSyncBundlesClient{stream}
+	return x, nil
+}
+
+type StatusSync_SyncBundlesClient interface {
+	Send(*Bundle) error
+	Recv() (*BundleAck, error)
+	grpc.ClientStream
+}
+
+type statusSyncSyncBundlesClient struct {
+	grpc.ClientStream
+}
+
+func (x *statusSyncSyncBundlesClient) Send(m *Bundle) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *statusSyncSyncBundlesClient) Recv() (*BundleAck, error) {
+	m := new(BundleAck)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// StatusSyncServer is the server API for StatusSync service.
+// All implementations must embed UnimplementedStatusSyncServer
+// for forward compatibility
+type StatusSyncServer interface {
+	// SyncBundles streams bundles from a leaf hub, identified by the x-leaf-hub-id request metadata, and replies with an
+	// ack per bundle. when the stream opens the hub first sends an ack for every bundle it holds from the leaf hub,
+	// followed by an ack with an empty id that ends the resume acks.
+	SyncBundles(StatusSync_SyncBundlesServer) error
+	mustEmbedUnimplementedStatusSyncServer()
+}
+
+// UnimplementedStatusSyncServer must be embedded to have forward compatible implementations.
+type UnimplementedStatusSyncServer struct {
+}
+
+func (UnimplementedStatusSyncServer) SyncBundles(StatusSync_SyncBundlesServer) error {
+	return status.Errorf(codes.Unimplemented, "method SyncBundles not implemented")
+}
+func (UnimplementedStatusSyncServer) mustEmbedUnimplementedStatusSyncServer() {}
+
+// UnsafeStatusSyncServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to StatusSyncServer will
+// result in compilation errors.
+type UnsafeStatusSyncServer interface {
+	mustEmbedUnimplementedStatusSyncServer()
+}
+
+func RegisterStatusSyncServer(s grpc.ServiceRegistrar, srv StatusSyncServer) {
+	s.RegisterService(&StatusSync_ServiceDesc, srv)
+}
+
+func _StatusSync_SyncBundles_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(StatusSyncServer).SyncBundles(&statusSyncSyncBundlesServer{stream})
+}
+
+type StatusSync_SyncBundlesServer interface {
+	Send(*BundleAck) error
+	Recv() (*Bundle, error)
+	grpc.ServerStream
+}
+
+type statusSyncSyncBundlesServer struct {
+	grpc.ServerStream
+}
+
+func (x *statusSyncSyncBundlesServer) Send(m *BundleAck) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *statusSyncSyncBundlesServer) Recv() (*Bundle, error) {
+	m := new(Bundle)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// StatusSync_ServiceDesc is the grpc.ServiceDesc for StatusSync service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var StatusSync_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "hubofhubs.status.v1.StatusSync",
+	HandlerType: (*StatusSyncServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SyncBundles",
+			Handler:       _StatusSync_SyncBundles_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "status_sync.proto",
+}
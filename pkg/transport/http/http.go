@@ -0,0 +1,430 @@
+package httptransport
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+)
+
+const (
+	envVarHTTPTransportURL            = "HTTP_TRANSPORT_URL"
+	envVarHTTPTransportCACertPath     = "HTTP_TRANSPORT_CA_CERT_PATH"
+	envVarHTTPTransportClientCertPath = "HTTP_TRANSPORT_CLIENT_CERT_PATH"
+	envVarHTTPTransportClientKeyPath  = "HTTP_TRANSPORT_CLIENT_KEY_PATH"
+	envVarHTTPTransportRequestTimeout = "HTTP_TRANSPORT_REQUEST_TIMEOUT"
+	envVarHTTPTransportDrainTimeout   = "HTTP_TRANSPORT_DRAIN_TIMEOUT"
+	envVarMaxRetries                  = "HTTP_TRANSPORT_MAX_RETRIES"
+	envVarRetryInitialBackoff         = "HTTP_TRANSPORT_RETRY_INITIAL_BACKOFF"
+	envVarRetryMaxBackoff             = "HTTP_TRANSPORT_RETRY_MAX_BACKOFF"
+
+	// IDHeader is the http header that holds the bundle id.
+	IDHeader = "X-Bundle-Id"
+	// MsgTypeHeader is the http header that holds the bundle type.
+	MsgTypeHeader = "X-Bundle-Type"
+	// VersionHeader is the http header that holds the bundle version, in requests and in GET responses.
+	VersionHeader = "X-Bundle-Version"
+	// MetadataHeaderPrefix is the prefix of the http headers that hold the message metadata entries.
+	MetadataHeaderPrefix = "X-Bundle-Metadata-"
+
+	defaultRequestTimeout = 10 * time.Second
+	defaultDrainTimeout   = 10 * time.Second
+
+	defaultMaxRetries          = 5
+	defaultRetryInitialBackoff = time.Second
+	defaultRetryMaxBackoff     = 30 * time.Second
+)
+
+var (
+	errEnvVarNotFound       = errors.New("not found environment variable")
+	errEnvVarWrongType      = errors.New("wrong type of environment variable")
+	errEnvVarIllegalValue   = errors.New("illegal value of environment variable")
+	errFailedToLoadCACert   = errors.New("failed to append CA certificate to the pool")
+	errUnexpectedStatus     = errors.New("unexpected response status")
+	errRejected             = errors.New("the hub rejected the message")
+	errHTTPTransportStopped = errors.New("http transport was stopped")
+)
+
+// TransportType is the transport type the HTTP transport is registered under.
+const TransportType = "http"
+
+func init() {
+	transport.Register(TransportType, func(log logr.Logger) (transport.Service, error) {
+		return NewHTTP(log)
+	})
+}
+
+// HTTP abstracts a transport that POSTs bundles to a hub of hubs REST endpoint at <url>/<msgType>/<id>. the version
+// of the last bundle the hub received is read using a GET on the same endpoint.
+type HTTP struct {
+	endpoint     *url.URL
+	client       *http.Client
+	queue        *transport.MessageQueue
+	retryPolicy  *transport.RetryPolicy
+	drainTimeout time.Duration
+	drainChan    chan struct{}
+	doneChan     chan struct{}
+	stopChan     chan struct{}
+	startOnce    sync.Once
+	stopOnce     sync.Once
+	log          logr.Logger
+}
+
+// NewHTTP creates a new instance of HTTP, configured using environment variables. mutual TLS is used if a client
+// certificate is configured. the proxy is taken from the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables.
+func NewHTTP(log logr.Logger) (*HTTP, error) {
+	endpoint, requestTimeout, drainTimeout, err := readEnvVars()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize http transport - %w", err)
+	}
+
+	retryPolicy, err := readRetryEnvVars()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize http transport - %w", err)
+	}
+
+	tlsConfig, err := readTLSEnvVars()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize http transport - %w", err)
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			TLSClientConfig:     tlsConfig,
+			TLSHandshakeTimeout: requestTimeout,
+		},
+		Timeout: requestTimeout,
+	}
+
+	queueCapacity, err := transport.ReadMessageQueueCapacity()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize http transport - %w", err)
+	}
+
+	return NewHTTPWithClient(endpoint, client, retryPolicy, queueCapacity, drainTimeout, log)
+}
+
+// NewHTTPWithClient creates a new instance of HTTP that uses the given http client, e.g. the client of an httptest
+// server that stands in for the hub.
+func NewHTTPWithClient(endpoint string, client *http.Client, retryPolicy *transport.RetryPolicy, queueCapacity int,
+	drainTimeout time.Duration, log logr.Logger) (*HTTP, error) {
+	endpointURL, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse http transport url - %w", err)
+	}
+
+	return &HTTP{
+		endpoint:     endpointURL,
+		client:       client,
+		queue:        transport.NewMessageQueue("http", queueCapacity),
+		retryPolicy:  retryPolicy,
+		drainTimeout: drainTimeout,
+		drainChan:    make(chan struct{}),
+		doneChan:     make(chan struct{}),
+		stopChan:     make(chan struct{}, 1),
+		log:          log,
+	}, nil
+}
+
+func readEnvVars() (string, time.Duration, time.Duration, error) {
+	endpoint := os.Getenv(envVarHTTPTransportURL)
+	if endpoint == "" {
+		return "", 0, 0, fmt.Errorf("%w: %s", errEnvVarNotFound, envVarHTTPTransportURL)
+	}
+
+	requestTimeout, err := readDurationEnvVar(envVarHTTPTransportRequestTimeout, defaultRequestTimeout)
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	drainTimeout, err := readDurationEnvVar(envVarHTTPTransportDrainTimeout, defaultDrainTimeout)
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	return endpoint, requestTimeout, drainTimeout, nil
+}
+
+func readRetryEnvVars() (*transport.RetryPolicy, error) {
+	maxRetries, err := readIntEnvVar(envVarMaxRetries, defaultMaxRetries)
+	if err != nil {
+		return nil, err
+	}
+
+	initialBackoff, err := readDurationEnvVar(envVarRetryInitialBackoff, defaultRetryInitialBackoff)
+	if err != nil {
+		return nil, err
+	}
+
+	maxBackoff, err := readDurationEnvVar(envVarRetryMaxBackoff, defaultRetryMaxBackoff)
+	if err != nil {
+		return nil, err
+	}
+
+	return &transport.RetryPolicy{
+		MaxRetries:     maxRetries,
+		InitialBackoff: initialBackoff,
+		MaxBackoff:     maxBackoff,
+	}, nil
+}
+
+// readIntEnvVar returns the default value if the environment variable is not set.
+func readIntEnvVar(name string, defaultValue int) (int, error) {
+	valueStr := os.Getenv(name)
+	if valueStr == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s must be an integer", errEnvVarWrongType, name)
+	}
+
+	return value, nil
+}
+
+// readDurationEnvVar returns the default value if the environment variable is not set.
+func readDurationEnvVar(name string, defaultValue time.Duration) (time.Duration, error) {
+	valueStr := os.Getenv(name)
+	if valueStr == "" {
+		return defaultValue, nil
+	}
+
+	value, err := time.ParseDuration(valueStr)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s must be a duration", errEnvVarWrongType, name)
+	}
+
+	return value, nil
+}
+
+func readTLSEnvVars() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caCertPath := os.Getenv(envVarHTTPTransportCACertPath); caCertPath != "" {
+		caCert, err := ioutil.ReadFile(caCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate - %w", err)
+		}
+
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("%w: %s", errFailedToLoadCACert, caCertPath)
+		}
+
+		tlsConfig.RootCAs = certPool
+	}
+
+	clientCertPath := os.Getenv(envVarHTTPTransportClientCertPath)
+	clientKeyPath := os.Getenv(envVarHTTPTransportClientKeyPath)
+
+	if (clientCertPath == "") != (clientKeyPath == "") {
+		return nil, fmt.Errorf("%w: %s and %s must be set together", errEnvVarIllegalValue,
+			envVarHTTPTransportClientCertPath, envVarHTTPTransportClientKeyPath)
+	}
+
+	if clientCertPath != "" {
+		clientCert, err := tls.LoadX509KeyPair(clientCertPath, clientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate - %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	return tlsConfig, nil
+}
+
+// Start function starts the http transport.
+func (h *HTTP) Start() {
+	h.startOnce.Do(func() {
+		go h.sendMessages()
+	})
+}
+
+// Stop function stops the http transport. messages that are waiting in the queue are sent until the drain timeout
+// expires.
+func (h *HTTP) Stop() {
+	h.stopOnce.Do(func() {
+		h.queue.Close() // messages that are sent from now on are reported as failed
+		close(h.drainChan)
+
+		if transport.WasStarted(&h.startOnce) {
+			select {
+			case <-h.doneChan:
+				h.log.Info("all messages were sent")
+			case <-time.After(h.drainTimeout):
+				h.log.Info(fmt.Sprintf("drain timeout expired, %d messages were not sent", h.queue.Len()))
+			}
+		}
+
+		close(h.stopChan)
+	})
+}
+
+// SendAsync function sends a message to the hub asynchronously.
+func (h *HTTP) SendAsync(message *transport.Message) {
+	h.queue.Push(message)
+}
+
+// GetVersion returns the version of the last bundle with the given id and type that the hub received. if the hub
+// has no such bundle or an error occurred returns an empty string.
+func (h *HTTP) GetVersion(id string, msgType string) string {
+	version, err := h.readVersion(id, msgType)
+	if err != nil {
+		h.log.Error(err, "Failed to read the version from the hub", "id", id, "type", msgType)
+		return ""
+	}
+
+	return version
+}
+
+// Subscribe function does nothing, receiving commands is not supported by the http transport.
+func (h *HTTP) Subscribe(string, transport.CommandHandler) {}
+
+func (h *HTTP) sendMessages() {
+	defer close(h.doneChan)
+
+	for {
+		msg := h.queue.Pop(h.drainChan)
+		if msg == nil { // drained
+			return
+		}
+
+		select {
+		case <-h.stopChan: // drain timeout expired
+			msg.ReportDeliveryResult(errHTTPTransportStopped)
+			return
+		default:
+		}
+
+		msg.ReportDeliveryResult(h.sendMessageWithRetries(msg))
+	}
+}
+
+// sendMessageWithRetries resends a failed message according to the retry policy, unless the hub rejected it. the
+// message is abandoned if a newer message with the same id and type is waiting in the queue.
+func (h *HTTP) sendMessageWithRetries(msg *transport.Message) error {
+	for attempt := 0; ; attempt++ {
+		err := h.sendMessage(msg)
+		if err == nil || errors.Is(err, errRejected) || attempt >= h.retryPolicy.MaxRetries {
+			return err
+		}
+
+		if err := h.waitBeforeResend(msg, h.retryPolicy.Backoff(attempt)); err != nil {
+			return err
+		}
+	}
+}
+
+// waitBeforeResend returns an error if the http transport was stopped or if the message was superseded while waiting.
+func (h *HTTP) waitBeforeResend(msg *transport.Message, waitDuration time.Duration) error {
+	timer := time.NewTimer(waitDuration)
+	defer timer.Stop()
+
+	select {
+	case <-h.stopChan:
+		return errHTTPTransportStopped
+	case <-timer.C:
+	}
+
+	if h.queue.Contains(msg.ID, msg.MsgType) {
+		h.log.Info(fmt.Sprintf("Message '%s' from type '%s' with version '%s' was superseded, not resending",
+			msg.ID, msg.MsgType, msg.Version))
+		return transport.ErrMessageSuperseded
+	}
+
+	return nil
+}
+
+func (h *HTTP) sendMessage(msg *transport.Message) error {
+	request, err := http.NewRequestWithContext(context.Background(), http.MethodPost, h.bundleURL(msg.ID, msg.MsgType),
+		bytes.NewReader(msg.Payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request - %w", err)
+	}
+
+	request.Header.Set("Content-Type", "application/octet-stream")
+	request.Header.Set(IDHeader, msg.ID)
+	request.Header.Set(MsgTypeHeader, msg.MsgType)
+	request.Header.Set(VersionHeader, msg.Version)
+
+	for key, value := range msg.Metadata {
+		request.Header.Set(MetadataHeaderPrefix+key, value)
+	}
+
+	response, err := h.client.Do(request)
+	if err != nil {
+		h.log.Error(err, "Failed to send the message to the hub")
+		return fmt.Errorf("failed to send the message to the hub - %w", err)
+	}
+
+	defer closeResponse(response)
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		if isPermanentFailure(response.StatusCode) {
+			return fmt.Errorf("failed to send the message to the hub - %w: %s", errRejected, response.Status)
+		}
+
+		return fmt.Errorf("failed to send the message to the hub - %w: %s", errUnexpectedStatus, response.Status)
+	}
+
+	h.log.Info(fmt.Sprintf("Message '%s' from type '%s' with version '%s' sent", msg.ID, msg.MsgType, msg.Version))
+
+	return nil
+}
+
+func (h *HTTP) readVersion(id string, msgType string) (string, error) {
+	request, err := http.NewRequestWithContext(context.Background(), http.MethodGet, h.bundleURL(id, msgType), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request - %w", err)
+	}
+
+	response, err := h.client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request - %w", err)
+	}
+
+	defer closeResponse(response)
+
+	switch {
+	case response.StatusCode == http.StatusNotFound:
+		return "", nil
+	case response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices:
+		return "", fmt.Errorf("%w: %s", errUnexpectedStatus, response.Status)
+	default:
+		return response.Header.Get(VersionHeader), nil
+	}
+}
+
+// isPermanentFailure returns true for client errors that resending the same message cannot fix.
+func isPermanentFailure(statusCode int) bool {
+	return statusCode >= http.StatusBadRequest && statusCode < http.StatusInternalServerError &&
+		statusCode != http.StatusRequestTimeout && statusCode != http.StatusTooManyRequests
+}
+
+// bundleURL returns the url of the bundle with the given id and type, <url>/<msgType>/<id>.
+func (h *HTTP) bundleURL(id string, msgType string) string {
+	return fmt.Sprintf("%s/%s/%s", h.endpoint, url.PathEscape(msgType), url.PathEscape(id))
+}
+
+// closeResponse drains and closes the response body, so the connection can be reused.
+func closeResponse(response *http.Response) {
+	_, _ = io.Copy(ioutil.Discard, response.Body)
+	response.Body.Close()
+}
@@ -0,0 +1,223 @@
+package httptransport
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+)
+
+const (
+	testMsgType      = "StatusBundle"
+	testID           = "hub1.policies"
+	testMaxRetries   = 3
+	testDrainTimeout = time.Second
+	testTimeout      = 5 * time.Second
+)
+
+// receivedRequest is a POST request the fake hub received.
+type receivedRequest struct {
+	header  http.Header
+	payload string
+}
+
+// fakeHub keeps the version of the last bundle it received from each type and id, and fails the first requests with
+// the configured status.
+type fakeHub struct {
+	lock         sync.Mutex
+	failStatus   int
+	failRequests int
+	requests     []receivedRequest
+	versions     map[string]string
+}
+
+func (f *fakeHub) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	path := strings.TrimPrefix(request.URL.Path, "/")
+
+	switch request.Method {
+	case http.MethodGet:
+		version, found := f.versions[path]
+		if !found {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		writer.Header().Set(VersionHeader, version)
+	case http.MethodPost:
+		payload, _ := ioutil.ReadAll(request.Body)
+		f.requests = append(f.requests, receivedRequest{header: request.Header.Clone(), payload: string(payload)})
+
+		if f.failRequests > 0 {
+			f.failRequests--
+			writer.WriteHeader(f.failStatus)
+
+			return
+		}
+
+		f.versions[path] = request.Header.Get(VersionHeader)
+	default:
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeHub) receivedRequests() []receivedRequest {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return append([]receivedRequest(nil), f.requests...)
+}
+
+func newTestHTTP(t *testing.T, hub *fakeHub) *HTTP {
+	t.Helper()
+
+	server := httptest.NewServer(hub)
+	t.Cleanup(server.Close)
+
+	retryPolicy := &transport.RetryPolicy{
+		MaxRetries:     testMaxRetries,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+	}
+
+	httpTransport, err := NewHTTPWithClient(server.URL+"/", server.Client(), retryPolicy,
+		transport.DefaultMessageQueueCapacity, testDrainTimeout, logrtesting.NullLogger{})
+	if err != nil {
+		t.Fatalf("failed to create the http transport: %v", err)
+	}
+
+	httpTransport.Start()
+	t.Cleanup(httpTransport.Stop)
+
+	return httpTransport
+}
+
+func send(t *testing.T, httpTransport *HTTP, version string, metadata map[string]string) error {
+	t.Helper()
+
+	resultChan := make(chan error, 1)
+
+	httpTransport.SendAsync(&transport.Message{
+		ID:               testID,
+		MsgType:          testMsgType,
+		Version:          version,
+		Metadata:         metadata,
+		Payload:          []byte("payload-" + version),
+		DeliveryCallback: func(err error) { resultChan <- err },
+	})
+
+	select {
+	case err := <-resultChan:
+		return err
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for the delivery result")
+		return nil
+	}
+}
+
+func TestSendAsyncPostsTheBundle(t *testing.T) {
+	hub := &fakeHub{versions: make(map[string]string)}
+	httpTransport := newTestHTTP(t, hub)
+
+	if err := send(t, httpTransport, "1", map[string]string{"codec": "json", "compression": "gzip"}); err != nil {
+		t.Fatalf("failed to send the bundle: %v", err)
+	}
+
+	requests := hub.receivedRequests()
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(requests))
+	}
+
+	request := requests[0]
+
+	for header, expected := range map[string]string{
+		IDHeader:                             testID,
+		MsgTypeHeader:                        testMsgType,
+		VersionHeader:                        "1",
+		MetadataHeaderPrefix + "codec":       "json",
+		MetadataHeaderPrefix + "compression": "gzip",
+	} {
+		if actual := request.header.Get(header); actual != expected {
+			t.Fatalf("expected header %s to be %q, got %q", header, expected, actual)
+		}
+	}
+
+	if request.payload != "payload-1" {
+		t.Fatalf("expected the payload to be posted, got %q", request.payload)
+	}
+}
+
+func TestSendAsyncRetries(t *testing.T) {
+	for name, test := range map[string]struct {
+		failStatus       int
+		failRequests     int
+		expectedRequests int
+		expectedErr      error
+	}{
+		"delivered after retries": {
+			failStatus:       http.StatusServiceUnavailable,
+			failRequests:     2,
+			expectedRequests: 3,
+		},
+		"retries exhausted": {
+			failStatus:       http.StatusInternalServerError,
+			failRequests:     testMaxRetries + 1,
+			expectedRequests: testMaxRetries + 1,
+			expectedErr:      errUnexpectedStatus,
+		},
+		"too many requests": {
+			failStatus:       http.StatusTooManyRequests,
+			failRequests:     1,
+			expectedRequests: 2,
+		},
+		"rejected": {
+			failStatus:       http.StatusBadRequest,
+			failRequests:     1,
+			expectedRequests: 1,
+			expectedErr:      errRejected,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			hub := &fakeHub{
+				failStatus:   test.failStatus,
+				failRequests: test.failRequests,
+				versions:     make(map[string]string),
+			}
+			httpTransport := newTestHTTP(t, hub)
+
+			if err := send(t, httpTransport, "1", nil); !errors.Is(err, test.expectedErr) {
+				t.Fatalf("expected %v, got %v", test.expectedErr, err)
+			}
+
+			if requests := len(hub.receivedRequests()); requests != test.expectedRequests {
+				t.Fatalf("expected %d requests, got %d", test.expectedRequests, requests)
+			}
+		})
+	}
+}
+
+func TestGetVersionReturnsTheVersionTheHubReceived(t *testing.T) {
+	hub := &fakeHub{versions: make(map[string]string)}
+	httpTransport := newTestHTTP(t, hub)
+
+	if version := httpTransport.GetVersion(testID, testMsgType); version != "" {
+		t.Fatalf("expected no version before the bundle was sent, got %q", version)
+	}
+
+	if err := send(t, httpTransport, "7", nil); err != nil {
+		t.Fatalf("failed to send the bundle: %v", err)
+	}
+
+	if version := httpTransport.GetVersion(testID, testMsgType); version != "7" {
+		t.Fatalf("expected version 7, got %q", version)
+	}
+}
@@ -0,0 +1,498 @@
+package fakekafka
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+)
+
+const (
+	apiKeyProduce     int16 = 0
+	apiKeyMetadata    int16 = 3
+	apiKeyAPIVersions int16 = 18
+
+	nodeID    int32 = 0
+	partition int32 = 0
+
+	recordBatchMagic = 2
+	// base offset, batch length, partition leader epoch, magic, crc, attributes, last offset delta, first and max
+	// timestamps, producer id, producer epoch, base sequence and records count.
+	recordBatchHeaderSize = 8 + 4 + 4 + 1 + 4 + 2 + 4 + 8 + 8 + 8 + 2 + 4 + 4
+	maxRequestSize        = 10 * 1024 * 1024
+)
+
+var errProtocol = errors.New("protocol error")
+
+// apiVersions are the api versions the broker supports, produce up to v3 is the first version with record batches,
+// which carry the headers of the messages.
+var apiVersions = []struct{ key, minVersion, maxVersion int16 }{
+	{apiKeyProduce, 3, 3},
+	{apiKeyMetadata, 1, 1},
+	{apiKeyAPIVersions, 0, 0},
+}
+
+// Record is a record that was produced to the topic of the fake Kafka broker.
+type Record struct {
+	Key     []byte
+	Value   []byte
+	Headers map[string]string
+	Offset  int64
+}
+
+// Broker is a fake Kafka broker that speaks the subset of the Kafka protocol used by the kafka-go writer: api
+// versions, metadata and produce of uncompressed record batches. the broker leads the single partition of a single
+// topic and keeps the produced records in memory. tests can inspect the records and make produce requests fail.
+type Broker struct {
+	listener         net.Listener
+	topic            string
+	records          []Record
+	failedProduces   int
+	produceErrorCode int16
+	conns            map[net.Conn]struct{}
+	waitGroup        sync.WaitGroup
+	lock             sync.Mutex
+}
+
+// NewBroker creates and starts a new fake Kafka broker of the given topic, listening on a local port.
+func NewBroker(topic string) (*Broker, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen - %w", err)
+	}
+
+	broker := &Broker{
+		listener: listener,
+		topic:    topic,
+		conns:    make(map[net.Conn]struct{}),
+	}
+
+	broker.waitGroup.Add(1)
+
+	go broker.accept()
+
+	return broker, nil
+}
+
+// Address returns the host:port address of the broker.
+func (broker *Broker) Address() string {
+	return broker.listener.Addr().String()
+}
+
+// Close shuts the broker down and closes the connections of the clients.
+func (broker *Broker) Close() {
+	_ = broker.listener.Close()
+
+	broker.lock.Lock()
+	for conn := range broker.conns {
+		_ = conn.Close()
+	}
+	broker.lock.Unlock()
+
+	broker.waitGroup.Wait()
+}
+
+// FailProduceRequests makes the next count produce requests fail with the given Kafka error code.
+func (broker *Broker) FailProduceRequests(count int, errorCode int16) {
+	broker.lock.Lock()
+	defer broker.lock.Unlock()
+
+	broker.failedProduces = count
+	broker.produceErrorCode = errorCode
+}
+
+// Records returns copies of the records that were produced to the topic, in order.
+func (broker *Broker) Records() []Record {
+	broker.lock.Lock()
+	defer broker.lock.Unlock()
+
+	records := make([]Record, 0, len(broker.records))
+
+	for _, record := range broker.records {
+		headers := make(map[string]string, len(record.Headers))
+		for key, value := range record.Headers {
+			headers[key] = value
+		}
+
+		records = append(records, Record{
+			Key:     append([]byte{}, record.Key...),
+			Value:   append([]byte{}, record.Value...),
+			Headers: headers,
+			Offset:  record.Offset,
+		})
+	}
+
+	return records
+}
+
+func (broker *Broker) accept() {
+	defer broker.waitGroup.Done()
+
+	for {
+		conn, err := broker.listener.Accept()
+		if err != nil { // closed
+			return
+		}
+
+		broker.lock.Lock()
+		broker.conns[conn] = struct{}{}
+		broker.lock.Unlock()
+
+		broker.waitGroup.Add(1)
+
+		go broker.serve(conn)
+	}
+}
+
+func (broker *Broker) serve(conn net.Conn) {
+	defer broker.waitGroup.Done()
+	defer func() {
+		broker.lock.Lock()
+		delete(broker.conns, conn)
+		broker.lock.Unlock()
+
+		_ = conn.Close()
+	}()
+
+	reader := bufio.NewReader(conn)
+
+	for {
+		response, err := broker.processRequest(reader)
+		if err != nil {
+			return
+		}
+
+		if _, err := conn.Write(response); err != nil {
+			return
+		}
+	}
+}
+
+// processRequest reads a single request of the client and returns the response to it, including the size prefix.
+func (broker *Broker) processRequest(reader io.Reader) ([]byte, error) {
+	var size int32
+	if err := binary.Read(reader, binary.BigEndian, &size); err != nil {
+		return nil, fmt.Errorf("failed to read the request size - %w", err)
+	}
+
+	if size < 0 || size > maxRequestSize {
+		return nil, fmt.Errorf("%w: request size %d", errProtocol, size)
+	}
+
+	request := &decoder{data: make([]byte, size)}
+	if _, err := io.ReadFull(reader, request.data); err != nil {
+		return nil, fmt.Errorf("failed to read the request - %w", err)
+	}
+
+	apiKey := request.int16()
+	apiVersion := request.int16()
+	correlationID := request.int32()
+	request.string() // client id
+
+	response := &encoder{}
+	response.int32(0) // size, set below
+	response.int32(correlationID)
+
+	switch {
+	case apiKey == apiKeyAPIVersions:
+		writeAPIVersions(response)
+	case apiKey == apiKeyMetadata && apiVersion == 1:
+		broker.writeMetadata(response)
+	case apiKey == apiKeyProduce && apiVersion == 3:
+		if err := broker.produce(request, response); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported api key %d version %d", errProtocol, apiKey, apiVersion)
+	}
+
+	if request.err != nil {
+		return nil, request.err
+	}
+
+	binary.BigEndian.PutUint32(response.Bytes(), uint32(response.Len()-4))
+
+	return response.Bytes(), nil
+}
+
+func writeAPIVersions(response *encoder) {
+	response.int16(0) // error code
+	response.int32(int32(len(apiVersions)))
+
+	for _, api := range apiVersions {
+		response.int16(api.key)
+		response.int16(api.minVersion)
+		response.int16(api.maxVersion)
+	}
+}
+
+func (broker *Broker) writeMetadata(response *encoder) {
+	host, portStr, _ := net.SplitHostPort(broker.Address())
+	port, _ := strconv.Atoi(portStr)
+
+	response.int32(1) // brokers
+	response.int32(nodeID)
+	response.string(host)
+	response.int32(int32(port))
+	response.string("") // rack
+
+	response.int32(nodeID) // controller id
+
+	response.int32(1) // topics
+	response.int16(0) // error code
+	response.string(broker.topic)
+	response.int8(0) // is internal
+
+	response.int32(1) // partitions
+	response.int16(0) // error code
+	response.int32(partition)
+	response.int32(nodeID) // leader
+	response.int32(1)      // replicas
+	response.int32(nodeID)
+	response.int32(1) // in-sync replicas
+	response.int32(nodeID)
+}
+
+// produce decodes a produce v3 request of a single topic and partition, stores its records and writes the response.
+func (broker *Broker) produce(request *decoder, response *encoder) error {
+	request.nullableString() // transactional id
+	request.int16()          // required acks
+	request.int32()          // timeout
+
+	if topics := request.int32(); topics != 1 {
+		return fmt.Errorf("%w: expected a single topic, got %d", errProtocol, topics)
+	}
+
+	topic := request.string()
+
+	if partitions := request.int32(); partitions != 1 {
+		return fmt.Errorf("%w: expected a single partition, got %d", errProtocol, partitions)
+	}
+
+	requestPartition := request.int32()
+	recordBatch := &decoder{data: request.bytes()}
+
+	records, err := decodeRecordBatch(recordBatch)
+	if err != nil {
+		return err
+	}
+
+	errorCode, baseOffset := broker.storeRecords(topic, records)
+
+	response.int32(1) // topics
+	response.string(topic)
+	response.int32(1) // partitions
+	response.int32(requestPartition)
+	response.int16(errorCode)
+	response.int64(baseOffset)
+	response.int64(-1) // log append time
+	response.int32(0)  // throttle time
+
+	return nil
+}
+
+func (broker *Broker) storeRecords(topic string, records []Record) (int16, int64) {
+	broker.lock.Lock()
+	defer broker.lock.Unlock()
+
+	if broker.failedProduces > 0 {
+		broker.failedProduces--
+		return broker.produceErrorCode, -1
+	}
+
+	if topic != broker.topic {
+		return 3, -1 // unknown topic or partition
+	}
+
+	baseOffset := int64(len(broker.records))
+
+	for i := range records {
+		records[i].Offset = baseOffset + int64(i)
+		broker.records = append(broker.records, records[i])
+	}
+
+	return 0, baseOffset
+}
+
+// decodeRecordBatch decodes an uncompressed record batch of the v2 message format.
+func decodeRecordBatch(batch *decoder) ([]Record, error) {
+	if len(batch.data) < recordBatchHeaderSize {
+		return nil, fmt.Errorf("%w: record batch of %d bytes", errProtocol, len(batch.data))
+	}
+
+	batch.int64() // base offset
+	batch.int32() // batch length
+	batch.int32() // partition leader epoch
+
+	if magic := batch.int8(); magic != recordBatchMagic {
+		return nil, fmt.Errorf("%w: record batch magic %d", errProtocol, magic)
+	}
+
+	batch.int32() // crc
+
+	if attributes := batch.int16(); attributes&0x7 != 0 {
+		return nil, fmt.Errorf("%w: compressed record batches are not supported", errProtocol)
+	}
+
+	batch.skip(4 + 8 + 8 + 8 + 2 + 4) // last offset delta, timestamps, producer id and epoch, base sequence
+
+	count := batch.int32()
+	records := make([]Record, 0, count)
+
+	for i := int32(0); i < count && batch.err == nil; i++ {
+		record := &decoder{data: batch.next(int(batch.varint()))}
+
+		record.int8()   // attributes
+		record.varint() // timestamp delta
+		record.varint() // offset delta
+
+		key := record.varBytes()
+		value := record.varBytes()
+		headers := make(map[string]string)
+
+		for j := record.varint(); j > 0 && record.err == nil; j-- {
+			headerKey := string(record.varBytes())
+			headers[headerKey] = string(record.varBytes())
+		}
+
+		if record.err != nil {
+			return nil, record.err
+		}
+
+		records = append(records, Record{Key: key, Value: value, Headers: headers})
+	}
+
+	if batch.err != nil {
+		return nil, batch.err
+	}
+
+	return records, nil
+}
+
+// decoder reads the big endian and zigzag varint encoded fields of the Kafka protocol, remembering the first error.
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+
+	if n < 0 || n > len(d.data) {
+		d.err = fmt.Errorf("%w: expected %d bytes, %d left", errProtocol, n, len(d.data))
+		return nil
+	}
+
+	field := d.data[:n]
+	d.data = d.data[n:]
+
+	return field
+}
+
+func (d *decoder) skip(n int) {
+	d.next(n)
+}
+
+func (d *decoder) int8() int8 {
+	if field := d.next(1); field != nil {
+		return int8(field[0])
+	}
+
+	return 0
+}
+
+func (d *decoder) int16() int16 {
+	if field := d.next(2); field != nil {
+		return int16(binary.BigEndian.Uint16(field))
+	}
+
+	return 0
+}
+
+func (d *decoder) int32() int32 {
+	if field := d.next(4); field != nil {
+		return int32(binary.BigEndian.Uint32(field))
+	}
+
+	return 0
+}
+
+func (d *decoder) int64() int64 {
+	if field := d.next(8); field != nil {
+		return int64(binary.BigEndian.Uint64(field))
+	}
+
+	return 0
+}
+
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+
+	value, n := binary.Varint(d.data)
+	if n <= 0 {
+		d.err = fmt.Errorf("%w: malformed varint", errProtocol)
+		return 0
+	}
+
+	d.data = d.data[n:]
+
+	return value
+}
+
+func (d *decoder) string() string {
+	return string(d.next(int(d.int16())))
+}
+
+func (d *decoder) nullableString() {
+	if length := d.int16(); length > 0 {
+		d.skip(int(length))
+	}
+}
+
+func (d *decoder) bytes() []byte {
+	return d.next(int(d.int32()))
+}
+
+func (d *decoder) varBytes() []byte {
+	length := d.varint()
+	if length < 0 { // null
+		return nil
+	}
+
+	return append([]byte{}, d.next(int(length))...)
+}
+
+// encoder writes the big endian fields of the Kafka protocol.
+type encoder struct {
+	bytes.Buffer
+}
+
+func (e *encoder) int8(value int8) {
+	e.WriteByte(byte(value))
+}
+
+func (e *encoder) int16(value int16) {
+	_ = binary.Write(e, binary.BigEndian, value)
+}
+
+func (e *encoder) int32(value int32) {
+	_ = binary.Write(e, binary.BigEndian, value)
+}
+
+func (e *encoder) int64(value int64) {
+	_ = binary.Write(e, binary.BigEndian, value)
+}
+
+func (e *encoder) string(value string) {
+	e.int16(int16(len(value)))
+	e.WriteString(value)
+}
@@ -0,0 +1,529 @@
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+const (
+	envVarKafkaBootstrapServers = "KAFKA_BOOTSTRAP_SERVERS"
+	envVarKafkaTopic            = "KAFKA_TOPIC"
+	envVarKafkaSASLMechanism    = "KAFKA_SASL_MECHANISM"
+	envVarKafkaSASLUser         = "KAFKA_SASL_USER"
+	envVarKafkaSASLPassword     = "KAFKA_SASL_PASSWORD"
+	envVarKafkaTLSEnabled       = "KAFKA_TLS_ENABLED"
+	envVarKafkaCACertPath       = "KAFKA_CA_CERT_PATH"
+	envVarKafkaClientCertPath   = "KAFKA_CLIENT_CERT_PATH"
+	envVarKafkaClientKeyPath    = "KAFKA_CLIENT_KEY_PATH"
+	envVarKafkaDrainTimeout     = "KAFKA_DRAIN_TIMEOUT"
+
+	saslMechanismPlain       = "PLAIN"
+	saslMechanismScramSHA256 = "SCRAM-SHA-256"
+	saslMechanismScramSHA512 = "SCRAM-SHA-512"
+
+	msgTypeHeader = "type"
+	versionHeader = "version"
+
+	dialTimeout       = 10 * time.Second
+	writeTimeout      = 10 * time.Second
+	readTimeout       = 10 * time.Second
+	readBatchMaxBytes = 10e6 // 10MB
+
+	// the last version is read backwards in windows of readWindowSize offsets, up to readMaxScannedOffsets offsets.
+	readWindowSize        = 100
+	readMaxScannedOffsets = 10000
+
+	defaultDrainTimeout = 10 * time.Second
+)
+
+var (
+	errEnvVarNotFound       = errors.New("not found environment variable")
+	errEnvVarWrongType      = errors.New("wrong type of environment variable")
+	errEnvVarIllegalValue   = errors.New("illegal value of environment variable")
+	errFailedToLoadCACert   = errors.New("failed to append CA certificate to the pool")
+	errNoPartitionsForTopic = errors.New("no partitions found for topic")
+	errEmptyFetch           = errors.New("no messages to read from the partition")
+	errKafkaStopped         = errors.New("kafka was stopped")
+	errScanLimitReached     = errors.New("no version found in the scanned offsets of the partition")
+)
+
+// TransportType is the transport type the Kafka transport is registered under.
+const TransportType = "kafka"
+
+func init() {
+	transport.Register(TransportType, func(log logr.Logger) (transport.Service, error) {
+		return NewKafka(log)
+	})
+}
+
+// Kafka abstracts a Kafka producer that sends bundles to a topic, using the bundle id as the message key.
+type Kafka struct {
+	brokers       []string
+	topic         string
+	dialer        *kafkago.Dialer
+	dialPartition dialPartitionFunc
+	writer        *kafkago.Writer
+	queue         *transport.MessageQueue
+	drainTimeout  time.Duration
+	drainChan     chan struct{}
+	doneChan      chan struct{}
+	stopChan      chan struct{}
+	startOnce     sync.Once
+	stopOnce      sync.Once
+	log           logr.Logger
+}
+
+// NewKafka creates a new instance of Kafka.
+func NewKafka(log logr.Logger) (*Kafka, error) {
+	brokers, topic, drainTimeout, err := readEnvVars()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka - %w", err)
+	}
+
+	dialer, err := createDialer()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka - %w", err)
+	}
+
+	queueCapacity, err := transport.ReadMessageQueueCapacity()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kafka - %w", err)
+	}
+
+	writer := kafkago.NewWriter(kafkago.WriterConfig{
+		Brokers:      brokers,
+		Topic:        topic,
+		Dialer:       dialer,
+		Balancer:     &kafkago.Hash{}, // all messages of the same bundle go to the same partition
+		BatchSize:    1,
+		WriteTimeout: writeTimeout,
+		RequiredAcks: -1, // wait for all in-sync replicas
+	})
+
+	return &Kafka{
+		brokers:       brokers,
+		topic:         topic,
+		dialer:        dialer,
+		dialPartition: newDialPartitionFunc(dialer, topic),
+		writer:        writer,
+		log:           log,
+		queue:         transport.NewMessageQueue("kafka", queueCapacity),
+		drainTimeout:  drainTimeout,
+		drainChan:     make(chan struct{}),
+		doneChan:      make(chan struct{}),
+		stopChan:      make(chan struct{}, 1),
+	}, nil
+}
+
+func readEnvVars() ([]string, string, time.Duration, error) {
+	bootstrapServers := os.Getenv(envVarKafkaBootstrapServers)
+	if bootstrapServers == "" {
+		return nil, "", 0, fmt.Errorf("%w: %s", errEnvVarNotFound, envVarKafkaBootstrapServers)
+	}
+
+	topic := os.Getenv(envVarKafkaTopic)
+	if topic == "" {
+		return nil, "", 0, fmt.Errorf("%w: %s", errEnvVarNotFound, envVarKafkaTopic)
+	}
+
+	drainTimeout := defaultDrainTimeout
+
+	if drainTimeoutStr := os.Getenv(envVarKafkaDrainTimeout); drainTimeoutStr != "" {
+		var err error
+		if drainTimeout, err = time.ParseDuration(drainTimeoutStr); err != nil {
+			return nil, "", 0, fmt.Errorf("%w: %s must be a duration", errEnvVarWrongType, envVarKafkaDrainTimeout)
+		}
+	}
+
+	return strings.Split(bootstrapServers, ","), topic, drainTimeout, nil
+}
+
+func createDialer() (*kafkago.Dialer, error) {
+	saslMechanism, err := readSASLEnvVars()
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := readTLSEnvVars()
+	if err != nil {
+		return nil, err
+	}
+
+	return &kafkago.Dialer{
+		Timeout:       dialTimeout,
+		DualStack:     true,
+		SASLMechanism: saslMechanism,
+		TLS:           tlsConfig,
+	}, nil
+}
+
+// readSASLEnvVars returns nil if SASL is not configured.
+func readSASLEnvVars() (sasl.Mechanism, error) {
+	mechanism := os.Getenv(envVarKafkaSASLMechanism)
+	if mechanism == "" {
+		return nil, nil
+	}
+
+	user := os.Getenv(envVarKafkaSASLUser)
+	if user == "" {
+		return nil, fmt.Errorf("%w: %s", errEnvVarNotFound, envVarKafkaSASLUser)
+	}
+
+	password := os.Getenv(envVarKafkaSASLPassword)
+	if password == "" {
+		return nil, fmt.Errorf("%w: %s", errEnvVarNotFound, envVarKafkaSASLPassword)
+	}
+
+	switch mechanism {
+	case saslMechanismPlain:
+		return plain.Mechanism{Username: user, Password: password}, nil
+	case saslMechanismScramSHA256:
+		return createScramMechanism(scram.SHA256, user, password)
+	case saslMechanismScramSHA512:
+		return createScramMechanism(scram.SHA512, user, password)
+	default:
+		return nil, fmt.Errorf("%w: %s must be one of %s, %s, %s", errEnvVarIllegalValue, envVarKafkaSASLMechanism,
+			saslMechanismPlain, saslMechanismScramSHA256, saslMechanismScramSHA512)
+	}
+}
+
+func createScramMechanism(algorithm scram.Algorithm, user string, password string) (sasl.Mechanism, error) {
+	mechanism, err := scram.Mechanism(algorithm, user, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SASL mechanism - %w", err)
+	}
+
+	return mechanism, nil
+}
+
+// readTLSEnvVars returns nil if TLS is not enabled.
+func readTLSEnvVars() (*tls.Config, error) {
+	tlsEnabledStr := os.Getenv(envVarKafkaTLSEnabled)
+	if tlsEnabledStr == "" {
+		return nil, nil
+	}
+
+	tlsEnabled, err := strconv.ParseBool(tlsEnabledStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s must be a boolean", errEnvVarWrongType, envVarKafkaTLSEnabled)
+	}
+
+	if !tlsEnabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caCertPath := os.Getenv(envVarKafkaCACertPath); caCertPath != "" {
+		caCert, err := ioutil.ReadFile(caCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate - %w", err)
+		}
+
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("%w: %s", errFailedToLoadCACert, caCertPath)
+		}
+
+		tlsConfig.RootCAs = certPool
+	}
+
+	clientCertPath := os.Getenv(envVarKafkaClientCertPath)
+	clientKeyPath := os.Getenv(envVarKafkaClientKeyPath)
+
+	if (clientCertPath == "") != (clientKeyPath == "") {
+		return nil, fmt.Errorf("%w: %s and %s must be set together", errEnvVarIllegalValue, envVarKafkaClientCertPath,
+			envVarKafkaClientKeyPath)
+	}
+
+	if clientCertPath != "" {
+		clientCert, err := tls.LoadX509KeyPair(clientCertPath, clientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate - %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	return tlsConfig, nil
+}
+
+// Start function starts kafka.
+func (k *Kafka) Start() {
+	k.startOnce.Do(func() {
+		go k.sendMessages()
+	})
+}
+
+// Stop function stops kafka. messages that are waiting in the queue are sent until the drain timeout expires.
+func (k *Kafka) Stop() {
+	k.stopOnce.Do(func() {
+		k.queue.Close() // messages that are sent from now on are reported as failed
+		close(k.drainChan)
+
+		if transport.WasStarted(&k.startOnce) {
+			select {
+			case <-k.doneChan:
+				k.log.Info("all messages were sent")
+			case <-time.After(k.drainTimeout):
+				k.log.Info(fmt.Sprintf("drain timeout expired, %d messages were not sent", k.queue.Len()))
+			}
+		}
+
+		close(k.stopChan)
+
+		if err := k.writer.Close(); err != nil {
+			k.log.Error(err, "Failed to close the kafka writer")
+		}
+	})
+}
+
+// SendAsync function sends a message to kafka asynchronously.
+func (k *Kafka) SendAsync(message *transport.Message) {
+	k.queue.Push(message)
+}
+
+// GetVersion returns the version of the last message in the topic with the given id and type. if no such message
+// exists or an error occurred returns an empty string.
+func (k *Kafka) GetVersion(id string, msgType string) string {
+	version, err := k.readLastVersion(id, msgType)
+	if err != nil {
+		k.log.Error(err, "Failed to read the last version from kafka", "id", id, "type", msgType)
+		return ""
+	}
+
+	return version
+}
+
+// Subscribe function does nothing, receiving commands is not supported by the kafka.
+func (k *Kafka) Subscribe(string, transport.CommandHandler) {}
+
+func (k *Kafka) sendMessages() {
+	defer close(k.doneChan)
+
+	for {
+		msg := k.queue.Pop(k.drainChan)
+		if msg == nil { // drained
+			return
+		}
+
+		select {
+		case <-k.stopChan: // drain timeout expired
+			msg.ReportDeliveryResult(errKafkaStopped)
+			return
+		default:
+		}
+
+		msg.ReportDeliveryResult(k.sendMessage(msg))
+	}
+}
+
+func (k *Kafka) sendMessage(msg *transport.Message) error {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancelFunc()
+
+	headers := []kafkago.Header{
+		{Key: msgTypeHeader, Value: []byte(msg.MsgType)},
+		{Key: versionHeader, Value: []byte(msg.Version)},
+	}
+
+	for key, value := range msg.Metadata {
+		headers = append(headers, kafkago.Header{Key: key, Value: []byte(value)})
+	}
+
+	if err := k.writer.WriteMessages(ctx, kafkago.Message{
+		Key:     []byte(msg.ID),
+		Value:   msg.Payload,
+		Headers: headers,
+	}); err != nil {
+		k.log.Error(err, "Failed to write the message to kafka")
+		return fmt.Errorf("failed to write the message to kafka - %w", err)
+	}
+
+	k.log.Info(fmt.Sprintf("Message '%s' from type '%s' with version '%s' sent", msg.ID, msg.MsgType, msg.Version))
+
+	return nil
+}
+
+// readLastVersion reads the version of the last message with the given id and type from the partition of the id.
+func (k *Kafka) readLastVersion(id string, msgType string) (string, error) {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), readTimeout)
+	defer cancelFunc()
+
+	partition, err := k.getPartition(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	return k.readLastVersionFromPartition(ctx, partition, id, msgType)
+}
+
+func (k *Kafka) readLastVersionFromPartition(ctx context.Context, partition int, id string,
+	msgType string) (string, error) {
+	var reader partitionReader
+
+	err := k.forEachBroker(func(broker string) error {
+		var dialErr error
+		reader, dialErr = k.dialPartition(ctx, broker, partition)
+
+		return dialErr
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to dial partition leader - %w", err)
+	}
+	defer reader.Close()
+
+	return scanLastVersion(reader, id, msgType)
+}
+
+// scanLastVersion reads the partition backwards in windows of readWindowSize offsets, starting from the window that
+// ends at the last offset, and returns the version of the last message with the given id and type. the scan stops
+// when a read returns no messages, returning an empty string, or after readMaxScannedOffsets offsets, returning
+// errScanLimitReached. the bundle is then resent, since its version is unknown.
+func scanLastVersion(reader partitionReader, id string, msgType string) (string, error) {
+	firstOffset, lastOffset, err := reader.ReadOffsets()
+	if err != nil {
+		return "", fmt.Errorf("failed to read partition offsets - %w", err)
+	}
+
+	for windowEnd := lastOffset; windowEnd > firstOffset && lastOffset-windowEnd < readMaxScannedOffsets; {
+		windowStart := windowEnd - readWindowSize
+		if windowStart < firstOffset {
+			windowStart = firstOffset
+		}
+
+		version, found, err := scanWindow(reader, windowStart, windowEnd, id, msgType)
+		if errors.Is(err, errEmptyFetch) { // nothing left to read
+			return "", nil
+		}
+
+		if err != nil || found {
+			return version, err
+		}
+
+		windowEnd = windowStart
+	}
+
+	if lastOffset-firstOffset > readMaxScannedOffsets {
+		return "", fmt.Errorf("%w: %d offsets from %d", errScanLimitReached, readMaxScannedOffsets, lastOffset)
+	}
+
+	return "", nil
+}
+
+// scanWindow returns the version of the last message with the given id and type in [windowStart, windowEnd), and
+// whether such a message was found. returns errEmptyFetch if a read returned no messages before one was found.
+func scanWindow(reader partitionReader, windowStart int64, windowEnd int64, id string,
+	msgType string) (string, bool, error) {
+	version := ""
+	found := false
+
+	for offset := windowStart; offset < windowEnd; {
+		messages, err := reader.ReadMessages(offset)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to read partition - %w", err)
+		}
+
+		if len(messages) == 0 { // e.g. the messages were removed by retention, don't spin
+			if found {
+				return version, found, nil
+			}
+
+			return "", false, errEmptyFetch
+		}
+
+		for _, msg := range messages {
+			if msg.Offset >= windowEnd {
+				return version, found, nil
+			}
+
+			offset = msg.Offset + 1
+
+			if string(msg.Key) == id && getHeader(msg, msgTypeHeader) == msgType {
+				version = getHeader(msg, versionHeader)
+				found = true
+			}
+		}
+	}
+
+	return version, found, nil
+}
+
+// getPartition returns the partition that the writer chooses for the given id.
+func (k *Kafka) getPartition(ctx context.Context, id string) (int, error) {
+	var partitions []kafkago.Partition
+
+	if err := k.forEachBroker(func(broker string) error {
+		conn, err := k.dialer.DialContext(ctx, "tcp", broker)
+		if err != nil {
+			return fmt.Errorf("failed to dial broker - %w", err)
+		}
+		defer conn.Close()
+
+		if partitions, err = conn.ReadPartitions(k.topic); err != nil {
+			return fmt.Errorf("failed to read partitions - %w", err)
+		}
+
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	if len(partitions) == 0 {
+		return 0, fmt.Errorf("%w: %s", errNoPartitionsForTopic, k.topic)
+	}
+
+	partitionIDs := make([]int, len(partitions))
+	for i, partition := range partitions {
+		partitionIDs[i] = partition.ID
+	}
+
+	sort.Ints(partitionIDs) // same order as the writer uses
+
+	balancer := &kafkago.Hash{}
+
+	return balancer.Balance(kafkago.Message{Key: []byte(id)}, partitionIDs...), nil
+}
+
+// forEachBroker invokes the function with each broker in turn until it succeeds. returns the error of the last broker
+// if it fails for all of them.
+func (k *Kafka) forEachBroker(function func(broker string) error) error {
+	var err error
+
+	for _, broker := range k.brokers {
+		if err = function(broker); err == nil {
+			return nil
+		}
+
+		k.log.Error(err, "Failed to use kafka broker, trying the next broker", "broker", broker)
+	}
+
+	return err
+}
+
+func getHeader(msg kafkago.Message, key string) string {
+	for _, header := range msg.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+
+	return ""
+}
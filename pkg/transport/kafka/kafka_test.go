@@ -0,0 +1,346 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/kafka/fakekafka"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+const (
+	testMsgType     = "ClustersPerPolicy"
+	testBundleID    = "hub1.policies"
+	testBatchSize   = 10
+	testHealthyHost = "broker-2:9092"
+	testTopic       = "status"
+	testTimeout     = 5 * time.Second
+
+	errorCodeNotLeaderForPartition = 6
+)
+
+var errBrokerDown = errors.New("broker is down")
+
+func setEnvVar(t *testing.T, name string, value string) {
+	t.Helper()
+
+	if err := os.Setenv(name, value); err != nil {
+		t.Fatalf("failed to set %s: %v", name, err)
+	}
+
+	t.Cleanup(func() { _ = os.Unsetenv(name) })
+}
+
+func newTestBroker(t *testing.T) *fakekafka.Broker {
+	t.Helper()
+
+	broker, err := fakekafka.NewBroker(testTopic)
+	if err != nil {
+		t.Fatalf("failed to start the fake kafka broker: %v", err)
+	}
+
+	t.Cleanup(broker.Close)
+
+	setEnvVar(t, envVarKafkaBootstrapServers, broker.Address())
+	setEnvVar(t, envVarKafkaTopic, testTopic)
+
+	return broker
+}
+
+func newStartedKafka(t *testing.T) *Kafka {
+	t.Helper()
+
+	kafka, err := NewKafka(logrtesting.NullLogger{})
+	if err != nil {
+		t.Fatalf("failed to create kafka: %v", err)
+	}
+
+	kafka.Start()
+	t.Cleanup(kafka.Stop)
+
+	return kafka
+}
+
+func send(t *testing.T, kafka *Kafka, version string, metadata map[string]string) error {
+	t.Helper()
+
+	resultChan := make(chan error, 1)
+
+	kafka.SendAsync(&transport.Message{
+		ID:               testBundleID,
+		MsgType:          testMsgType,
+		Version:          version,
+		Metadata:         metadata,
+		Payload:          []byte("payload-" + version),
+		DeliveryCallback: func(err error) { resultChan <- err },
+	})
+
+	select {
+	case err := <-resultChan:
+		return err
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for the delivery result")
+		return nil
+	}
+}
+
+// mockPartition is a partition leader that returns the messages of a single partition in batches of testBatchSize.
+type mockPartition struct {
+	messages    []kafkago.Message
+	firstOffset int64
+	lastOffset  int64
+	readOffsets []int64
+}
+
+func newMockPartition(firstOffset int64, lastOffset int64) *mockPartition {
+	partition := &mockPartition{firstOffset: firstOffset, lastOffset: lastOffset}
+
+	for offset := firstOffset; offset < lastOffset; offset++ {
+		partition.messages = append(partition.messages, newMessage(offset, fmt.Sprintf("hub1.other-%d", offset),
+			testMsgType, "1"))
+	}
+
+	return partition
+}
+
+func newMessage(offset int64, id string, msgType string, version string) kafkago.Message {
+	return kafkago.Message{
+		Offset: offset,
+		Key:    []byte(id),
+		Headers: []kafkago.Header{
+			{Key: msgTypeHeader, Value: []byte(msgType)},
+			{Key: versionHeader, Value: []byte(version)},
+		},
+	}
+}
+
+// set replaces the message at the given offset.
+func (p *mockPartition) set(offset int64, id string, version string) {
+	p.messages[offset-p.firstOffset] = newMessage(offset, id, testMsgType, version)
+}
+
+func (p *mockPartition) ReadOffsets() (int64, int64, error) {
+	return p.firstOffset, p.lastOffset, nil
+}
+
+func (p *mockPartition) ReadMessages(offset int64) ([]kafkago.Message, error) {
+	p.readOffsets = append(p.readOffsets, offset)
+
+	var batch []kafkago.Message
+
+	for _, msg := range p.messages {
+		if msg.Offset >= offset && len(batch) < testBatchSize {
+			batch = append(batch, msg)
+		}
+	}
+
+	return batch, nil
+}
+
+func (p *mockPartition) Close() error {
+	return nil
+}
+
+// newTestKafka returns a Kafka whose first broker is down and whose second broker leads the given partition.
+func newTestKafka(partition partitionReader) (*Kafka, *[]string) {
+	var dialedBrokers []string
+
+	return &Kafka{
+		brokers: []string{"broker-1:9092", testHealthyHost},
+		dialPartition: func(_ context.Context, broker string, _ int) (partitionReader, error) {
+			dialedBrokers = append(dialedBrokers, broker)
+
+			if broker != testHealthyHost {
+				return nil, errBrokerDown
+			}
+
+			return partition, nil
+		},
+		log: logrtesting.NullLogger{},
+	}, &dialedBrokers
+}
+
+func TestReadLastVersionReadsBackwardsFromTheLastOffset(t *testing.T) {
+	partition := newMockPartition(0, 1000)
+	partition.set(10, testBundleID, "1")
+	partition.set(950, testBundleID, "5")
+	partition.set(960, testBundleID, "6")
+
+	kafka, dialedBrokers := newTestKafka(partition)
+
+	version, err := kafka.readLastVersionFromPartition(context.Background(), 0, testBundleID, testMsgType)
+	if err != nil {
+		t.Fatalf("failed to read the last version: %v", err)
+	}
+
+	if version != "6" {
+		t.Fatalf("expected version 6, got %q", version)
+	}
+
+	for _, offset := range partition.readOffsets {
+		if offset < 1000-readWindowSize {
+			t.Fatalf("expected only the last window to be read, read from offset %d", offset)
+		}
+	}
+
+	if len(*dialedBrokers) != 2 || (*dialedBrokers)[1] != testHealthyHost {
+		t.Fatalf("expected to fall back to the second broker, dialed %v", *dialedBrokers)
+	}
+}
+
+func TestReadLastVersionReadsEarlierWindows(t *testing.T) {
+	partition := newMockPartition(500, 1000)
+	partition.set(650, testBundleID, "3")
+
+	kafka, _ := newTestKafka(partition)
+
+	version, err := kafka.readLastVersionFromPartition(context.Background(), 0, testBundleID, testMsgType)
+	if err != nil {
+		t.Fatalf("failed to read the last version: %v", err)
+	}
+
+	if version != "3" {
+		t.Fatalf("expected version 3, got %q", version)
+	}
+
+	for _, offset := range partition.readOffsets {
+		if offset < 600 {
+			t.Fatalf("expected the windows before the match not to be read, read from offset %d", offset)
+		}
+	}
+}
+
+func TestReadLastVersionIsBounded(t *testing.T) {
+	partition := newMockPartition(0, 3*readMaxScannedOffsets)
+	partition.set(5, testBundleID, "1") // beyond the scan limit
+
+	kafka, _ := newTestKafka(partition)
+
+	version, err := kafka.readLastVersionFromPartition(context.Background(), 0, testBundleID, testMsgType)
+	if !errors.Is(err, errScanLimitReached) {
+		t.Fatalf("expected %v, got %v", errScanLimitReached, err)
+	}
+
+	if version != "" {
+		t.Fatalf("expected no version beyond the scan limit, got %q", version)
+	}
+
+	if maxReads := readMaxScannedOffsets / testBatchSize; len(partition.readOffsets) > maxReads {
+		t.Fatalf("expected at most %d reads, got %d", maxReads, len(partition.readOffsets))
+	}
+}
+
+func TestReadLastVersionStopsOnEmptyFetch(t *testing.T) {
+	partition := newMockPartition(0, 1000)
+	partition.messages = nil // e.g. the messages were removed by retention after the offsets were read
+
+	kafka, _ := newTestKafka(partition)
+
+	version, err := kafka.readLastVersionFromPartition(context.Background(), 0, testBundleID, testMsgType)
+	if err != nil {
+		t.Fatalf("failed to read the last version: %v", err)
+	}
+
+	if version != "" {
+		t.Fatalf("expected no version, got %q", version)
+	}
+
+	if len(partition.readOffsets) != 1 {
+		t.Fatalf("expected the scan to stop after the empty read, got %d reads", len(partition.readOffsets))
+	}
+}
+
+func TestReadLastVersionFailsWhenAllBrokersAreDown(t *testing.T) {
+	kafka, dialedBrokers := newTestKafka(newMockPartition(0, 10))
+	kafka.brokers = []string{"broker-1:9092", "broker-3:9092"}
+
+	if _, err := kafka.readLastVersionFromPartition(context.Background(), 0, testBundleID,
+		testMsgType); !errors.Is(err, errBrokerDown) {
+		t.Fatalf("expected %v, got %v", errBrokerDown, err)
+	}
+
+	if len(*dialedBrokers) != 2 {
+		t.Fatalf("expected all the brokers to be dialed, dialed %v", *dialedBrokers)
+	}
+}
+
+func TestSendAsyncProducesTheBundleToTheTopic(t *testing.T) {
+	broker := newTestBroker(t)
+	kafka := newStartedKafka(t)
+
+	if err := send(t, kafka, "1", map[string]string{"codec": "json"}); err != nil {
+		t.Fatalf("failed to send the bundle: %v", err)
+	}
+
+	records := broker.Records()
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record in the topic, got %d", len(records))
+	}
+
+	record := records[0]
+
+	if string(record.Key) != testBundleID {
+		t.Fatalf("expected the key %s, got %s", testBundleID, record.Key)
+	}
+
+	for header, expected := range map[string]string{
+		msgTypeHeader: testMsgType,
+		versionHeader: "1",
+		"codec":       "json",
+	} {
+		if actual := record.Headers[header]; actual != expected {
+			t.Fatalf("expected header %s to be %q, got %q", header, expected, actual)
+		}
+	}
+
+	if string(record.Value) != "payload-1" {
+		t.Fatalf("expected the payload to be produced, got %q", record.Value)
+	}
+}
+
+func TestSendAsyncRetriesARejectedProduce(t *testing.T) {
+	broker := newTestBroker(t)
+	broker.FailProduceRequests(1, errorCodeNotLeaderForPartition)
+
+	kafka := newStartedKafka(t)
+
+	if err := send(t, kafka, "1", nil); err != nil {
+		t.Fatalf("failed to send the bundle: %v", err)
+	}
+
+	if records := broker.Records(); len(records) != 1 {
+		t.Fatalf("expected the bundle to be produced once, got %d records", len(records))
+	}
+}
+
+func TestSendAsyncAfterStopReportsFailure(t *testing.T) {
+	newTestBroker(t)
+	kafka := newStartedKafka(t)
+	kafka.Stop()
+
+	if err := send(t, kafka, "1", nil); err == nil {
+		t.Fatal("expected the delivery of a bundle sent after stop to fail")
+	}
+}
+
+func TestReadTLSEnvVarsRequiresClientCertAndKeyTogether(t *testing.T) {
+	for name, envVar := range map[string]string{
+		"cert without key": envVarKafkaClientCertPath,
+		"key without cert": envVarKafkaClientKeyPath,
+	} {
+		t.Run(name, func(t *testing.T) {
+			setEnvVar(t, envVarKafkaTLSEnabled, "true")
+			setEnvVar(t, envVar, "/etc/kafka/tls/client")
+
+			if _, err := readTLSEnvVars(); !errors.Is(err, errEnvVarIllegalValue) {
+				t.Fatalf("expected %v, got %v", errEnvVarIllegalValue, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// partitionReader reads the messages of a single partition.
+type partitionReader interface {
+	// ReadOffsets returns the first offset of the partition and the offset the next message will be written at.
+	ReadOffsets() (int64, int64, error)
+	// ReadMessages returns a batch of the messages starting at the given offset. returns no messages if there are
+	// none to read.
+	ReadMessages(offset int64) ([]kafkago.Message, error)
+	Close() error
+}
+
+// dialPartitionFunc dials the leader of the partition using the given broker.
+type dialPartitionFunc func(ctx context.Context, broker string, partition int) (partitionReader, error)
+
+// leaderReader is a partitionReader of a connection to the partition leader.
+type leaderReader struct {
+	conn *kafkago.Conn
+}
+
+func newDialPartitionFunc(dialer *kafkago.Dialer, topic string) dialPartitionFunc {
+	return func(ctx context.Context, broker string, partition int) (partitionReader, error) {
+		conn, err := dialer.DialLeader(ctx, "tcp", broker, topic, partition)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial partition leader using %s - %w", broker, err)
+		}
+
+		return &leaderReader{conn: conn}, nil
+	}
+}
+
+func (r *leaderReader) ReadOffsets() (int64, int64, error) {
+	firstOffset, lastOffset, err := r.conn.ReadOffsets()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read offsets - %w", err)
+	}
+
+	return firstOffset, lastOffset, nil
+}
+
+func (r *leaderReader) ReadMessages(offset int64) ([]kafkago.Message, error) {
+	if _, err := r.conn.Seek(offset, kafkago.SeekAbsolute); err != nil {
+		return nil, fmt.Errorf("failed to seek partition - %w", err)
+	}
+
+	if err := r.conn.SetReadDeadline(time.Now().Add(readTimeout)); err != nil {
+		return nil, fmt.Errorf("failed to set read deadline - %w", err)
+	}
+
+	batch := r.conn.ReadBatch(1, readBatchMaxBytes)
+
+	var messages []kafkago.Message
+
+	for {
+		msg, err := batch.ReadMessage()
+		if err != nil {
+			break
+		}
+
+		messages = append(messages, msg)
+	}
+
+	if err := batch.Close(); err != nil {
+		return nil, fmt.Errorf("failed to read batch - %w", err)
+	}
+
+	return messages, nil
+}
+
+func (r *leaderReader) Close() error {
+	if err := r.conn.Close(); err != nil {
+		return fmt.Errorf("failed to close connection - %w", err)
+	}
+
+	return nil
+}
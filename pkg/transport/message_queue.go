@@ -0,0 +1,202 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+const (
+	// DefaultMessageQueueCapacity is the capacity of the message queue of a transport, unless it is configured using
+	// the TRANSPORT_QUEUE_CAPACITY environment variable.
+	DefaultMessageQueueCapacity = 100
+
+	envVarMessageQueueCapacity = "TRANSPORT_QUEUE_CAPACITY"
+)
+
+var (
+	errIllegalQueueCapacity = errors.New("illegal message queue capacity")
+
+	// ErrMessageSuperseded is reported to the delivery callback of a message that was replaced by a newer message
+	// with the same id and type before it was sent.
+	ErrMessageSuperseded = errors.New("message was superseded by a newer message")
+	// ErrQueueFull is reported to the delivery callback of a message that was dropped since the queue was full.
+	ErrQueueFull = errors.New("message queue is full")
+	// ErrTransportStopped is reported to the delivery callback of a message that was sent after the transport was
+	// stopped.
+	ErrTransportStopped = errors.New("transport was stopped")
+)
+
+// ReadMessageQueueCapacity returns the message queue capacity from the TRANSPORT_QUEUE_CAPACITY environment variable,
+// or DefaultMessageQueueCapacity if it is not set.
+func ReadMessageQueueCapacity() (int, error) {
+	capacityStr := os.Getenv(envVarMessageQueueCapacity)
+	if capacityStr == "" {
+		return DefaultMessageQueueCapacity, nil
+	}
+
+	capacity, err := strconv.Atoi(capacityStr)
+	if err != nil || capacity <= 0 {
+		return 0, fmt.Errorf("%w: %s must be a positive integer", errIllegalQueueCapacity, envVarMessageQueueCapacity)
+	}
+
+	return capacity, nil
+}
+
+// NewMessageQueue creates a new instance of MessageQueue. name identifies the queue in metrics. a capacity smaller
+// than 1 is raised to 1, so the queue always holds the newest message.
+func NewMessageQueue(name string, capacity int) *MessageQueue {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &MessageQueue{
+		name:       name,
+		capacity:   capacity,
+		keys:       make([]string, 0, capacity),
+		messages:   make(map[string]*Message, capacity),
+		signalChan: make(chan struct{}, 1),
+		lock:       sync.Mutex{},
+	}
+}
+
+// MessageQueue is a bounded non blocking queue that keeps only the newest pending message per id and type.
+// messages are popped in the order their id and type were first pushed.
+type MessageQueue struct {
+	name         string
+	capacity     int
+	keys         []string
+	messages     map[string]*Message
+	signalChan   chan struct{}
+	droppedCount uint64
+	closed       bool
+	lock         sync.Mutex
+}
+
+// Push adds a message to the queue without blocking. a pending message with the same id and type is replaced.
+// if the queue is full, the oldest pending message is dropped. if the queue was closed, ErrTransportStopped is
+// reported to the message.
+func (q *MessageQueue) Push(message *Message) {
+	q.lock.Lock()
+
+	if q.closed {
+		q.lock.Unlock()
+		message.ReportDeliveryResult(ErrTransportStopped)
+
+		return
+	}
+
+	key := queueKey(message.ID, message.MsgType)
+
+	var droppedMessage *Message
+
+	var dropReason error
+
+	dropReasonLabel := ""
+
+	if pendingMessage, found := q.messages[key]; found {
+		droppedMessage, dropReason, dropReasonLabel = pendingMessage, ErrMessageSuperseded, "superseded"
+	} else {
+		if len(q.keys) >= q.capacity {
+			oldestKey := q.keys[0]
+			droppedMessage, dropReason, dropReasonLabel = q.messages[oldestKey], ErrQueueFull, "queue_full"
+			q.keys = q.keys[1:]
+			delete(q.messages, oldestKey)
+		}
+
+		q.keys = append(q.keys, key)
+	}
+
+	q.messages[key] = message
+	queueDepthGauge.WithLabelValues(q.name).Set(float64(len(q.keys)))
+
+	if droppedMessage != nil {
+		q.droppedCount++
+		queueDroppedCounter.WithLabelValues(q.name, dropReasonLabel).Inc()
+	}
+
+	q.lock.Unlock()
+
+	select { // signal a waiting consumer, if there is one
+	case q.signalChan <- struct{}{}:
+	default:
+	}
+
+	if droppedMessage != nil { // report outside the lock, the callback may push again
+		droppedMessage.ReportDeliveryResult(dropReason)
+	}
+}
+
+// Pop blocks until a message is available and removes it from the queue. returns nil if stopChan was closed and
+// the queue is empty.
+func (q *MessageQueue) Pop(stopChan <-chan struct{}) *Message {
+	for {
+		if message := q.tryPop(); message != nil {
+			return message
+		}
+
+		select {
+		case <-stopChan:
+			return nil
+		case <-q.signalChan:
+		}
+	}
+}
+
+// Close rejects the messages that are pushed from now on. the messages that are already in the queue can still be
+// popped, so they can be drained.
+func (q *MessageQueue) Close() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.closed = true
+}
+
+// Contains returns true if a message with the given id and type is waiting in the queue, otherwise false.
+func (q *MessageQueue) Contains(id string, msgType string) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	_, found := q.messages[queueKey(id, msgType)]
+
+	return found
+}
+
+// Len returns the number of messages waiting in the queue.
+func (q *MessageQueue) Len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return len(q.keys)
+}
+
+// DroppedCount returns the number of messages that were dropped from the queue before they were popped.
+func (q *MessageQueue) DroppedCount() uint64 {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.droppedCount
+}
+
+func (q *MessageQueue) tryPop() *Message {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if len(q.keys) == 0 {
+		return nil
+	}
+
+	key := q.keys[0]
+	message := q.messages[key]
+	q.keys = q.keys[1:]
+	delete(q.messages, key)
+	queueDepthGauge.WithLabelValues(q.name).Set(float64(len(q.keys)))
+
+	return message
+}
+
+func queueKey(id string, msgType string) string {
+	return fmt.Sprintf("%s.%s", msgType, id)
+}
@@ -0,0 +1,181 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const testMsgType = "StatusBundle"
+
+func setEnvVar(t *testing.T, name string, value string) {
+	t.Helper()
+
+	if err := os.Setenv(name, value); err != nil {
+		t.Fatalf("failed to set %s: %v", name, err)
+	}
+
+	t.Cleanup(func() { _ = os.Unsetenv(name) })
+}
+
+func TestNewMessageQueueRaisesIllegalCapacity(t *testing.T) {
+	for _, capacity := range []int{0, -1} {
+		t.Run(fmt.Sprint(capacity), func(t *testing.T) {
+			queue := NewMessageQueue("test", capacity)
+
+			var dropped error
+
+			queue.Push(&Message{ID: "1", MsgType: testMsgType, DeliveryCallback: func(err error) { dropped = err }})
+			queue.Push(&Message{ID: "2", MsgType: testMsgType})
+
+			if queue.Len() != 1 || !errors.Is(dropped, ErrQueueFull) {
+				t.Fatalf("expected the queue to hold only the newest message, len %d, dropped %v", queue.Len(), dropped)
+			}
+
+			if message := queue.Pop(nil); message.ID != "2" {
+				t.Fatalf("expected message 2, got %s", message.ID)
+			}
+		})
+	}
+}
+
+func TestReadMessageQueueCapacity(t *testing.T) {
+	for name, test := range map[string]struct {
+		value            string
+		expectedCapacity int
+		expectedErr      error
+	}{
+		"not set":  {expectedCapacity: DefaultMessageQueueCapacity},
+		"set":      {value: "500", expectedCapacity: 500},
+		"zero":     {value: "0", expectedErr: errIllegalQueueCapacity},
+		"negative": {value: "-5", expectedErr: errIllegalQueueCapacity},
+		"invalid":  {value: "many", expectedErr: errIllegalQueueCapacity},
+	} {
+		t.Run(name, func(t *testing.T) {
+			setEnvVar(t, envVarMessageQueueCapacity, test.value)
+
+			capacity, err := ReadMessageQueueCapacity()
+			if !errors.Is(err, test.expectedErr) || capacity != test.expectedCapacity {
+				t.Fatalf("expected capacity %d and error %v, got %d and %v", test.expectedCapacity, test.expectedErr,
+					capacity, err)
+			}
+		})
+	}
+}
+
+func TestPushToClosedQueueReportsStopped(t *testing.T) {
+	queue := NewMessageQueue("test", DefaultMessageQueueCapacity)
+	queue.Push(&Message{ID: "1", MsgType: testMsgType})
+	queue.Close()
+
+	var result error
+
+	queue.Push(&Message{ID: "2", MsgType: testMsgType, DeliveryCallback: func(err error) { result = err }})
+
+	if !errors.Is(result, ErrTransportStopped) {
+		t.Fatalf("expected %v, got %v", ErrTransportStopped, result)
+	}
+
+	if message := queue.Pop(nil); message.ID != "1" || queue.Len() != 0 {
+		t.Fatal("expected the message that was pushed before closing to be drained")
+	}
+}
+
+func TestPushReplacesThePendingMessageOfTheSameID(t *testing.T) {
+	queue := NewMessageQueue("test-superseded", DefaultMessageQueueCapacity)
+
+	var superseded error
+
+	queue.Push(&Message{ID: "1", MsgType: testMsgType, Version: "1",
+		DeliveryCallback: func(err error) { superseded = err }})
+	queue.Push(&Message{ID: "2", MsgType: testMsgType, Version: "1"})
+	queue.Push(&Message{ID: "1", MsgType: testMsgType, Version: "2"})
+
+	if !errors.Is(superseded, ErrMessageSuperseded) {
+		t.Fatalf("expected %v, got %v", ErrMessageSuperseded, superseded)
+	}
+
+	if queue.Len() != 2 || queue.DroppedCount() != 1 {
+		t.Fatalf("expected 2 pending messages and 1 dropped, got %d and %d", queue.Len(), queue.DroppedCount())
+	}
+
+	// the newer version keeps the place of the message it replaced
+	if message := queue.Pop(nil); message.ID != "1" || message.Version != "2" {
+		t.Fatalf("expected version 2 of message 1, got version %s of message %s", message.Version, message.ID)
+	}
+
+	if message := queue.Pop(nil); message.ID != "2" {
+		t.Fatalf("expected message 2, got %s", message.ID)
+	}
+}
+
+func TestMessagesOfTheSameIDWithDifferentTypesAreNotReplaced(t *testing.T) {
+	queue := NewMessageQueue("test-types", DefaultMessageQueueCapacity)
+	queue.Push(&Message{ID: "1", MsgType: testMsgType})
+	queue.Push(&Message{ID: "1", MsgType: testMsgType + "Chunk"})
+
+	if queue.Len() != 2 || queue.DroppedCount() != 0 {
+		t.Fatalf("expected 2 pending messages and none dropped, got %d and %d", queue.Len(), queue.DroppedCount())
+	}
+}
+
+func TestPopReturnsMessagesInOrderOfTheirIDs(t *testing.T) {
+	queue := NewMessageQueue("test-fifo", DefaultMessageQueueCapacity)
+
+	for _, id := range []string{"c", "a", "b"} {
+		queue.Push(&Message{ID: id, MsgType: testMsgType})
+	}
+
+	for _, expectedID := range []string{"c", "a", "b"} {
+		if message := queue.Pop(nil); message.ID != expectedID {
+			t.Fatalf("expected message %s, got %s", expectedID, message.ID)
+		}
+	}
+}
+
+func TestPushToFullQueueDropsTheOldestMessage(t *testing.T) {
+	queue := NewMessageQueue("test-full", 2)
+	dropped := map[string]error{}
+
+	for _, id := range []string{"1", "2", "3"} {
+		id := id
+		queue.Push(&Message{ID: id, MsgType: testMsgType, DeliveryCallback: func(err error) { dropped[id] = err }})
+	}
+
+	if len(dropped) != 1 || !errors.Is(dropped["1"], ErrQueueFull) {
+		t.Fatalf("expected only message 1 to be dropped with %v, got %v", ErrQueueFull, dropped)
+	}
+
+	if message := queue.Pop(nil); message.ID != "2" {
+		t.Fatalf("expected message 2, got %s", message.ID)
+	}
+}
+
+func TestQueueMetrics(t *testing.T) {
+	const name = "test-metrics"
+
+	queue := NewMessageQueue(name, 2)
+	queue.Push(&Message{ID: "1", MsgType: testMsgType})
+	queue.Push(&Message{ID: "1", MsgType: testMsgType}) // superseded
+	queue.Push(&Message{ID: "2", MsgType: testMsgType})
+	queue.Push(&Message{ID: "3", MsgType: testMsgType}) // the queue is full
+
+	if depth := testutil.ToFloat64(queueDepthGauge.WithLabelValues(name)); depth != 2 {
+		t.Fatalf("expected queue depth 2, got %v", depth)
+	}
+
+	for reason, expected := range map[string]float64{"superseded": 1, "queue_full": 1} {
+		if dropped := testutil.ToFloat64(queueDroppedCounter.WithLabelValues(name, reason)); dropped != expected {
+			t.Fatalf("expected %v messages dropped as %s, got %v", expected, reason, dropped)
+		}
+	}
+
+	queue.Pop(nil)
+
+	if depth := testutil.ToFloat64(queueDepthGauge.WithLabelValues(name)); depth != 1 {
+		t.Fatalf("expected queue depth 1 after pop, got %v", depth)
+	}
+}
@@ -0,0 +1,25 @@
+package transport
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	queueDepthGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "leaf_hub_status_sync_transport_queue_depth",
+		Help: "Number of messages waiting in the transport queue.",
+	}, []string{"transport"})
+	queueDroppedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "leaf_hub_status_sync_transport_queue_dropped_messages_total",
+		Help: "Number of messages dropped from the transport queue before they were sent.",
+	}, []string{"transport", "reason"})
+	circuitBreakerStateGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "leaf_hub_status_sync_transport_circuit_breaker_state",
+		Help: "State of the transport circuit breaker (0 - closed, 1 - half-open, 2 - open).",
+	}, []string{"transport"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(queueDepthGauge, queueDroppedCounter, circuitBreakerStateGauge)
+}
@@ -0,0 +1,142 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+)
+
+var (
+	errUnsupportedMiddleware = errors.New("unsupported transport middleware")
+
+	middlewareRegistryLock sync.RWMutex
+	middlewareRegistry     = make(map[string]MiddlewareFactory)
+)
+
+// MiddlewareFactory creates a middleware, a transport that wraps next and may see and change the messages on the send
+// path before passing them on. each middleware reads and validates its own configuration. a middleware that has to be
+// started and stopped implements Service.
+type MiddlewareFactory func(next Transport, log logr.Logger) (Transport, error)
+
+// RegisterMiddleware registers a middleware factory under the given name. middlewares register themselves in the init
+// function of their package. panics if the name is already registered.
+func RegisterMiddleware(name string, factory MiddlewareFactory) {
+	middlewareRegistryLock.Lock()
+	defer middlewareRegistryLock.Unlock()
+
+	if _, found := middlewareRegistry[name]; found {
+		panic(fmt.Sprintf("transport middleware %s is already registered", name))
+	}
+
+	middlewareRegistry[name] = factory
+}
+
+// RegisteredMiddlewares returns the sorted names of the registered middlewares.
+func RegisteredMiddlewares() []string {
+	middlewareRegistryLock.RLock()
+	defer middlewareRegistryLock.RUnlock()
+
+	names := make([]string, 0, len(middlewareRegistry))
+	for name := range middlewareRegistry {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Chain is an ordered chain of middlewares around a transport. the first middleware sees the messages first, the
+// last one passes them to the transport.
+type Chain struct {
+	Transport
+	// services are the middlewares that have to be started and stopped, from the transport outwards.
+	services  []Service
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// NewChain creates a chain of the named middlewares around the given transport, using the registered factories.
+func NewChain(transportToWrap Transport, middlewareNames []string, log logr.Logger) (*Chain, error) {
+	chain := &Chain{
+		Transport: transportToWrap,
+		services:  make([]Service, 0),
+	}
+
+	for i := len(middlewareNames) - 1; i >= 0; i-- { // build from the transport outwards
+		name := middlewareNames[i]
+
+		middlewareRegistryLock.RLock()
+		factory, found := middlewareRegistry[name]
+		middlewareRegistryLock.RUnlock()
+
+		if !found {
+			return nil, fmt.Errorf("%w: %s (registered middlewares: %s)", errUnsupportedMiddleware, name,
+				strings.Join(RegisteredMiddlewares(), ", "))
+		}
+
+		middleware, err := factory(chain.Transport, log.WithName(name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create transport middleware %s: %w", name, err)
+		}
+
+		if service, ok := middleware.(Service); ok {
+			chain.services = append(chain.services, service)
+		}
+
+		chain.Transport = middleware
+	}
+
+	return chain, nil
+}
+
+// Start starts the middlewares that have to be started, from the transport outwards.
+func (chain *Chain) Start() {
+	chain.startOnce.Do(func() {
+		for _, service := range chain.services {
+			service.Start()
+		}
+	})
+}
+
+// Stop stops the middlewares that have to be stopped, from the outermost inwards.
+func (chain *Chain) Stop() {
+	chain.stopOnce.Do(func() {
+		for i := len(chain.services) - 1; i >= 0; i-- {
+			chain.services[i].Stop()
+		}
+	})
+}
+
+// SendFunc processes a message on the send path. it may change the message, e.g. its id, type, version, payload or
+// metadata. if it returns an error, the error is reported to the delivery callback and the message is not passed on.
+type SendFunc func(message *Message) error
+
+// NewSendFuncMiddleware returns a middleware factory of a middleware that applies sendFunc to every message before
+// passing it on. GetVersion and Subscribe are passed on as is.
+func NewSendFuncMiddleware(sendFunc SendFunc) MiddlewareFactory {
+	return func(next Transport, _ logr.Logger) (Transport, error) {
+		return &sendFuncMiddleware{
+			Transport: next,
+			sendFunc:  sendFunc,
+		}, nil
+	}
+}
+
+type sendFuncMiddleware struct {
+	Transport
+	sendFunc SendFunc
+}
+
+func (middleware *sendFuncMiddleware) SendAsync(message *Message) {
+	if err := middleware.sendFunc(message); err != nil {
+		message.ReportDeliveryResult(err)
+		return
+	}
+
+	middleware.Transport.SendAsync(message)
+}
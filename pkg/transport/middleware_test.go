@@ -0,0 +1,120 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr"
+	logrtesting "github.com/go-logr/logr/testing"
+)
+
+var errRejected = errors.New("rejected by middleware")
+
+// registerTestMiddleware registers a middleware that appends its name to the payload of the messages it passes on.
+// if events is not nil, the middleware is a service that records when it's started and stopped.
+func registerTestMiddleware(t *testing.T, name string, events *[]string) string {
+	t.Helper()
+
+	middlewareName := fmt.Sprintf("%s/%s", t.Name(), name)
+
+	RegisterMiddleware(middlewareName, func(next Transport, _ logr.Logger) (Transport, error) {
+		middleware, _ := NewSendFuncMiddleware(func(message *Message) error {
+			message.Payload = append(message.Payload, []byte(name+" ")...)
+			return nil
+		})(next, logrtesting.NullLogger{})
+
+		if events == nil {
+			return middleware, nil
+		}
+
+		return &serviceMiddleware{Transport: middleware, testService: testService{name: name, events: events}}, nil
+	})
+
+	return middlewareName
+}
+
+// serviceMiddleware is a middleware that has to be started and stopped.
+type serviceMiddleware struct {
+	Transport
+	testService
+}
+
+func (middleware *serviceMiddleware) SendAsync(message *Message) {
+	middleware.Transport.SendAsync(message)
+}
+
+func (middleware *serviceMiddleware) GetVersion(id string, msgType string) string {
+	return middleware.Transport.GetVersion(id, msgType)
+}
+
+func (middleware *serviceMiddleware) Subscribe(commandType string, handler CommandHandler) {
+	middleware.Transport.Subscribe(commandType, handler)
+}
+
+func TestChainPassesTheMessagesThroughTheMiddlewaresInOrder(t *testing.T) {
+	wrapped := &testService{}
+
+	chain, err := NewChain(wrapped, []string{
+		registerTestMiddleware(t, "first", nil),
+		registerTestMiddleware(t, "second", nil),
+		registerTestMiddleware(t, "third", nil),
+	}, logrtesting.NullLogger{})
+	if err != nil {
+		t.Fatalf("failed to create the chain: %v", err)
+	}
+
+	chain.SendAsync(&Message{ID: "1", MsgType: testMsgType})
+
+	if len(wrapped.sent) != 1 {
+		t.Fatalf("expected the message to reach the transport, got %d messages", len(wrapped.sent))
+	}
+
+	if payload := string(wrapped.sent[0].Payload); payload != "first second third " {
+		t.Fatalf("expected the middlewares to see the message in order, got %q", payload)
+	}
+}
+
+func TestChainStartsFromTheTransportOutwardsAndStopsInReverse(t *testing.T) {
+	var events []string
+
+	chain, err := NewChain(&testService{}, []string{
+		registerTestMiddleware(t, "outer", &events),
+		registerTestMiddleware(t, "plain", nil),
+		registerTestMiddleware(t, "inner", &events),
+	}, logrtesting.NullLogger{})
+	if err != nil {
+		t.Fatalf("failed to create the chain: %v", err)
+	}
+
+	chain.Start()
+	chain.Start() // started once
+	chain.Stop()
+	chain.Stop() // stopped once
+
+	expected := []string{"start inner", "start outer", "stop outer", "stop inner"}
+	if fmt.Sprint(events) != fmt.Sprint(expected) {
+		t.Fatalf("expected %v, got %v", expected, events)
+	}
+}
+
+func TestChainOfUnknownMiddlewareFails(t *testing.T) {
+	if _, err := NewChain(&testService{}, []string{"unknown"},
+		logrtesting.NullLogger{}); !errors.Is(err, errUnsupportedMiddleware) {
+		t.Fatalf("expected %v, got %v", errUnsupportedMiddleware, err)
+	}
+}
+
+func TestSendFuncErrorIsReportedAndTheMessageIsNotPassedOn(t *testing.T) {
+	wrapped := &testService{}
+	middleware, _ := NewSendFuncMiddleware(func(*Message) error { return errRejected })(wrapped,
+		logrtesting.NullLogger{})
+
+	var result error
+
+	middleware.SendAsync(&Message{ID: "1", MsgType: testMsgType, DeliveryCallback: func(err error) { result = err }})
+
+	if !errors.Is(result, errRejected) || len(wrapped.sent) != 0 {
+		t.Fatalf("expected the message to be rejected, got %v and %d sent messages", result, len(wrapped.sent))
+	}
+}
@@ -0,0 +1,11 @@
+package mqtt
+
+// Envelope is the payload of the MQTT messages. MQTT 3.1.1 has no message properties, so the bundle id, type,
+// version and metadata travel in the envelope together with the bundle payload.
+type Envelope struct {
+	ID       string            `json:"id"`
+	MsgType  string            `json:"msgType"`
+	Version  string            `json:"version"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Payload  []byte            `json:"payload"`
+}
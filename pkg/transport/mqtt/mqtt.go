@@ -0,0 +1,397 @@
+package mqtt
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	pahomqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/go-logr/logr"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+)
+
+const (
+	envVarMQTTBrokerURL      = "MQTT_BROKER_URL"
+	envVarMQTTClientID       = "MQTT_CLIENT_ID"
+	envVarMQTTUsername       = "MQTT_USERNAME"
+	envVarMQTTPassword       = "MQTT_PASSWORD"
+	envVarMQTTTopicPrefix    = "MQTT_TOPIC_PREFIX"
+	envVarMQTTCACertPath     = "MQTT_CA_CERT_PATH"
+	envVarMQTTClientCertPath = "MQTT_CLIENT_CERT_PATH"
+	envVarMQTTClientKeyPath  = "MQTT_CLIENT_KEY_PATH"
+	envVarMQTTDrainTimeout   = "MQTT_DRAIN_TIMEOUT"
+	envVarMQTTConnectTimeout = "MQTT_CONNECT_TIMEOUT"
+	envVarLeafHubID          = "LH_ID"
+
+	clientIDPrefix     = "leaf-hub-status-sync-"
+	defaultTopicPrefix = "leaf-hub-status"
+
+	qosAtLeastOnce        = 1
+	protocolVersion311    = 4
+	publishTimeout        = 10 * time.Second
+	readTimeout           = 5 * time.Second
+	disconnectQuiesceMs   = 250
+	defaultDrainTimeout   = 10 * time.Second
+	defaultConnectTimeout = time.Minute
+	connectRetryInterval  = 5 * time.Second
+)
+
+var (
+	errEnvVarNotFound     = errors.New("not found environment variable")
+	errEnvVarWrongType    = errors.New("wrong type of environment variable")
+	errEnvVarIllegalValue = errors.New("illegal value of environment variable")
+	errFailedToLoadCACert = errors.New("failed to append CA certificate to the pool")
+	errTimeout            = errors.New("timed out waiting for the broker")
+	errNotConnected       = errors.New("not connected to the mqtt broker")
+	errMQTTStopped        = errors.New("mqtt was stopped")
+)
+
+// TransportType is the transport type the MQTT transport is registered under.
+const TransportType = "mqtt"
+
+func init() {
+	transport.Register(TransportType, func(log logr.Logger) (transport.Service, error) {
+		return NewMQTT(log)
+	})
+}
+
+// MQTT abstracts an MQTT 3.1.1 client that publishes each bundle as a retained QoS 1 message to
+// <topic prefix>/<msgType>/<id>, so the broker always holds the latest bundle per key.
+type MQTT struct {
+	client         pahomqtt.Client
+	topicPrefix    string
+	queue          *transport.MessageQueue
+	connectTimeout time.Duration
+	connectedChan  chan struct{}
+	connectedOnce  sync.Once
+	drainTimeout   time.Duration
+	drainChan      chan struct{}
+	doneChan       chan struct{}
+	stopChan       chan struct{}
+	startOnce      sync.Once
+	stopOnce       sync.Once
+	log            logr.Logger
+}
+
+// NewMQTT creates a new instance of MQTT.
+func NewMQTT(log logr.Logger) (*MQTT, error) {
+	clientOptions, topicPrefix, connectTimeout, drainTimeout, err := readEnvVars()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize mqtt - %w", err)
+	}
+
+	queueCapacity, err := transport.ReadMessageQueueCapacity()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize mqtt - %w", err)
+	}
+
+	return newMQTT(clientOptions, topicPrefix, connectTimeout, queueCapacity, drainTimeout, log), nil
+}
+
+func newMQTT(clientOptions *pahomqtt.ClientOptions, topicPrefix string, connectTimeout time.Duration,
+	queueCapacity int, drainTimeout time.Duration, log logr.Logger) *MQTT {
+	mqtt := &MQTT{
+		topicPrefix:    topicPrefix,
+		queue:          transport.NewMessageQueue("mqtt", queueCapacity),
+		connectTimeout: connectTimeout,
+		connectedChan:  make(chan struct{}),
+		drainTimeout:   drainTimeout,
+		drainChan:      make(chan struct{}),
+		doneChan:       make(chan struct{}),
+		stopChan:       make(chan struct{}, 1),
+		log:            log,
+	}
+
+	clientOptions.SetOnConnectHandler(func(pahomqtt.Client) {
+		mqtt.connectedOnce.Do(func() { close(mqtt.connectedChan) })
+	})
+	clientOptions.SetConnectionLostHandler(func(_ pahomqtt.Client, err error) {
+		mqtt.log.Error(err, "Lost connection to the mqtt broker, reconnecting")
+	})
+
+	mqtt.client = pahomqtt.NewClient(clientOptions)
+
+	return mqtt
+}
+
+func readEnvVars() (*pahomqtt.ClientOptions, string, time.Duration, time.Duration, error) {
+	brokerURL := os.Getenv(envVarMQTTBrokerURL)
+	if brokerURL == "" {
+		return nil, "", 0, 0, fmt.Errorf("%w: %s", errEnvVarNotFound, envVarMQTTBrokerURL)
+	}
+
+	// the session is persistent, so the client id must be unique per leaf hub
+	clientID := os.Getenv(envVarMQTTClientID)
+	if clientID == "" {
+		leafHubID := os.Getenv(envVarLeafHubID)
+		if leafHubID == "" {
+			return nil, "", 0, 0, fmt.Errorf("%w: %s or %s", errEnvVarNotFound, envVarMQTTClientID,
+				envVarLeafHubID)
+		}
+
+		clientID = clientIDPrefix + leafHubID
+	}
+
+	topicPrefix := strings.TrimSuffix(os.Getenv(envVarMQTTTopicPrefix), "/")
+	if topicPrefix == "" {
+		topicPrefix = defaultTopicPrefix
+	}
+
+	connectTimeout, err := readDurationEnvVar(envVarMQTTConnectTimeout, defaultConnectTimeout)
+	if err != nil {
+		return nil, "", 0, 0, err
+	}
+
+	drainTimeout, err := readDurationEnvVar(envVarMQTTDrainTimeout, defaultDrainTimeout)
+	if err != nil {
+		return nil, "", 0, 0, err
+	}
+
+	tlsConfig, err := readTLSEnvVars()
+	if err != nil {
+		return nil, "", 0, 0, err
+	}
+
+	clientOptions := pahomqtt.NewClientOptions().
+		AddBroker(brokerURL).
+		SetClientID(clientID).
+		SetProtocolVersion(protocolVersion311).
+		SetCleanSession(false). // keep unacknowledged QoS 1 messages across reconnects
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(connectRetryInterval).
+		SetTLSConfig(tlsConfig)
+
+	if username := os.Getenv(envVarMQTTUsername); username != "" {
+		clientOptions.SetUsername(username).SetPassword(os.Getenv(envVarMQTTPassword))
+	}
+
+	return clientOptions, topicPrefix, connectTimeout, drainTimeout, nil
+}
+
+// readDurationEnvVar returns the given default if the environment variable is not set.
+func readDurationEnvVar(envVar string, defaultValue time.Duration) (time.Duration, error) {
+	valueStr := os.Getenv(envVar)
+	if valueStr == "" {
+		return defaultValue, nil
+	}
+
+	value, err := time.ParseDuration(valueStr)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s must be a duration", errEnvVarWrongType, envVar)
+	}
+
+	return value, nil
+}
+
+// readTLSEnvVars returns nil if neither a CA certificate nor a client certificate are configured.
+func readTLSEnvVars() (*tls.Config, error) {
+	caCertPath := os.Getenv(envVarMQTTCACertPath)
+	clientCertPath := os.Getenv(envVarMQTTClientCertPath)
+	clientKeyPath := os.Getenv(envVarMQTTClientKeyPath)
+
+	if caCertPath == "" && clientCertPath == "" && clientKeyPath == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caCertPath != "" {
+		caCert, err := ioutil.ReadFile(caCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate - %w", err)
+		}
+
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("%w: %s", errFailedToLoadCACert, caCertPath)
+		}
+
+		tlsConfig.RootCAs = certPool
+	}
+
+	if (clientCertPath == "") != (clientKeyPath == "") {
+		return nil, fmt.Errorf("%w: %s and %s must be set together", errEnvVarIllegalValue,
+			envVarMQTTClientCertPath, envVarMQTTClientKeyPath)
+	}
+
+	if clientCertPath != "" {
+		clientCert, err := tls.LoadX509KeyPair(clientCertPath, clientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate - %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	return tlsConfig, nil
+}
+
+// Start function starts mqtt. the connection to the broker is retried in the background until it succeeds, sending
+// and reading versions wait for it.
+func (m *MQTT) Start() {
+	m.startOnce.Do(func() {
+		m.client.Connect()
+		go m.sendMessages()
+	})
+}
+
+// Stop function stops mqtt. messages that are waiting in the queue are sent until the drain timeout expires.
+func (m *MQTT) Stop() {
+	m.stopOnce.Do(func() {
+		m.queue.Close() // messages that are sent from now on are reported as failed
+		close(m.drainChan)
+
+		if transport.WasStarted(&m.startOnce) {
+			select {
+			case <-m.doneChan:
+				m.log.Info("all messages were sent")
+			case <-time.After(m.drainTimeout):
+				m.log.Info(fmt.Sprintf("drain timeout expired, %d messages were not sent", m.queue.Len()))
+			}
+		}
+
+		close(m.stopChan)
+		m.client.Disconnect(disconnectQuiesceMs)
+	})
+}
+
+// SendAsync function publishes a message to the broker asynchronously.
+func (m *MQTT) SendAsync(message *transport.Message) {
+	m.queue.Push(message)
+}
+
+// GetVersion returns the version of the retained message with the given id and type. waits for the connection to the
+// broker until the connect timeout expires, so the version isn't reported as missing while connecting. if the broker
+// has no such message or an error occurred returns an empty string.
+func (m *MQTT) GetVersion(id string, msgType string) string {
+	if err := m.waitForConnection(); err != nil {
+		m.log.Error(err, "Failed to read the retained version from the mqtt broker", "id", id, "type", msgType)
+		return ""
+	}
+
+	version, err := m.readRetainedVersion(id, msgType)
+	if err != nil {
+		m.log.Error(err, "Failed to read the retained version from the mqtt broker", "id", id, "type", msgType)
+		return ""
+	}
+
+	return version
+}
+
+// Subscribe function does nothing, receiving commands is not supported by the mqtt transport.
+func (m *MQTT) Subscribe(string, transport.CommandHandler) {}
+
+func (m *MQTT) sendMessages() {
+	defer close(m.doneChan)
+
+	for {
+		msg := m.queue.Pop(m.drainChan)
+		if msg == nil { // drained
+			return
+		}
+
+		select {
+		case <-m.stopChan: // drain timeout expired
+			msg.ReportDeliveryResult(errMQTTStopped)
+			return
+		default:
+		}
+
+		msg.ReportDeliveryResult(m.sendMessage(msg))
+	}
+}
+
+func (m *MQTT) sendMessage(msg *transport.Message) error {
+	payload, err := json.Marshal(&Envelope{
+		ID:       msg.ID,
+		MsgType:  msg.MsgType,
+		Version:  msg.Version,
+		Metadata: msg.Metadata,
+		Payload:  msg.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal the mqtt envelope - %w", err)
+	}
+
+	token := m.client.Publish(m.topic(msg.ID, msg.MsgType), qosAtLeastOnce, true, payload)
+	if err := waitForToken(token, publishTimeout); err != nil {
+		m.log.Error(err, "Failed to publish the message to the mqtt broker")
+		return fmt.Errorf("failed to publish the message to the mqtt broker - %w", err)
+	}
+
+	m.log.Info(fmt.Sprintf("Message '%s' from type '%s' with version '%s' sent", msg.ID, msg.MsgType, msg.Version))
+
+	return nil
+}
+
+// waitForConnection waits until the first connection to the broker succeeds. paho keeps retrying to connect after
+// Start, and reconnects automatically once connected.
+func (m *MQTT) waitForConnection() error {
+	select {
+	case <-m.connectedChan:
+		return nil
+	case <-time.After(m.connectTimeout):
+		return fmt.Errorf("%w after %s", errNotConnected, m.connectTimeout)
+	}
+}
+
+// readRetainedVersion subscribes to the topic of the given id and type, and reads the version from the retained
+// message that the broker delivers on subscription. returns an empty string if no message arrives until the timeout.
+func (m *MQTT) readRetainedVersion(id string, msgType string) (string, error) {
+	topic := m.topic(id, msgType)
+	envelopeChan := make(chan *Envelope, 1)
+
+	token := m.client.Subscribe(topic, qosAtLeastOnce, func(_ pahomqtt.Client, message pahomqtt.Message) {
+		envelope := &Envelope{}
+		if err := json.Unmarshal(message.Payload(), envelope); err != nil {
+			m.log.Error(err, "Failed to parse the retained mqtt envelope", "topic", topic)
+			return
+		}
+
+		select {
+		case envelopeChan <- envelope:
+		default:
+		}
+	})
+	if err := waitForToken(token, readTimeout); err != nil {
+		return "", fmt.Errorf("failed to subscribe to %s - %w", topic, err)
+	}
+
+	defer func() {
+		if err := waitForToken(m.client.Unsubscribe(topic), readTimeout); err != nil {
+			m.log.Error(err, "Failed to unsubscribe", "topic", topic)
+		}
+	}()
+
+	select {
+	case envelope := <-envelopeChan:
+		return envelope.Version, nil
+	case <-time.After(readTimeout):
+		return "", nil // no retained message
+	}
+}
+
+// topic returns the topic of the given id and type, <topic prefix>/<msgType>/<id>.
+func (m *MQTT) topic(id string, msgType string) string {
+	return fmt.Sprintf("%s/%s/%s", m.topicPrefix, msgType, id)
+}
+
+func waitForToken(token pahomqtt.Token, timeout time.Duration) error {
+	if !token.WaitTimeout(timeout) {
+		return errTimeout
+	}
+
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("mqtt request failed - %w", err)
+	}
+
+	return nil
+}
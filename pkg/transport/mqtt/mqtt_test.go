@@ -0,0 +1,266 @@
+package mqtt
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	pahomqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/eclipse/paho.mqtt.golang/packets"
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+)
+
+const (
+	testMsgType       = "StatusBundle"
+	testID            = "hub1.policies"
+	testTopicPrefix   = "leaf-hub-status"
+	testTimeout       = 10 * time.Second
+	testDrainTimeout  = time.Second
+	testLeafHubID     = "hub1"
+	testBrokerAddress = "tcp://127.0.0.1:1"
+)
+
+// fakeBroker is an in-process MQTT 3.1.1 broker that keeps the retained messages and delivers them on subscription.
+type fakeBroker struct {
+	listener  net.Listener
+	lock      sync.Mutex
+	retained  map[string][]byte
+	clientIDs []string
+}
+
+func newFakeBroker(t *testing.T) *fakeBroker {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	broker := &fakeBroker{listener: listener, retained: make(map[string][]byte)}
+
+	go broker.accept()
+
+	t.Cleanup(func() { _ = listener.Close() })
+
+	return broker
+}
+
+func (b *fakeBroker) url() string {
+	return "tcp://" + b.listener.Addr().String()
+}
+
+func (b *fakeBroker) accept() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go b.serve(conn)
+	}
+}
+
+func (b *fakeBroker) serve(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		packet, err := packets.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+
+		var responses []packets.ControlPacket
+
+		switch packet := packet.(type) {
+		case *packets.ConnectPacket:
+			b.lock.Lock()
+			b.clientIDs = append(b.clientIDs, packet.ClientIdentifier)
+			b.lock.Unlock()
+
+			responses = append(responses, packets.NewControlPacket(packets.Connack))
+		case *packets.PublishPacket:
+			if packet.Retain {
+				b.lock.Lock()
+				b.retained[packet.TopicName] = packet.Payload
+				b.lock.Unlock()
+			}
+
+			if packet.Qos > 0 {
+				puback := packets.NewControlPacket(packets.Puback).(*packets.PubackPacket)
+				puback.MessageID = packet.MessageID
+				responses = append(responses, puback)
+			}
+		case *packets.SubscribePacket:
+			suback := packets.NewControlPacket(packets.Suback).(*packets.SubackPacket)
+			suback.MessageID = packet.MessageID
+			suback.ReturnCodes = packet.Qoss
+			responses = append(responses, suback)
+			responses = append(responses, b.retainedMessages(packet.Topics)...)
+		case *packets.UnsubscribePacket:
+			unsuback := packets.NewControlPacket(packets.Unsuback).(*packets.UnsubackPacket)
+			unsuback.MessageID = packet.MessageID
+			responses = append(responses, unsuback)
+		case *packets.PingreqPacket:
+			responses = append(responses, packets.NewControlPacket(packets.Pingresp))
+		case *packets.DisconnectPacket:
+			return
+		}
+
+		for _, response := range responses {
+			if err := response.Write(conn); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// retainedMessages returns the retained messages of the given topics as QoS 0 publish packets.
+func (b *fakeBroker) retainedMessages(topics []string) []packets.ControlPacket {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var messages []packets.ControlPacket
+
+	for _, topic := range topics {
+		if payload, found := b.retained[topic]; found {
+			publish := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket)
+			publish.TopicName = topic
+			publish.Retain = true
+			publish.Payload = payload
+			messages = append(messages, publish)
+		}
+	}
+
+	return messages
+}
+
+func newTestMQTT(t *testing.T, brokerURL string, connectTimeout time.Duration) *MQTT {
+	t.Helper()
+
+	clientOptions := pahomqtt.NewClientOptions().
+		AddBroker(brokerURL).
+		SetClientID(clientIDPrefix + testLeafHubID).
+		SetProtocolVersion(protocolVersion311).
+		SetCleanSession(false).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(100 * time.Millisecond)
+
+	mqtt := newMQTT(clientOptions, testTopicPrefix, connectTimeout, transport.DefaultMessageQueueCapacity,
+		testDrainTimeout, logrtesting.NullLogger{})
+	mqtt.Start()
+
+	t.Cleanup(mqtt.Stop)
+
+	return mqtt
+}
+
+func setEnv(t *testing.T, envVars map[string]string) {
+	t.Helper()
+
+	for key, value := range envVars {
+		previousValue, found := os.LookupEnv(key)
+
+		if err := os.Setenv(key, value); err != nil {
+			t.Fatalf("failed to set %s: %v", key, err)
+		}
+
+		t.Cleanup(func() {
+			if found {
+				_ = os.Setenv(key, previousValue)
+			} else {
+				_ = os.Unsetenv(key)
+			}
+		})
+	}
+}
+
+func TestSendAndGetVersionRightAfterStart(t *testing.T) {
+	broker := newFakeBroker(t)
+
+	publisher := newTestMQTT(t, broker.url(), testTimeout)
+
+	if version := publisher.GetVersion(testID, testMsgType); version != "" {
+		t.Fatalf("expected no version before sending, got %q", version)
+	}
+
+	resultChan := make(chan error, 1)
+
+	publisher.SendAsync(&transport.Message{
+		ID:               testID,
+		MsgType:          testMsgType,
+		Version:          "3",
+		Payload:          []byte("payload"),
+		DeliveryCallback: func(err error) { resultChan <- err },
+	})
+
+	select {
+	case err := <-resultChan:
+		if err != nil {
+			t.Fatalf("failed to send the message: %v", err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for the delivery result")
+	}
+
+	// a new instance reads the version while it is still connecting, as the controllers do on startup
+	reader := newTestMQTT(t, broker.url(), testTimeout)
+
+	if version := reader.GetVersion(testID, testMsgType); version != "3" {
+		t.Fatalf("expected version 3, got %q", version)
+	}
+
+	broker.lock.Lock()
+	defer broker.lock.Unlock()
+
+	for _, clientID := range broker.clientIDs {
+		if clientID != clientIDPrefix+testLeafHubID {
+			t.Fatalf("unexpected client id %q", clientID)
+		}
+	}
+}
+
+func TestGetVersionGivesUpAfterConnectTimeout(t *testing.T) {
+	mqtt := newTestMQTT(t, testBrokerAddress, 200*time.Millisecond)
+
+	start := time.Now()
+
+	if version := mqtt.GetVersion(testID, testMsgType); version != "" {
+		t.Fatalf("expected no version without a broker, got %q", version)
+	}
+
+	if elapsed := time.Since(start); elapsed > testTimeout {
+		t.Fatalf("expected GetVersion to give up after the connect timeout, took %s", elapsed)
+	}
+}
+
+func TestClientIDIsDerivedFromLeafHubID(t *testing.T) {
+	setEnv(t, map[string]string{envVarMQTTBrokerURL: testBrokerAddress, envVarLeafHubID: testLeafHubID})
+
+	clientOptions, _, _, _, err := readEnvVars()
+	if err != nil {
+		t.Fatalf("failed to read the environment variables: %v", err)
+	}
+
+	if clientOptions.ClientID != clientIDPrefix+testLeafHubID {
+		t.Fatalf("expected client id %q, got %q", clientIDPrefix+testLeafHubID, clientOptions.ClientID)
+	}
+
+	setEnv(t, map[string]string{envVarMQTTClientID: "custom"})
+
+	if clientOptions, _, _, _, err = readEnvVars(); err != nil || clientOptions.ClientID != "custom" {
+		t.Fatalf("expected the configured client id, got %v", err)
+	}
+}
+
+func TestClientIDRequiresLeafHubID(t *testing.T) {
+	setEnv(t, map[string]string{envVarMQTTBrokerURL: testBrokerAddress, envVarLeafHubID: "", envVarMQTTClientID: ""})
+
+	if _, _, _, _, err := readEnvVars(); !errors.Is(err, errEnvVarNotFound) {
+		t.Fatalf("expected %v, got %v", errEnvVarNotFound, err)
+	}
+}
@@ -0,0 +1,596 @@
+package fakenats
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	natsgo "github.com/nats-io/nats.go"
+)
+
+const (
+	serverVersion = "2.10.0"
+	maxPayload    = 1024 * 1024
+
+	jetStreamAPIPrefix       = "$JS.API."
+	apiAccountInfo           = jetStreamAPIPrefix + "INFO"
+	apiStreamCreatePrefix    = jetStreamAPIPrefix + "STREAM.CREATE."
+	apiStreamInfoPrefix      = jetStreamAPIPrefix + "STREAM.INFO."
+	apiDirectGetPrefix       = jetStreamAPIPrefix + "DIRECT.GET."
+	errCodeStreamNotFound    = 10059
+	errCodeBadRequest        = 10003
+	errCodeWrongLastStream   = 10060
+	headerLine               = "NATS/1.0"
+	noRespondersStatusHeader = headerLine + " 503\r\n\r\n"
+	notFoundStatusHeader     = headerLine + " 404 Message Not Found\r\n\r\n"
+)
+
+var errProtocol = errors.New("protocol error")
+
+// Message is a message stored in a stream of the fake NATS server.
+type Message struct {
+	Subject  string
+	Header   natsgo.Header
+	Data     []byte
+	Sequence uint64
+	Time     time.Time
+}
+
+// Server is a fake NATS server that speaks the client protocol and serves the subset of JetStream used by the nats
+// transport, keeping the streams in memory: creating streams and getting their info, publishing to streams with
+// deduplication by message id, and getting the last message of a subject directly, which is how the key value store
+// of nats.go reads a key. tests can inspect the messages of the streams and disconnect the clients.
+type Server struct {
+	listener  net.Listener
+	clients   map[*client]struct{}
+	streams   map[string]*stream
+	lastSeq   map[string]uint64
+	waitGroup sync.WaitGroup
+	lock      sync.Mutex
+}
+
+type stream struct {
+	info       natsgo.StreamInfo
+	messages   []*Message
+	messageIDs map[string]uint64
+}
+
+// client is a connection of a client to the server.
+type client struct {
+	conn          net.Conn
+	reader        *bufio.Reader
+	writeLock     sync.Mutex
+	subscriptions map[string]string // sid -> subject
+}
+
+// NewServer creates and starts a new fake NATS server, listening on a local port.
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen - %w", err)
+	}
+
+	server := &Server{
+		listener: listener,
+		clients:  make(map[*client]struct{}),
+		streams:  make(map[string]*stream),
+		lastSeq:  make(map[string]uint64),
+	}
+
+	server.waitGroup.Add(1)
+
+	go server.accept()
+
+	return server, nil
+}
+
+// URL returns the url of the server.
+func (server *Server) URL() string {
+	return fmt.Sprintf("nats://%s", server.listener.Addr().String())
+}
+
+// Close shuts the server down and disconnects the clients.
+func (server *Server) Close() {
+	_ = server.listener.Close()
+	server.DisconnectClients()
+	server.waitGroup.Wait()
+}
+
+// DisconnectClients closes the connections of the connected clients, which then reconnect.
+func (server *Server) DisconnectClients() {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	for client := range server.clients {
+		_ = client.conn.Close()
+	}
+}
+
+// StreamConfig returns the configuration of the given stream and whether it exists.
+func (server *Server) StreamConfig(name string) (natsgo.StreamConfig, bool) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	stream, found := server.streams[name]
+	if !found {
+		return natsgo.StreamConfig{}, false
+	}
+
+	return stream.info.Config, true
+}
+
+// Messages returns copies of the messages the given stream keeps, in order.
+func (server *Server) Messages(streamName string) []Message {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	stream, found := server.streams[streamName]
+	if !found {
+		return nil
+	}
+
+	messages := make([]Message, 0, len(stream.messages))
+
+	for _, message := range stream.messages {
+		messageCopy := *message
+		messageCopy.Data = append([]byte{}, message.Data...)
+		messages = append(messages, messageCopy)
+	}
+
+	return messages
+}
+
+func (server *Server) accept() {
+	defer server.waitGroup.Done()
+
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil { // closed
+			return
+		}
+
+		client := &client{conn: conn, reader: bufio.NewReader(conn), subscriptions: make(map[string]string)}
+
+		server.lock.Lock()
+		server.clients[client] = struct{}{}
+		server.lock.Unlock()
+
+		server.waitGroup.Add(1)
+
+		go server.serve(client)
+	}
+}
+
+func (server *Server) serve(client *client) {
+	defer server.waitGroup.Done()
+	defer func() {
+		server.lock.Lock()
+		delete(server.clients, client)
+		server.lock.Unlock()
+
+		_ = client.conn.Close()
+	}()
+
+	info, _ := json.Marshal(map[string]interface{}{
+		"server_id":   "fakenats",
+		"server_name": "fakenats",
+		"version":     serverVersion,
+		"proto":       1,
+		"headers":     true,
+		"jetstream":   true,
+		"max_payload": maxPayload,
+	})
+
+	if err := client.write([]byte(fmt.Sprintf("INFO %s\r\n", info))); err != nil {
+		return
+	}
+
+	for {
+		if err := server.processCommand(client); err != nil {
+			return
+		}
+	}
+}
+
+// processCommand reads and processes a single protocol command of the client.
+func (server *Server) processCommand(client *client) error {
+	line, err := client.reader.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read command - %w", err)
+	}
+
+	fields := strings.Fields(strings.TrimSpace(line))
+	if len(fields) == 0 {
+		return nil
+	}
+
+	switch strings.ToUpper(fields[0]) {
+	case "CONNECT", "PONG":
+		return nil
+	case "PING":
+		return client.write([]byte("PONG\r\n"))
+	case "SUB": // SUB <subject> [queue group] <sid>
+		if len(fields) < 3 {
+			return errProtocol
+		}
+
+		server.lock.Lock()
+		client.subscriptions[fields[len(fields)-1]] = fields[1]
+		server.lock.Unlock()
+
+		return nil
+	case "UNSUB": // UNSUB <sid> [max msgs], max msgs is ignored
+		if len(fields) < 2 {
+			return errProtocol
+		}
+
+		server.lock.Lock()
+		delete(client.subscriptions, fields[1])
+		server.lock.Unlock()
+
+		return nil
+	case "PUB": // PUB <subject> [reply] <size>
+		if len(fields) < 3 {
+			return errProtocol
+		}
+
+		return server.readPublish(client, fields[1:len(fields)-1], "", fields[len(fields)-1])
+	case "HPUB": // HPUB <subject> [reply] <header size> <total size>
+		if len(fields) < 4 {
+			return errProtocol
+		}
+
+		return server.readPublish(client, fields[1:len(fields)-2], fields[len(fields)-2], fields[len(fields)-1])
+	default:
+		return fmt.Errorf("%w: unknown command %s", errProtocol, fields[0])
+	}
+}
+
+func (server *Server) readPublish(client *client, subjectAndReply []string, headerSizeStr string,
+	totalSizeStr string) error {
+	if len(subjectAndReply) == 0 || len(subjectAndReply) > 2 {
+		return errProtocol
+	}
+
+	subject, reply := subjectAndReply[0], ""
+	if len(subjectAndReply) == 2 {
+		reply = subjectAndReply[1]
+	}
+
+	totalSize, err := strconv.Atoi(totalSizeStr)
+	if err != nil {
+		return errProtocol
+	}
+
+	headerSize := 0
+	if headerSizeStr != "" {
+		if headerSize, err = strconv.Atoi(headerSizeStr); err != nil || headerSize > totalSize {
+			return errProtocol
+		}
+	}
+
+	payload := make([]byte, totalSize+2) // with the trailing \r\n
+	if _, err := io.ReadFull(client.reader, payload); err != nil {
+		return fmt.Errorf("failed to read payload - %w", err)
+	}
+
+	server.publish(subject, reply, payload[:headerSize], payload[headerSize:totalSize])
+
+	return nil
+}
+
+// publish handles a message published by a client: JetStream API requests are answered, messages to the subjects of
+// a stream are stored and acknowledged, and all messages are delivered to the matching subscriptions.
+func (server *Server) publish(subject string, reply string, header []byte, data []byte) {
+	handled := false
+
+	if strings.HasPrefix(subject, jetStreamAPIPrefix) {
+		handled = true
+
+		server.handleAPIRequest(subject, reply, data)
+	} else if pubAck, found := server.storeMessage(subject, header, data); found {
+		handled = true
+
+		if reply != "" {
+			server.deliver(reply, "", nil, pubAck)
+		}
+	}
+
+	if !server.deliver(subject, reply, header, data) && !handled && reply != "" {
+		server.deliver(reply, "", []byte(noRespondersStatusHeader), nil)
+	}
+}
+
+// deliver sends a message to the subscriptions that match the subject, returns false if there were none.
+func (server *Server) deliver(subject string, reply string, header []byte, data []byte) bool {
+	type delivery struct {
+		client *client
+		sid    string
+	}
+
+	var deliveries []delivery
+
+	server.lock.Lock()
+	for client := range server.clients {
+		for sid, pattern := range client.subscriptions {
+			if subjectMatches(pattern, subject) {
+				deliveries = append(deliveries, delivery{client: client, sid: sid})
+			}
+		}
+	}
+	server.lock.Unlock()
+
+	for _, delivery := range deliveries {
+		var message bytes.Buffer
+
+		if header == nil {
+			fmt.Fprintf(&message, "MSG %s %s %s%d\r\n", subject, delivery.sid, replyField(reply), len(data))
+		} else {
+			fmt.Fprintf(&message, "HMSG %s %s %s%d %d\r\n", subject, delivery.sid, replyField(reply), len(header),
+				len(header)+len(data))
+			message.Write(header)
+		}
+
+		message.Write(data)
+		message.WriteString("\r\n")
+
+		_ = delivery.client.write(message.Bytes())
+	}
+
+	return len(deliveries) > 0
+}
+
+func replyField(reply string) string {
+	if reply == "" {
+		return ""
+	}
+
+	return reply + " "
+}
+
+// storeMessage stores a message in the stream whose subjects match the subject. returns the publish acknowledgement
+// and false if no stream matches.
+func (server *Server) storeMessage(subject string, header []byte, data []byte) ([]byte, bool) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	stream := server.streamOfSubject(subject)
+	if stream == nil {
+		return nil, false
+	}
+
+	streamName := stream.info.Config.Name
+
+	var messageHeader natsgo.Header
+
+	if len(header) > 0 {
+		decodedHeader, err := natsgo.DecodeHeadersMsg(header)
+		if err != nil {
+			return apiErrorResponse(400, errCodeBadRequest, "invalid message headers"), true
+		}
+
+		messageHeader = decodedHeader
+	}
+
+	if expectedStream := messageHeader.Get(natsgo.ExpectedStreamHdr); expectedStream != "" &&
+		expectedStream != streamName {
+		return apiErrorResponse(400, errCodeWrongLastStream, "expected stream does not match"), true
+	}
+
+	messageID := messageHeader.Get(natsgo.MsgIdHdr)
+	if sequence, found := stream.messageIDs[messageID]; messageID != "" && found {
+		return marshal(&natsgo.PubAck{Stream: streamName, Sequence: sequence, Duplicate: true}), true
+	}
+
+	server.lastSeq[streamName]++
+	sequence := server.lastSeq[streamName]
+
+	stream.messages = append(stream.messages, &Message{
+		Subject:  subject,
+		Header:   messageHeader,
+		Data:     append([]byte{}, data...),
+		Sequence: sequence,
+		Time:     time.Now().UTC(),
+	})
+
+	if messageID != "" {
+		stream.messageIDs[messageID] = sequence
+	}
+
+	stream.applyMaxMsgsPerSubject(subject)
+
+	return marshal(&natsgo.PubAck{Stream: streamName, Sequence: sequence}), true
+}
+
+// applyMaxMsgsPerSubject removes the oldest messages of the subject beyond the limit of the stream.
+func (stream *stream) applyMaxMsgsPerSubject(subject string) {
+	limit := stream.info.Config.MaxMsgsPerSubject
+	if limit <= 0 {
+		return
+	}
+
+	count := int64(0)
+
+	for _, message := range stream.messages {
+		if message.Subject == subject {
+			count++
+		}
+	}
+
+	kept := make([]*Message, 0, len(stream.messages))
+
+	for _, message := range stream.messages {
+		if message.Subject == subject && count > limit {
+			count--
+			continue
+		}
+
+		kept = append(kept, message)
+	}
+
+	stream.messages = kept
+}
+
+func (server *Server) streamOfSubject(subject string) *stream {
+	for _, stream := range server.streams {
+		for _, pattern := range stream.info.Config.Subjects {
+			if subjectMatches(pattern, subject) {
+				return stream
+			}
+		}
+	}
+
+	return nil
+}
+
+func (server *Server) handleAPIRequest(subject string, reply string, data []byte) {
+	if reply == "" {
+		return
+	}
+
+	switch {
+	case subject == apiAccountInfo:
+		server.deliver(reply, "", nil, marshal(&natsgo.AccountInfo{}))
+	case strings.HasPrefix(subject, apiStreamCreatePrefix):
+		server.deliver(reply, "", nil, server.createStream(strings.TrimPrefix(subject, apiStreamCreatePrefix), data))
+	case strings.HasPrefix(subject, apiStreamInfoPrefix):
+		server.deliver(reply, "", nil, server.streamInfo(strings.TrimPrefix(subject, apiStreamInfoPrefix)))
+	case strings.HasPrefix(subject, apiDirectGetPrefix):
+		header, data := server.directGetLastBySubject(strings.TrimPrefix(subject, apiDirectGetPrefix))
+		server.deliver(reply, "", header, data)
+	default:
+		server.deliver(reply, "", nil, apiErrorResponse(400, errCodeBadRequest, "unsupported api "+subject))
+	}
+}
+
+// createStream creates the stream, or returns the info of the stream if it already exists.
+func (server *Server) createStream(name string, request []byte) []byte {
+	var config natsgo.StreamConfig
+	if err := json.Unmarshal(request, &config); err != nil || config.Name != name {
+		return apiErrorResponse(400, errCodeBadRequest, "invalid stream config")
+	}
+
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	existingStream, found := server.streams[name]
+	if found {
+		return marshal(&existingStream.info)
+	}
+
+	newStream := &stream{
+		info:       natsgo.StreamInfo{Config: config, Created: time.Now().UTC()},
+		messageIDs: make(map[string]uint64),
+	}
+	server.streams[name] = newStream
+
+	return marshal(&newStream.info)
+}
+
+func (server *Server) streamInfo(name string) []byte {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	stream, found := server.streams[name]
+	if !found {
+		return apiErrorResponse(404, errCodeStreamNotFound, "stream not found")
+	}
+
+	info := stream.info
+	info.State.Msgs = uint64(len(stream.messages))
+	info.State.LastSeq = server.lastSeq[name]
+
+	return marshal(&info)
+}
+
+// directGetLastBySubject answers a direct get of the last message of a subject, <stream>.<subject>, with the message
+// as the reply, or with a not found status.
+func (server *Server) directGetLastBySubject(streamAndSubject string) ([]byte, []byte) {
+	separator := strings.Index(streamAndSubject, ".")
+	if separator < 0 {
+		return []byte(notFoundStatusHeader), nil
+	}
+
+	streamName, subject := streamAndSubject[:separator], streamAndSubject[separator+1:]
+
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	stream, found := server.streams[streamName]
+	if !found {
+		return []byte(notFoundStatusHeader), nil
+	}
+
+	for i := len(stream.messages) - 1; i >= 0; i-- {
+		message := stream.messages[i]
+		if message.Subject != subject {
+			continue
+		}
+
+		var header bytes.Buffer
+
+		header.WriteString(headerLine + "\r\n")
+
+		for key, values := range message.Header {
+			for _, value := range values {
+				fmt.Fprintf(&header, "%s: %s\r\n", key, value)
+			}
+		}
+
+		fmt.Fprintf(&header, "%s: %s\r\n%s: %s\r\n%s: %d\r\n%s: %s\r\n\r\n", natsgo.JSStream, streamName,
+			natsgo.JSSubject, subject, natsgo.JSSequence, message.Sequence, natsgo.JSTimeStamp,
+			message.Time.Format(time.RFC3339Nano))
+
+		return header.Bytes(), append([]byte{}, message.Data...)
+	}
+
+	return []byte(notFoundStatusHeader), nil
+}
+
+func (client *client) write(data []byte) error {
+	client.writeLock.Lock()
+	defer client.writeLock.Unlock()
+
+	if _, err := client.conn.Write(data); err != nil {
+		return fmt.Errorf("failed to write - %w", err)
+	}
+
+	return nil
+}
+
+// subjectMatches returns true if the subject matches the pattern, which may hold * and > wildcards.
+func subjectMatches(pattern string, subject string) bool {
+	patternTokens := strings.Split(pattern, ".")
+	subjectTokens := strings.Split(subject, ".")
+
+	for i, patternToken := range patternTokens {
+		if patternToken == ">" {
+			return len(subjectTokens) > i
+		}
+
+		if i >= len(subjectTokens) || (patternToken != "*" && patternToken != subjectTokens[i]) {
+			return false
+		}
+	}
+
+	return len(patternTokens) == len(subjectTokens)
+}
+
+func apiErrorResponse(code int, errorCode int, description string) []byte {
+	return marshal(map[string]*natsgo.APIError{
+		"error": {Code: code, ErrorCode: natsgo.ErrorCode(errorCode), Description: description},
+	})
+}
+
+func marshal(value interface{}) []byte {
+	data, _ := json.Marshal(value)
+	return data
+}
@@ -0,0 +1,448 @@
+package nats
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+)
+
+const (
+	envVarNATSURL             = "NATS_URL"
+	envVarNATSStream          = "NATS_STREAM"
+	envVarNATSSubjectPrefix   = "NATS_SUBJECT_PREFIX"
+	envVarNATSKVBucket        = "NATS_KV_BUCKET"
+	envVarNATSCredsPath       = "NATS_CREDS_PATH"
+	envVarNATSCACertPath      = "NATS_CA_CERT_PATH"
+	envVarNATSClientCertPath  = "NATS_CLIENT_CERT_PATH"
+	envVarNATSClientKeyPath   = "NATS_CLIENT_KEY_PATH"
+	envVarNATSDuplicateWindow = "NATS_DUPLICATE_WINDOW"
+	envVarNATSDrainTimeout    = "NATS_DRAIN_TIMEOUT"
+	envVarLeafHubID           = "LH_ID"
+
+	defaultStream        = "LEAF_HUB_STATUS"
+	defaultSubjectPrefix = "leaf-hub-status"
+	defaultKVBucket      = "leaf-hub-status-versions"
+
+	msgTypeHeader = "type"
+	versionHeader = "version"
+
+	connectTimeout      = 10 * time.Second
+	requestTimeout      = 10 * time.Second
+	reconnectWait       = 2 * time.Second
+	defaultDrainTimeout = 10 * time.Second
+
+	defaultDuplicateWindow = 2 * time.Minute
+)
+
+var (
+	errEnvVarNotFound     = errors.New("not found environment variable")
+	errEnvVarWrongType    = errors.New("wrong type of environment variable")
+	errEnvVarIllegalValue = errors.New("illegal value of environment variable")
+	errNATSStopped        = errors.New("nats was stopped")
+	errNotConnected       = errors.New("not connected to the nats server")
+)
+
+// TransportType is the transport type the NATS JetStream transport is registered under.
+const TransportType = "nats"
+
+func init() {
+	transport.Register(TransportType, func(log logr.Logger) (transport.Service, error) {
+		return NewNATS(log)
+	})
+}
+
+// NATS abstracts a NATS JetStream publisher. each bundle is published to <subject prefix>.<leaf hub id>.<msgType>.<id>
+// with the message id <id>.<version>, so the stream drops duplicates of a version within the duplicate window.
+// the version of the last bundle sent per key is kept in a key value bucket that backs GetVersion.
+type NATS struct {
+	url             string
+	options         []natsgo.Option
+	stream          string
+	subjectPrefix   string
+	kvBucketName    string
+	duplicateWindow time.Duration
+	conn            *natsgo.Conn
+	js              natsgo.JetStreamContext
+	kvBucket        natsgo.KeyValue
+	setupLock       sync.Mutex
+	connectedChan   chan struct{}
+	connectedOnce   sync.Once
+	queue           *transport.MessageQueue
+	drainTimeout    time.Duration
+	drainChan       chan struct{}
+	doneChan        chan struct{}
+	stopChan        chan struct{}
+	startOnce       sync.Once
+	stopOnce        sync.Once
+	log             logr.Logger
+}
+
+// NewNATS creates a new instance of NATS.
+func NewNATS(log logr.Logger) (*NATS, error) {
+	nats, err := readEnvVars()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize nats - %w", err)
+	}
+
+	queueCapacity, err := transport.ReadMessageQueueCapacity()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize nats - %w", err)
+	}
+
+	nats.queue = transport.NewMessageQueue("nats", queueCapacity)
+	nats.drainChan = make(chan struct{})
+	nats.doneChan = make(chan struct{})
+	nats.stopChan = make(chan struct{}, 1)
+	nats.connectedChan = make(chan struct{})
+	nats.log = log
+
+	nats.options = append(nats.options,
+		natsgo.Timeout(connectTimeout),
+		natsgo.RetryOnFailedConnect(true),
+		natsgo.MaxReconnects(-1), // reconnect forever
+		natsgo.ReconnectWait(reconnectWait),
+		natsgo.ReconnectHandler(func(*natsgo.Conn) { // also invoked when a retried initial connect succeeds
+			nats.connectedOnce.Do(func() { close(nats.connectedChan) })
+		}),
+		natsgo.DisconnectErrHandler(func(_ *natsgo.Conn, err error) {
+			if err != nil {
+				log.Error(err, "Lost connection to the nats server, reconnecting")
+			}
+		}))
+
+	return nats, nil
+}
+
+func readEnvVars() (*NATS, error) {
+	url := os.Getenv(envVarNATSURL)
+	if url == "" {
+		return nil, fmt.Errorf("%w: %s", errEnvVarNotFound, envVarNATSURL)
+	}
+
+	leafHubID := os.Getenv(envVarLeafHubID)
+	if leafHubID == "" {
+		return nil, fmt.Errorf("%w: %s", errEnvVarNotFound, envVarLeafHubID)
+	}
+
+	subjectPrefix := strings.TrimSuffix(os.Getenv(envVarNATSSubjectPrefix), ".")
+	if subjectPrefix == "" {
+		subjectPrefix = defaultSubjectPrefix
+	}
+
+	drainTimeout, err := readDurationEnvVar(envVarNATSDrainTimeout, defaultDrainTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	duplicateWindow, err := readDurationEnvVar(envVarNATSDuplicateWindow, defaultDuplicateWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := readSecurityEnvVars()
+	if err != nil {
+		return nil, err
+	}
+
+	return &NATS{
+		url:             url,
+		options:         append(options, natsgo.Name(fmt.Sprintf("leaf-hub-status-sync-%s", leafHubID))),
+		stream:          getEnvOrDefault(envVarNATSStream, defaultStream),
+		subjectPrefix:   fmt.Sprintf("%s.%s", subjectPrefix, subjectToken(leafHubID)),
+		kvBucketName:    getEnvOrDefault(envVarNATSKVBucket, defaultKVBucket),
+		duplicateWindow: duplicateWindow,
+		drainTimeout:    drainTimeout,
+	}, nil
+}
+
+func readSecurityEnvVars() ([]natsgo.Option, error) {
+	var options []natsgo.Option
+
+	if credsPath := os.Getenv(envVarNATSCredsPath); credsPath != "" {
+		options = append(options, natsgo.UserCredentials(credsPath))
+	}
+
+	if caCertPath := os.Getenv(envVarNATSCACertPath); caCertPath != "" {
+		options = append(options, natsgo.RootCAs(caCertPath))
+	}
+
+	clientCertPath := os.Getenv(envVarNATSClientCertPath)
+	clientKeyPath := os.Getenv(envVarNATSClientKeyPath)
+
+	if (clientCertPath == "") != (clientKeyPath == "") {
+		return nil, fmt.Errorf("%w: %s and %s must be set together", errEnvVarIllegalValue,
+			envVarNATSClientCertPath, envVarNATSClientKeyPath)
+	}
+
+	if clientCertPath != "" {
+		options = append(options, natsgo.ClientCert(clientCertPath, clientKeyPath))
+	}
+
+	return options, nil
+}
+
+func readDurationEnvVar(envVar string, defaultValue time.Duration) (time.Duration, error) {
+	valueStr := os.Getenv(envVar)
+	if valueStr == "" {
+		return defaultValue, nil
+	}
+
+	value, err := time.ParseDuration(valueStr)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s must be a duration", errEnvVarWrongType, envVar)
+	}
+
+	return value, nil
+}
+
+func getEnvOrDefault(envVar string, defaultValue string) string {
+	if value := os.Getenv(envVar); value != "" {
+		return value
+	}
+
+	return defaultValue
+}
+
+// Start function starts nats. the connection to the server is retried in the background until it succeeds.
+func (n *NATS) Start() {
+	n.startOnce.Do(func() {
+		conn, err := natsgo.Connect(n.url, n.options...)
+		if err != nil { // only returned for invalid options, connection failures are retried
+			n.log.Error(err, "Failed to connect to the nats server")
+		}
+
+		n.setupLock.Lock()
+		n.conn = conn
+		n.setupLock.Unlock()
+
+		if conn != nil && conn.IsConnected() {
+			n.connectedOnce.Do(func() { close(n.connectedChan) })
+		}
+
+		go n.sendMessages()
+	})
+}
+
+// Stop function stops nats. messages that are waiting in the queue are sent until the drain timeout expires.
+func (n *NATS) Stop() {
+	n.stopOnce.Do(func() {
+		n.queue.Close() // messages that are sent from now on are reported as failed
+		close(n.drainChan)
+
+		if transport.WasStarted(&n.startOnce) {
+			select {
+			case <-n.doneChan:
+				n.log.Info("all messages were sent")
+			case <-time.After(n.drainTimeout):
+				n.log.Info(fmt.Sprintf("drain timeout expired, %d messages were not sent", n.queue.Len()))
+			}
+		}
+
+		close(n.stopChan)
+
+		n.setupLock.Lock()
+		defer n.setupLock.Unlock()
+
+		if n.conn != nil {
+			n.conn.Close()
+		}
+	})
+}
+
+// SendAsync function publishes a message to the stream asynchronously.
+func (n *NATS) SendAsync(message *transport.Message) {
+	n.queue.Push(message)
+}
+
+// GetVersion returns the version of the last message sent with the given id and type, as kept in the key value
+// bucket. if the bucket has no such key or an error occurred returns an empty string.
+func (n *NATS) GetVersion(id string, msgType string) string {
+	_, kvBucket, err := n.setup()
+	if err != nil {
+		n.log.Error(err, "Failed to read the version from nats", "id", id, "type", msgType)
+		return ""
+	}
+
+	entry, err := kvBucket.Get(kvKey(id, msgType))
+	if errors.Is(err, natsgo.ErrKeyNotFound) {
+		return ""
+	} else if err != nil {
+		n.log.Error(err, "Failed to read the version from nats", "id", id, "type", msgType)
+		return ""
+	}
+
+	return string(entry.Value())
+}
+
+// Subscribe function does nothing, receiving commands is not supported by the nats transport.
+func (n *NATS) Subscribe(string, transport.CommandHandler) {}
+
+func (n *NATS) sendMessages() {
+	defer close(n.doneChan)
+
+	for {
+		msg := n.queue.Pop(n.drainChan)
+		if msg == nil { // drained
+			return
+		}
+
+		select {
+		case <-n.stopChan: // drain timeout expired
+			msg.ReportDeliveryResult(errNATSStopped)
+			return
+		default:
+		}
+
+		msg.ReportDeliveryResult(n.sendMessage(msg))
+	}
+}
+
+func (n *NATS) sendMessage(msg *transport.Message) error {
+	js, kvBucket, err := n.setup()
+	if err != nil {
+		n.log.Error(err, "Failed to set up nats jetstream")
+		return fmt.Errorf("failed to set up nats jetstream - %w", err)
+	}
+
+	natsMsg := natsgo.NewMsg(n.subject(msg.ID, msg.MsgType))
+	natsMsg.Data = msg.Payload
+	natsMsg.Header.Set(msgTypeHeader, msg.MsgType)
+	natsMsg.Header.Set(versionHeader, msg.Version)
+
+	for key, value := range msg.Metadata {
+		natsMsg.Header.Set(key, value)
+	}
+
+	pubAck, err := js.PublishMsg(natsMsg, natsgo.MsgId(fmt.Sprintf("%s.%s", msg.ID, msg.Version)),
+		natsgo.ExpectStream(n.stream))
+	if err != nil {
+		n.log.Error(err, "Failed to publish the message to nats jetstream")
+		return fmt.Errorf("failed to publish the message to nats jetstream - %w", err)
+	}
+
+	// the version is stored after the bundle, a failure here resends the bundle which the stream then drops as duplicate
+	if _, err := kvBucket.PutString(kvKey(msg.ID, msg.MsgType), msg.Version); err != nil {
+		n.log.Error(err, "Failed to store the version in nats")
+		return fmt.Errorf("failed to store the version in nats - %w", err)
+	}
+
+	if pubAck.Duplicate {
+		n.log.Info(fmt.Sprintf("Message '%s' from type '%s' with version '%s' was already sent", msg.ID, msg.MsgType,
+			msg.Version))
+	} else {
+		n.log.Info(fmt.Sprintf("Message '%s' from type '%s' with version '%s' sent", msg.ID, msg.MsgType, msg.Version))
+	}
+
+	return nil
+}
+
+// setup creates the JetStream context, the stream and the key value bucket once the connection is established,
+// waiting up to the connect timeout for the first connection. failures are retried on the next call.
+func (n *NATS) setup() (natsgo.JetStreamContext, natsgo.KeyValue, error) {
+	select {
+	case <-n.connectedChan:
+	case <-time.After(connectTimeout):
+		return nil, nil, errNotConnected
+	}
+
+	n.setupLock.Lock()
+	defer n.setupLock.Unlock()
+
+	if n.kvBucket != nil {
+		return n.js, n.kvBucket, nil
+	}
+
+	js, err := n.conn.JetStream(natsgo.MaxWait(requestTimeout))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create jetstream context - %w", err)
+	}
+
+	if err := n.ensureStream(js); err != nil {
+		return nil, nil, err
+	}
+
+	kvBucket, err := ensureKVBucket(js, n.kvBucketName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	n.js = js
+	n.kvBucket = kvBucket
+
+	return n.js, n.kvBucket, nil
+}
+
+// ensureStream creates the file backed stream the bundles are published to, unless it already exists. an existing
+// stream is left as is.
+func (n *NATS) ensureStream(js natsgo.JetStreamContext) error {
+	if _, err := js.StreamInfo(n.stream); err == nil {
+		return nil
+	} else if !errors.Is(err, natsgo.ErrStreamNotFound) {
+		return fmt.Errorf("failed to get info of stream %s - %w", n.stream, err)
+	}
+
+	if _, err := js.AddStream(&natsgo.StreamConfig{
+		Name:              n.stream,
+		Subjects:          []string{n.subjectPrefix + ".>"},
+		Retention:         natsgo.LimitsPolicy,
+		MaxConsumers:      -1,
+		MaxMsgs:           -1,
+		MaxBytes:          -1,
+		MaxMsgsPerSubject: -1,
+		Discard:           natsgo.DiscardOld,
+		Storage:           natsgo.FileStorage,
+		Replicas:          1,
+		Duplicates:        n.duplicateWindow,
+	}); err != nil {
+		return fmt.Errorf("failed to create stream %s - %w", n.stream, err)
+	}
+
+	return nil
+}
+
+// ensureKVBucket returns the key value bucket, creating it with a history of one value per key unless it already
+// exists. the bucket is a regular nats key value store, so it can be inspected and managed with the nats cli.
+func ensureKVBucket(js natsgo.JetStreamContext, name string) (natsgo.KeyValue, error) {
+	kvBucket, err := js.KeyValue(name)
+	if err == nil {
+		return kvBucket, nil
+	} else if !errors.Is(err, natsgo.ErrBucketNotFound) {
+		return nil, fmt.Errorf("failed to get key value bucket %s - %w", name, err)
+	}
+
+	kvBucket, err = js.CreateKeyValue(&natsgo.KeyValueConfig{Bucket: name, History: 1, Storage: natsgo.FileStorage})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create key value bucket %s - %w", name, err)
+	}
+
+	return kvBucket, nil
+}
+
+// subject returns the subject of the given id and type, <subject prefix>.<leaf hub id>.<msgType>.<id>.
+func (n *NATS) subject(id string, msgType string) string {
+	return fmt.Sprintf("%s.%s.%s", n.subjectPrefix, subjectToken(msgType), subjectToken(id))
+}
+
+// kvKey returns the key value bucket key of the given id and type, <msgType>.<id>.
+func kvKey(id string, msgType string) string {
+	return fmt.Sprintf("%s.%s", subjectToken(msgType), subjectToken(id))
+}
+
+// subjectToken replaces the characters that can't be used within a single subject token or a key.
+func subjectToken(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, value)
+}
@@ -0,0 +1,18 @@
+package nats
+
+import (
+	"testing"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/conformance"
+)
+
+func TestConformance(t *testing.T) {
+	newTestServer(t)
+
+	conformance.Run(t, func() (transport.Transport, error) {
+		return NewNATS(logrtesting.NullLogger{})
+	}, &conformance.Options{DeliveryTimeout: 10 * time.Second})
+}
@@ -0,0 +1,191 @@
+package nats
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/nats/fakenats"
+)
+
+const (
+	testLeafHubID = "hub1"
+	testMsgType   = "StatusBundle"
+	testID        = "hub1.policies"
+	testTimeout   = 5 * time.Second
+)
+
+func setEnvVar(t *testing.T, name string, value string) {
+	t.Helper()
+
+	if err := os.Setenv(name, value); err != nil {
+		t.Fatalf("failed to set %s: %v", name, err)
+	}
+
+	t.Cleanup(func() { _ = os.Unsetenv(name) })
+}
+
+func newTestServer(t *testing.T) *fakenats.Server {
+	t.Helper()
+
+	server, err := fakenats.NewServer()
+	if err != nil {
+		t.Fatalf("failed to start the fake nats server: %v", err)
+	}
+
+	t.Cleanup(server.Close)
+
+	setEnvVar(t, envVarNATSURL, server.URL())
+	setEnvVar(t, envVarLeafHubID, testLeafHubID)
+
+	return server
+}
+
+func newTestNATS(t *testing.T) *NATS {
+	t.Helper()
+
+	nats, err := NewNATS(logrtesting.NullLogger{})
+	if err != nil {
+		t.Fatalf("failed to create nats: %v", err)
+	}
+
+	nats.Start()
+	t.Cleanup(nats.Stop)
+
+	return nats
+}
+
+func send(t *testing.T, nats *NATS, version string, metadata map[string]string) error {
+	t.Helper()
+
+	resultChan := make(chan error, 1)
+
+	nats.SendAsync(&transport.Message{
+		ID:               testID,
+		MsgType:          testMsgType,
+		Version:          version,
+		Metadata:         metadata,
+		Payload:          []byte("payload-" + version),
+		DeliveryCallback: func(err error) { resultChan <- err },
+	})
+
+	select {
+	case err := <-resultChan:
+		return err
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for the delivery result")
+		return nil
+	}
+}
+
+func TestSendAsyncPublishesTheBundleToTheStream(t *testing.T) {
+	server := newTestServer(t)
+	nats := newTestNATS(t)
+
+	if err := send(t, nats, "1", map[string]string{"codec": "json"}); err != nil {
+		t.Fatalf("failed to send the bundle: %v", err)
+	}
+
+	messages := server.Messages(defaultStream)
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message in the stream, got %d", len(messages))
+	}
+
+	message := messages[0]
+
+	if expectedSubject := "leaf-hub-status.hub1.StatusBundle.hub1_policies"; message.Subject != expectedSubject {
+		t.Fatalf("expected the subject %s, got %s", expectedSubject, message.Subject)
+	}
+
+	for header, expected := range map[string]string{
+		msgTypeHeader: testMsgType,
+		versionHeader: "1",
+		"codec":       "json",
+		"Nats-Msg-Id": testID + ".1",
+	} {
+		if actual := message.Header.Get(header); actual != expected {
+			t.Fatalf("expected header %s to be %q, got %q", header, expected, actual)
+		}
+	}
+
+	if string(message.Data) != "payload-1" {
+		t.Fatalf("expected the payload to be published, got %q", message.Data)
+	}
+}
+
+func TestResentVersionIsDroppedAsDuplicate(t *testing.T) {
+	server := newTestServer(t)
+	nats := newTestNATS(t)
+
+	for i := 0; i < 2; i++ {
+		if err := send(t, nats, "1", nil); err != nil {
+			t.Fatalf("failed to send the bundle: %v", err)
+		}
+	}
+
+	if messages := server.Messages(defaultStream); len(messages) != 1 {
+		t.Fatalf("expected the resent version to be dropped, got %d messages", len(messages))
+	}
+}
+
+func TestVersionIsKeptInTheKeyValueBucket(t *testing.T) {
+	server := newTestServer(t)
+	nats := newTestNATS(t)
+
+	if version := nats.GetVersion(testID, testMsgType); version != "" {
+		t.Fatalf("expected no version before the bundle was sent, got %q", version)
+	}
+
+	for _, version := range []string{"1", "2"} {
+		if err := send(t, nats, version, nil); err != nil {
+			t.Fatalf("failed to send the bundle: %v", err)
+		}
+	}
+
+	config, found := server.StreamConfig("KV_" + defaultKVBucket)
+	if !found || config.MaxMsgsPerSubject != 1 {
+		t.Fatalf("expected a key value bucket that keeps one version per key, got %+v", config)
+	}
+
+	if messages := server.Messages("KV_" + defaultKVBucket); len(messages) != 1 ||
+		string(messages[0].Data) != "2" {
+		t.Fatalf("expected only the last version in the bucket, got %v", messages)
+	}
+
+	// a new instance, e.g. after a restart, reads the version from the bucket
+	if version := newTestNATS(t).GetVersion(testID, testMsgType); version != "2" {
+		t.Fatalf("expected version 2, got %q", version)
+	}
+}
+
+func TestSendAsyncAfterReconnect(t *testing.T) {
+	server := newTestServer(t)
+	nats := newTestNATS(t)
+
+	if err := send(t, nats, "1", nil); err != nil {
+		t.Fatalf("failed to send the bundle: %v", err)
+	}
+
+	server.DisconnectClients()
+
+	// a message that is published before the client notices the lost connection is lost and reported as failed when
+	// the publish times out, so the bundle is sent once the client reconnected
+	deadline := time.Now().Add(testTimeout)
+	for nats.conn.Stats().Reconnects == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the client to reconnect")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := send(t, nats, "2", nil); err != nil {
+		t.Fatalf("failed to send the bundle after reconnecting: %v", err)
+	}
+
+	if version := nats.GetVersion(testID, testMsgType); version != "2" {
+		t.Fatalf("expected version 2, got %q", version)
+	}
+}
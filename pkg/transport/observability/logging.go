@@ -0,0 +1,40 @@
+package observability
+
+import (
+	"github.com/go-logr/logr"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+)
+
+// LoggingMiddlewareName is the name the logging middleware is registered under.
+const LoggingMiddlewareName = "logging"
+
+func init() {
+	transport.RegisterMiddleware(LoggingMiddlewareName, func(next transport.Transport, log logr.Logger) (
+		transport.Transport, error) {
+		return transport.NewSendFuncMiddleware(func(message *transport.Message) error {
+			logSend(log, message)
+			return nil
+		})(next, log)
+	})
+}
+
+// logSend logs the message as it is passed on and the delivery result once the delivery completes.
+func logSend(log logr.Logger, message *transport.Message) {
+	id, msgType, version := message.ID, message.MsgType, message.Version
+
+	log.V(1).Info("sending message", "id", id, "type", msgType, "version", version,
+		"size", len(message.Payload))
+
+	callback := message.DeliveryCallback
+	message.DeliveryCallback = func(err error) {
+		if err != nil {
+			log.Error(err, "failed to deliver message", "id", id, "type", msgType, "version", version)
+		} else {
+			log.V(1).Info("message delivered", "id", id, "type", msgType, "version", version)
+		}
+
+		if callback != nil {
+			callback(err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package observability
+
+import (
+	"github.com/go-logr/logr"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// MetricsMiddlewareName is the name the metrics middleware is registered under.
+	MetricsMiddlewareName = "metrics"
+	resultSuccess         = "success"
+	resultFailure         = "failure"
+)
+
+var (
+	messagesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "leaf_hub_status_sync_transport_messages_total",
+		Help: "Number of messages handed to the transport, by message type and delivery result.",
+	}, []string{"type", "result"})
+	payloadBytesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "leaf_hub_status_sync_transport_payload_bytes_total",
+		Help: "Number of payload bytes handed to the transport, by message type.",
+	}, []string{"type"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(messagesCounter, payloadBytesCounter)
+
+	transport.RegisterMiddleware(MetricsMiddlewareName, func(next transport.Transport, log logr.Logger) (
+		transport.Transport, error) {
+		return transport.NewSendFuncMiddleware(countSend)(next, log)
+	})
+}
+
+// countSend counts the message and its payload size, and the delivery result once the delivery completes.
+func countSend(message *transport.Message) error {
+	msgType := message.MsgType
+
+	payloadBytesCounter.WithLabelValues(msgType).Add(float64(len(message.Payload)))
+
+	callback := message.DeliveryCallback
+	message.DeliveryCallback = func(err error) {
+		result := resultSuccess
+		if err != nil {
+			result = resultFailure
+		}
+
+		messagesCounter.WithLabelValues(msgType, result).Inc()
+
+		if callback != nil {
+			callback(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+)
+
+var (
+	errUnsupportedTransportType = errors.New("unsupported transport type")
+
+	registryLock sync.RWMutex
+	registry     = make(map[string]Factory)
+)
+
+// Service is a transport that has to be started before use and stopped on shutdown.
+type Service interface {
+	Transport
+	Start()
+	Stop()
+}
+
+// Factory creates a transport service. each transport reads and validates its own configuration.
+type Factory func(log logr.Logger) (Service, error)
+
+// Register registers a transport factory under the given transport type. transports register themselves in the init
+// function of their package. panics if the transport type is already registered.
+func Register(transportType string, factory Factory) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if _, found := registry[transportType]; found {
+		panic(fmt.Sprintf("transport type %s is already registered", transportType))
+	}
+
+	registry[transportType] = factory
+}
+
+// NewService creates a transport service of the given transport type using its registered factory.
+func NewService(transportType string, log logr.Logger) (Service, error) {
+	registryLock.RLock()
+	factory, found := registry[transportType]
+	registryLock.RUnlock()
+
+	if !found {
+		return nil, fmt.Errorf("%w: %s (registered types: %s)", errUnsupportedTransportType, transportType,
+			strings.Join(RegisteredTypes(), ", "))
+	}
+
+	service, err := factory(log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transport of type %s: %w", transportType, err)
+	}
+
+	return service, nil
+}
+
+// RegisteredTypes returns the sorted transport types that are registered.
+func RegisteredTypes() []string {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	transportTypes := make([]string, 0, len(registry))
+	for transportType := range registry {
+		transportTypes = append(transportTypes, transportType)
+	}
+
+	sort.Strings(transportTypes)
+
+	return transportTypes
+}
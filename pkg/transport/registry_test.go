@@ -0,0 +1,105 @@
+package transport
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	logrtesting "github.com/go-logr/logr/testing"
+)
+
+var errFactoryFailed = errors.New("factory failed")
+
+// testService is a transport service that records the messages sent using it, and whether it was started and stopped.
+type testService struct {
+	sent    []*Message
+	events  *[]string
+	name    string
+	started bool
+	stopped bool
+}
+
+func (service *testService) SendAsync(message *Message) {
+	service.sent = append(service.sent, message)
+}
+
+func (service *testService) GetVersion(string, string) string { return "" }
+
+func (service *testService) Subscribe(string, CommandHandler) {}
+
+func (service *testService) Start() {
+	service.started = true
+
+	if service.events != nil {
+		*service.events = append(*service.events, "start "+service.name)
+	}
+}
+
+func (service *testService) Stop() {
+	service.stopped = true
+
+	if service.events != nil {
+		*service.events = append(*service.events, "stop "+service.name)
+	}
+}
+
+func TestNewServiceUsesTheRegisteredFactory(t *testing.T) {
+	service := &testService{}
+
+	Register(t.Name(), func(logr.Logger) (Service, error) { return service, nil })
+
+	created, err := NewService(t.Name(), logrtesting.NullLogger{})
+	if err != nil {
+		t.Fatalf("failed to create the transport: %v", err)
+	}
+
+	if created != service {
+		t.Fatal("expected the transport created by the registered factory")
+	}
+
+	found := false
+
+	for _, transportType := range RegisteredTypes() {
+		found = found || transportType == t.Name()
+	}
+
+	if !found {
+		t.Fatalf("expected %s in the registered types, got %v", t.Name(), RegisteredTypes())
+	}
+}
+
+func TestNewServiceOfUnknownTypeFails(t *testing.T) {
+	Register(t.Name(), func(logr.Logger) (Service, error) { return &testService{}, nil })
+
+	_, err := NewService("unknown", logrtesting.NullLogger{})
+	if !errors.Is(err, errUnsupportedTransportType) {
+		t.Fatalf("expected %v, got %v", errUnsupportedTransportType, err)
+	}
+
+	if !strings.Contains(err.Error(), t.Name()) {
+		t.Fatalf("expected the error to list the registered types, got %v", err)
+	}
+}
+
+func TestNewServiceReturnsTheFactoryError(t *testing.T) {
+	Register(t.Name(), func(logr.Logger) (Service, error) { return nil, errFactoryFailed })
+
+	if _, err := NewService(t.Name(), logrtesting.NullLogger{}); !errors.Is(err, errFactoryFailed) {
+		t.Fatalf("expected %v, got %v", errFactoryFailed, err)
+	}
+}
+
+func TestRegisterTwicePanics(t *testing.T) {
+	factory := func(logr.Logger) (Service, error) { return &testService{}, nil }
+
+	Register(t.Name(), factory)
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected registering the same transport type twice to panic")
+		}
+	}()
+
+	Register(t.Name(), factory)
+}
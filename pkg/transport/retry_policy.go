@@ -0,0 +1,38 @@
+package transport
+
+import (
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy defines how many times a failed message is resent and how long to wait between the attempts.
+type RetryPolicy struct {
+	// MaxRetries is the number of times a failed message is resent before giving up.
+	MaxRetries int
+	// InitialBackoff is the time to wait before the first retry. the wait time is doubled on each retry.
+	InitialBackoff time.Duration
+	// MaxBackoff is the upper bound of the wait time between retries.
+	MaxBackoff time.Duration
+}
+
+// Backoff returns the time to wait before the given retry attempt (starting from 0), including a random jitter of up
+// to half of the wait time.
+func (policy *RetryPolicy) Backoff(attempt int) time.Duration {
+	backoff := policy.InitialBackoff
+
+	for i := 0; i < attempt && backoff < policy.MaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > policy.MaxBackoff {
+		backoff = policy.MaxBackoff
+	}
+
+	if backoff <= 1 {
+		return backoff
+	}
+
+	halfBackoff := backoff / 2
+
+	return halfBackoff + time.Duration(rand.Int63n(int64(halfBackoff))) //nolint:gosec // jitter doesn't need crypto
+}
@@ -0,0 +1,37 @@
+package transport
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBackoffDoublesUpToTheMaxBackoff(t *testing.T) {
+	policy := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	for attempt, expected := range []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+		time.Second,
+	} {
+		t.Run(fmt.Sprint(attempt), func(t *testing.T) {
+			for i := 0; i < 100; i++ { // the jitter is random
+				if backoff := policy.Backoff(attempt); backoff < expected/2 || backoff >= expected {
+					t.Fatalf("expected a backoff in [%s, %s), got %s", expected/2, expected, backoff)
+				}
+			}
+		})
+	}
+}
+
+func TestBackoffOfAZeroInitialBackoff(t *testing.T) {
+	policy := &RetryPolicy{MaxBackoff: time.Second}
+
+	if backoff := policy.Backoff(3); backoff != 0 {
+		t.Fatalf("expected no backoff, got %s", backoff)
+	}
+}
@@ -0,0 +1,41 @@
+package signing
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+)
+
+// AESGCMAlgorithm is the encryption algorithm of the encryptor.
+const AESGCMAlgorithm = "aes-gcm"
+
+// encryptor encrypts payloads with AES-GCM. the random nonce is prepended to the ciphertext.
+type encryptor struct {
+	aead cipher.AEAD
+}
+
+// newEncryptor creates a new encryptor. the key must be 16, 24 or 32 bytes long, to select AES-128, AES-192 or AES-256.
+func newEncryptor(key []byte) (*encryptor, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create encryption cipher - %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create encryption cipher - %w", err)
+	}
+
+	return &encryptor{aead: aead}, nil
+}
+
+// Encrypt encrypts the given payload. additionalData is authenticated but not encrypted.
+func (e *encryptor) Encrypt(payload []byte, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce - %w", err)
+	}
+
+	return e.aead.Seal(nonce, nonce, payload, additionalData), nil
+}
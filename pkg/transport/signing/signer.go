@@ -0,0 +1,144 @@
+package signing
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+const (
+	// Ed25519Algorithm is the signing algorithm of the ed25519 signer.
+	Ed25519Algorithm = "ed25519"
+	// HMACSHA256Algorithm is the signing algorithm of the HMAC signer.
+	HMACSHA256Algorithm = "hmac-sha256"
+
+	keyIDLength = 16
+)
+
+var (
+	errUnsupportedSigningAlgorithm = errors.New("unsupported signing algorithm")
+	errInvalidSigningKey           = errors.New("invalid signing key")
+)
+
+// Signer signs payloads.
+type Signer interface {
+	// GetAlgorithm returns the signing algorithm, which is recorded in the message metadata.
+	GetAlgorithm() string
+	// GetKeyID returns the id of the key that is used to sign, which is recorded in the message metadata.
+	GetKeyID() string
+	// Sign returns the signature of the given data.
+	Sign(data []byte) ([]byte, error)
+}
+
+// NewSigner returns a signer of the given algorithm. an ed25519 key is a PEM encoded PKCS #8 private key, an HMAC key
+// is used without its trailing whitespace, e.g. the newline that editors and echo add to a key file. if keyID is
+// empty, the key id is derived from the public key (ed25519) or the key (HMAC).
+func NewSigner(algorithm string, key []byte, keyID string) (Signer, error) {
+	switch algorithm {
+	case Ed25519Algorithm:
+		return newEd25519Signer(key, keyID)
+	case HMACSHA256Algorithm:
+		return newHMACSigner(key, keyID)
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedSigningAlgorithm, algorithm)
+	}
+}
+
+type ed25519Signer struct {
+	privateKey ed25519.PrivateKey
+	keyID      string
+}
+
+func newEd25519Signer(key []byte, keyID string) (*ed25519Signer, error) {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return nil, fmt.Errorf("%w: ed25519 key must be PEM encoded", errInvalidSigningKey)
+	}
+
+	parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidSigningKey, err)
+	}
+
+	privateKey, ok := parsedKey.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: not an ed25519 private key", errInvalidSigningKey)
+	}
+
+	if keyID == "" {
+		keyID = deriveKeyID(privateKey.Public().(ed25519.PublicKey))
+	}
+
+	return &ed25519Signer{
+		privateKey: privateKey,
+		keyID:      keyID,
+	}, nil
+}
+
+func (signer *ed25519Signer) GetAlgorithm() string {
+	return Ed25519Algorithm
+}
+
+func (signer *ed25519Signer) GetKeyID() string {
+	return signer.keyID
+}
+
+func (signer *ed25519Signer) Sign(data []byte) ([]byte, error) {
+	signature, err := signer.privateKey.Sign(rand.Reader, data, crypto.Hash(0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign payload - %w", err)
+	}
+
+	return signature, nil
+}
+
+type hmacSigner struct {
+	key   []byte
+	keyID string
+}
+
+func newHMACSigner(key []byte, keyID string) (*hmacSigner, error) {
+	key = bytes.TrimRight(key, " \t\r\n")
+	if len(key) == 0 {
+		return nil, fmt.Errorf("%w: HMAC key is empty", errInvalidSigningKey)
+	}
+
+	if keyID == "" {
+		keyID = deriveKeyID(key)
+	}
+
+	return &hmacSigner{
+		key:   key,
+		keyID: keyID,
+	}, nil
+}
+
+func (signer *hmacSigner) GetAlgorithm() string {
+	return HMACSHA256Algorithm
+}
+
+func (signer *hmacSigner) GetKeyID() string {
+	return signer.keyID
+}
+
+func (signer *hmacSigner) Sign(data []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, signer.key)
+	mac.Write(data)
+
+	return mac.Sum(nil), nil
+}
+
+// deriveKeyID returns a key id that is derived from the given key material, so the hub can find the key to verify
+// with without it being configured twice.
+func deriveKeyID(keyMaterial []byte) string {
+	hash := sha256.Sum256(keyMaterial)
+	return hex.EncodeToString(hash[:])[:keyIDLength]
+}
@@ -0,0 +1,188 @@
+package signing
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+)
+
+const (
+	// EnvVarBundleSigningKeyPath is the environment variable that holds the path of the signing key, usually mounted
+	// from a secret. an ed25519 key is a PEM encoded PKCS #8 private key, an HMAC key is the raw content of the file.
+	EnvVarBundleSigningKeyPath = "BUNDLE_SIGNING_KEY_PATH"
+	// MetadataKeySignature is the message metadata key that holds the base64 encoded signature.
+	MetadataKeySignature = "signature"
+	// MetadataKeySignatureAlgorithm is the message metadata key that holds the signing algorithm.
+	MetadataKeySignatureAlgorithm = "signatureAlgorithm"
+	// MetadataKeyKeyID is the message metadata key that holds the id of the signing key.
+	MetadataKeyKeyID = "keyId"
+	// MetadataKeyEncryption is the message metadata key that holds the encryption algorithm of the payload.
+	MetadataKeyEncryption = "encryption"
+	// MetadataKeySignedMetadata is the message metadata key that holds the comma separated sorted keys of the metadata
+	// entries that are covered by the signature. entries that are added after signing, e.g. by chunking, are not.
+	MetadataKeySignedMetadata = "signedMetadata"
+
+	envVarBundleSigningAlgorithm  = "BUNDLE_SIGNING_ALGORITHM"
+	envVarBundleSigningKeyID      = "BUNDLE_SIGNING_KEY_ID"
+	envVarBundleEncryptionKeyPath = "BUNDLE_ENCRYPTION_KEY_PATH"
+	defaultSigningAlgorithm       = Ed25519Algorithm
+)
+
+var errEnvVarNotFound = errors.New("not found environment variable")
+
+// MiddlewareName is the name the signing middleware is registered under.
+const MiddlewareName = "signing"
+
+func init() {
+	transport.RegisterMiddleware(MiddlewareName, func(next transport.Transport, log logr.Logger) (transport.Transport,
+		error) {
+		return NewTransport(next)
+	})
+}
+
+// Transport wraps a transport, optionally encrypts the payload of the messages and signs them. the signature covers
+// the message id, type, version and metadata together with the (encrypted) payload, so a signed payload can't be
+// replayed as another bundle or as another leaf hub's bundle, and its codec, content type and compression can't be
+// changed.
+type Transport struct {
+	transport transport.Transport
+	signer    Signer
+	encryptor *encryptor
+}
+
+// NewTransport creates a new instance of Transport that wraps the given transport. the keys are read once, so a
+// rotated key is used only after a restart.
+func NewTransport(transportToWrap transport.Transport) (*Transport, error) {
+	signer, encryptor, err := readEnvVars()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize signing - %w", err)
+	}
+
+	return &Transport{
+		transport: transportToWrap,
+		signer:    signer,
+		encryptor: encryptor,
+	}, nil
+}
+
+func readEnvVars() (Signer, *encryptor, error) {
+	signingKeyPath, found := os.LookupEnv(EnvVarBundleSigningKeyPath)
+	if !found {
+		return nil, nil, fmt.Errorf("%w: %s", errEnvVarNotFound, EnvVarBundleSigningKeyPath)
+	}
+
+	signingKey, err := ioutil.ReadFile(signingKeyPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read signing key - %w", err)
+	}
+
+	algorithm, found := os.LookupEnv(envVarBundleSigningAlgorithm)
+	if !found {
+		algorithm = defaultSigningAlgorithm
+	}
+
+	signer, err := NewSigner(strings.TrimSpace(algorithm), signingKey, os.Getenv(envVarBundleSigningKeyID))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encryptionKeyPath, found := os.LookupEnv(envVarBundleEncryptionKeyPath)
+	if !found {
+		return signer, nil, nil
+	}
+
+	encryptionKey, err := ioutil.ReadFile(encryptionKeyPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read encryption key - %w", err)
+	}
+
+	encryptor, err := newEncryptor(encryptionKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return signer, encryptor, nil
+}
+
+// SendAsync encrypts the message payload if encryption is enabled, signs the message and sends it using the wrapped
+// transport.
+func (t *Transport) SendAsync(message *transport.Message) {
+	if t.encryptor != nil {
+		message.SetMetadata(MetadataKeyEncryption, AESGCMAlgorithm)
+	}
+
+	signedKeys := signedMetadataKeys(message.Metadata)
+	message.SetMetadata(MetadataKeySignedMetadata, strings.Join(signedKeys, ","))
+
+	header := signedHeader(message, signedKeys)
+
+	if t.encryptor != nil {
+		encryptedPayload, err := t.encryptor.Encrypt(message.Payload, header)
+		if err != nil {
+			message.ReportDeliveryResult(err)
+			return
+		}
+
+		message.Payload = encryptedPayload
+	}
+
+	signature, err := t.signer.Sign(append(header, message.Payload...))
+	if err != nil {
+		message.ReportDeliveryResult(err)
+		return
+	}
+
+	message.SetMetadata(MetadataKeySignature, base64.StdEncoding.EncodeToString(signature))
+	message.SetMetadata(MetadataKeySignatureAlgorithm, t.signer.GetAlgorithm())
+	message.SetMetadata(MetadataKeyKeyID, t.signer.GetKeyID())
+
+	t.transport.SendAsync(message)
+}
+
+// GetVersion returns the version from the wrapped transport.
+func (t *Transport) GetVersion(id string, msgType string) string {
+	return t.transport.GetVersion(id, msgType)
+}
+
+// Subscribe subscribes the handler to commands received by the wrapped transport.
+func (t *Transport) Subscribe(commandType string, handler transport.CommandHandler) {
+	t.transport.Subscribe(commandType, handler)
+}
+
+// signedHeader returns the message fields that are covered by the signature in addition to the payload, in the
+// format <id>\n<type>\n<version>\n<metadata>\n, where <metadata> is the form url encoding of the signed metadata
+// entries sorted by key (e.g. codec=json&compression=gzip). it is also used as the additional authenticated data of
+// the encryption.
+func signedHeader(message *transport.Message, signedKeys []string) []byte {
+	metadata := url.Values{}
+	for _, key := range signedKeys {
+		metadata.Set(key, message.Metadata[key])
+	}
+
+	return []byte(fmt.Sprintf("%s\n%s\n%s\n%s\n", message.ID, message.MsgType, message.Version, metadata.Encode()))
+}
+
+// signedMetadataKeys returns the sorted keys of the metadata entries, except the entries that hold the signature.
+func signedMetadataKeys(metadata map[string]string) []string {
+	keys := make([]string, 0, len(metadata))
+
+	for key := range metadata {
+		switch key {
+		case MetadataKeySignature, MetadataKeySignatureAlgorithm, MetadataKeyKeyID, MetadataKeySignedMetadata:
+		default:
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
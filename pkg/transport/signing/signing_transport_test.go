@@ -0,0 +1,168 @@
+package signing
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/transporttest"
+)
+
+var (
+	testSigningKey    = []byte("test-signing-key")
+	testEncryptionKey = bytes.Repeat([]byte("k"), 32)
+)
+
+func newTestTransport(t *testing.T, encrypt bool) (*Transport, *transporttest.Transport) {
+	t.Helper()
+
+	signer, err := NewSigner(HMACSHA256Algorithm, testSigningKey, "")
+	if err != nil {
+		t.Fatalf("failed to create the signer: %v", err)
+	}
+
+	fake := transporttest.NewTransport()
+	signingTransport := &Transport{transport: fake, signer: signer}
+
+	if encrypt {
+		if signingTransport.encryptor, err = newEncryptor(testEncryptionKey); err != nil {
+			t.Fatalf("failed to create the encryptor: %v", err)
+		}
+	}
+
+	return signingTransport, fake
+}
+
+func newMessage() *transport.Message {
+	return &transport.Message{
+		ID:      "hub1.policies",
+		MsgType: "StatusBundle",
+		Version: "3",
+		Payload: []byte("payload"),
+		Metadata: map[string]string{
+			"contentType": "application/cloudevents+json",
+			"compression": "gzip",
+			"codec":       "json",
+		},
+	}
+}
+
+// verify verifies the signature of the message the way the hub does, using the signed metadata keys of the message.
+func verify(t *testing.T, message *transport.Message) bool {
+	t.Helper()
+
+	signature, err := base64.StdEncoding.DecodeString(message.Metadata[MetadataKeySignature])
+	if err != nil {
+		t.Fatalf("failed to decode the signature: %v", err)
+	}
+
+	signer, _ := NewSigner(HMACSHA256Algorithm, testSigningKey, "")
+	expectedSignature, _ := signer.Sign(append(signedHeader(message, signedKeysOf(message)), message.Payload...))
+
+	return hmac.Equal(signature, expectedSignature)
+}
+
+func signedKeysOf(message *transport.Message) []string {
+	return strings.Split(message.Metadata[MetadataKeySignedMetadata], ",")
+}
+
+func TestSignedHeaderIsCanonical(t *testing.T) {
+	message := newMessage()
+	expected := "hub1.policies\nStatusBundle\n3\n" +
+		"codec=json&compression=gzip&contentType=application%2Fcloudevents%2Bjson\n"
+
+	if header := string(signedHeader(message, signedMetadataKeys(message.Metadata))); header != expected {
+		t.Fatalf("expected the signed header %q, got %q", expected, header)
+	}
+}
+
+func TestSignatureCoversMetadata(t *testing.T) {
+	signingTransport, fake := newTestTransport(t, false)
+	signingTransport.SendAsync(newMessage())
+
+	message := fake.Sent()[0]
+
+	if signedMetadata := message.Metadata[MetadataKeySignedMetadata]; signedMetadata != "codec,compression,contentType" {
+		t.Fatalf("expected the sorted metadata keys to be signed, got %q", signedMetadata)
+	}
+
+	if !verify(t, message) {
+		t.Fatal("expected the signature to be valid")
+	}
+
+	message.SetMetadata("chunked", "true") // added after signing, not covered
+
+	if !verify(t, message) {
+		t.Fatal("expected the signature to be valid with unsigned metadata entries")
+	}
+
+	message.SetMetadata("codec", "protobuf")
+
+	if verify(t, message) {
+		t.Fatal("expected the signature to be invalid after the codec was changed")
+	}
+}
+
+func TestEncryptionAuthenticatesMetadata(t *testing.T) {
+	signingTransport, fake := newTestTransport(t, true)
+	signingTransport.SendAsync(newMessage())
+
+	message := fake.Sent()[0]
+
+	if message.Metadata[MetadataKeyEncryption] != AESGCMAlgorithm || !verify(t, message) {
+		t.Fatal("expected the encryption to be recorded in the signed metadata")
+	}
+
+	nonceSize := signingTransport.encryptor.aead.NonceSize()
+	nonce, ciphertext := message.Payload[:nonceSize], message.Payload[nonceSize:]
+	header := signedHeader(message, signedKeysOf(message))
+
+	payload, err := signingTransport.encryptor.aead.Open(nil, nonce, ciphertext, header)
+	if err != nil || string(payload) != "payload" {
+		t.Fatalf("failed to decrypt the payload: %v", err)
+	}
+
+	message.SetMetadata("compression", "zstd")
+
+	if _, err := signingTransport.encryptor.aead.Open(nil, nonce, ciphertext,
+		signedHeader(message, signedKeysOf(message))); err == nil {
+		t.Fatal("expected the decryption to fail after the compression was changed")
+	}
+}
+
+func TestHMACKeyTrailingWhitespaceIsIgnored(t *testing.T) {
+	signer, err := NewSigner(HMACSHA256Algorithm, testSigningKey, "")
+	if err != nil {
+		t.Fatalf("failed to create the signer: %v", err)
+	}
+
+	for name, key := range map[string]string{
+		"newline":      string(testSigningKey) + "\n",
+		"crlf":         string(testSigningKey) + "\r\n",
+		"trailing tab": string(testSigningKey) + " \t\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			keyFileSigner, err := NewSigner(HMACSHA256Algorithm, []byte(key), "")
+			if err != nil {
+				t.Fatalf("failed to create the signer: %v", err)
+			}
+
+			expectedSignature, _ := signer.Sign([]byte("payload"))
+			signature, _ := keyFileSigner.Sign([]byte("payload"))
+
+			if !hmac.Equal(signature, expectedSignature) || keyFileSigner.GetKeyID() != signer.GetKeyID() {
+				t.Fatal("expected the trailing whitespace of the key not to change the signature and key id")
+			}
+		})
+	}
+}
+
+func TestWhitespaceHMACKeyIsRejected(t *testing.T) {
+	if _, err := NewSigner(HMACSHA256Algorithm, []byte("\n"), ""); !errors.Is(err, errInvalidSigningKey) {
+		t.Fatalf("expected %v, got %v", errInvalidSigningKey, err)
+	}
+}
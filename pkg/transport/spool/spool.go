@@ -0,0 +1,659 @@
+package spool
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+)
+
+const (
+	// EnvVarSpoolDir is the environment variable that holds the spool directory. the spool is enabled if it's set.
+	EnvVarSpoolDir            = "SPOOL_DIR"
+	envVarSpoolMaxEntries     = "SPOOL_MAX_ENTRIES"
+	envVarSpoolMaxBytes       = "SPOOL_MAX_BYTES"
+	envVarSpoolEvictionPolicy = "SPOOL_EVICTION_POLICY"
+	envVarSpoolReplayInterval = "SPOOL_REPLAY_INTERVAL"
+
+	// EvictOldest evicts the entries that were spooled first.
+	EvictOldest = "oldest"
+	// EvictLargest evicts the entries with the largest payload.
+	EvictLargest = "largest"
+	// EvictNone doesn't evict entries, new entries are not spooled when the spool is full.
+	EvictNone = "none"
+
+	defaultMaxEntries     = 100
+	defaultMaxBytes       = 100 * 1024 * 1024 // 100MB
+	defaultEvictionPolicy = EvictOldest
+	defaultReplayInterval = 30 * time.Second
+
+	recordFileSuffix = ".json"
+	tempFileSuffix   = ".tmp"
+	filePermissions  = 0o600
+)
+
+var (
+	errEnvVarWrongType    = errors.New("wrong type of environment variable")
+	errEnvVarIllegalValue = errors.New("illegal value of environment variable")
+	errMessageEvicted     = errors.New("message was evicted from the spool")
+)
+
+// MiddlewareName is the name the spool middleware is registered under.
+const MiddlewareName = "spool"
+
+func init() {
+	transport.RegisterMiddleware(MiddlewareName, func(next transport.Transport, log logr.Logger) (transport.Transport,
+		error) {
+		return NewSpool(next, log)
+	})
+}
+
+// record is the spooled representation of a message, as stored on disk.
+type record struct {
+	Sequence uint64            `json:"sequence"`
+	ID       string            `json:"id"`
+	MsgType  string            `json:"msgType"`
+	Version  string            `json:"version"`
+	Payload  []byte            `json:"payload"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+type spoolEntry struct {
+	record           *record
+	inFlightSequence uint64 // sequence of the record that was handed to the transport and wasn't acknowledged yet
+	dirty            bool   // the record wasn't written to disk yet
+}
+
+// Spool wraps a transport and keeps the latest message per id and type on disk until it's delivered.
+// spooled messages survive restarts and are replayed in order once the transport recovers. records are written to
+// disk in the background, so SendAsync never waits for the disk. the spool owns the retries of the messages it
+// spooled: a failed delivery is not reported, the message is replayed until it's delivered, replaced by a newer
+// version or evicted, and only then its delivery result is reported. a version that is already spooled is neither
+// spooled again nor sent twice.
+type Spool struct {
+	transport      transport.Transport
+	dir            string
+	maxEntries     int
+	maxBytes       int64
+	evictionPolicy string
+	replayInterval time.Duration
+	entries        map[string]*spoolEntry
+	totalBytes     int64
+	lastSequence   uint64
+	// callbacks holds the delivery callbacks of the spooled records that weren't reported yet, by record sequence.
+	callbacks   map[uint64][]transport.DeliveryCallback
+	replayChan  chan struct{}
+	persistChan chan struct{}
+	persistLock sync.Mutex // serializes writing the records to disk
+	stopChan    chan struct{}
+	startOnce   sync.Once
+	stopOnce    sync.Once
+	lock        sync.Mutex
+	log         logr.Logger
+}
+
+// NewSpool creates a new instance of Spool that wraps the given transport and loads the spooled messages from disk.
+func NewSpool(transportToWrap transport.Transport, log logr.Logger) (*Spool, error) {
+	spool := &Spool{
+		transport:   transportToWrap,
+		entries:     make(map[string]*spoolEntry),
+		callbacks:   make(map[uint64][]transport.DeliveryCallback),
+		replayChan:  make(chan struct{}, 1),
+		persistChan: make(chan struct{}, 1),
+		stopChan:    make(chan struct{}, 1),
+		log:         log,
+	}
+
+	if err := spool.readEnvVars(); err != nil {
+		return nil, fmt.Errorf("failed to initialize spool - %w", err)
+	}
+
+	if err := os.MkdirAll(spool.dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory - %w", err)
+	}
+
+	if err := spool.load(); err != nil {
+		return nil, fmt.Errorf("failed to load spool - %w", err)
+	}
+
+	return spool, nil
+}
+
+func (s *Spool) readEnvVars() error {
+	s.dir = os.Getenv(EnvVarSpoolDir)
+	if s.dir == "" {
+		return fmt.Errorf("%w: %s must not be empty", errEnvVarIllegalValue, EnvVarSpoolDir)
+	}
+
+	var err error
+
+	if s.maxEntries, err = readIntEnvVar(envVarSpoolMaxEntries, defaultMaxEntries); err != nil {
+		return err
+	}
+
+	maxBytes, err := readIntEnvVar(envVarSpoolMaxBytes, defaultMaxBytes)
+	if err != nil {
+		return err
+	}
+
+	s.maxBytes = int64(maxBytes)
+
+	s.evictionPolicy = os.Getenv(envVarSpoolEvictionPolicy)
+	if s.evictionPolicy == "" {
+		s.evictionPolicy = defaultEvictionPolicy
+	}
+
+	if s.evictionPolicy != EvictOldest && s.evictionPolicy != EvictLargest && s.evictionPolicy != EvictNone {
+		return fmt.Errorf("%w: %s must be one of %s, %s, %s", errEnvVarIllegalValue, envVarSpoolEvictionPolicy,
+			EvictOldest, EvictLargest, EvictNone)
+	}
+
+	s.replayInterval = defaultReplayInterval
+
+	if replayIntervalStr := os.Getenv(envVarSpoolReplayInterval); replayIntervalStr != "" {
+		if s.replayInterval, err = time.ParseDuration(replayIntervalStr); err != nil {
+			return fmt.Errorf("%w: %s must be a duration", errEnvVarWrongType, envVarSpoolReplayInterval)
+		}
+	}
+
+	return nil
+}
+
+// readIntEnvVar returns the default value if the environment variable is not set.
+func readIntEnvVar(name string, defaultValue int) (int, error) {
+	valueStr := os.Getenv(name)
+	if valueStr == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s must be an integer", errEnvVarWrongType, name)
+	}
+
+	return value, nil
+}
+
+// load reads the spooled records from disk. leftovers of interrupted writes and unreadable records are removed.
+func (s *Spool) load() error {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read spool directory - %w", err)
+	}
+
+	for _, file := range files {
+		path := filepath.Join(s.dir, file.Name())
+
+		if file.IsDir() || !strings.HasSuffix(file.Name(), recordFileSuffix) {
+			if strings.HasSuffix(file.Name(), tempFileSuffix) {
+				s.removeFile(path)
+			}
+
+			continue
+		}
+
+		spooledRecord, err := readRecord(path)
+		if err != nil {
+			s.log.Error(err, "removing unreadable spool record", "path", path)
+			s.removeFile(path)
+
+			continue
+		}
+
+		s.entries[recordKey(spooledRecord.ID, spooledRecord.MsgType)] = &spoolEntry{record: spooledRecord}
+		s.totalBytes += int64(len(spooledRecord.Payload))
+
+		if spooledRecord.Sequence > s.lastSequence {
+			s.lastSequence = spooledRecord.Sequence
+		}
+	}
+
+	s.log.Info(fmt.Sprintf("loaded %d spooled messages", len(s.entries)))
+
+	return nil
+}
+
+// Start function starts replaying spooled messages and writing new records to disk.
+func (s *Spool) Start() {
+	s.startOnce.Do(func() {
+		go s.replayPeriodically()
+		go s.persistInBackground()
+		s.triggerReplay()
+	})
+}
+
+// Stop function stops the spool. records that weren't written to disk yet are written, spooled messages remain on
+// disk and the delivery results that weren't reported yet are reported as transport.ErrTransportStopped.
+func (s *Spool) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+		s.persist()
+
+		s.lock.Lock()
+		stopped := deliveryResult{err: transport.ErrTransportStopped}
+
+		for sequence, callbacks := range s.callbacks {
+			stopped.callbacks = append(stopped.callbacks, callbacks...)
+			delete(s.callbacks, sequence)
+		}
+		s.lock.Unlock()
+
+		stopped.report()
+	})
+}
+
+// SendAsync spools the message and sends it using the wrapped transport, unless the same version is in flight
+// already. the record is written to disk in the background.
+func (s *Spool) SendAsync(message *transport.Message) {
+	spooledRecord, results := s.spool(message)
+
+	for _, result := range results {
+		result.report()
+	}
+
+	if spooledRecord == nil { // not spooled, send without tracking
+		s.transport.SendAsync(message)
+		return
+	}
+
+	s.send(spooledRecord)
+}
+
+// GetVersion returns the version of the spooled message if there is one, otherwise the version from the wrapped
+// transport.
+func (s *Spool) GetVersion(id string, msgType string) string {
+	if version, found := s.getSpooledVersion(id, msgType); found {
+		return version
+	}
+
+	return s.transport.GetVersion(id, msgType)
+}
+
+// Subscribe subscribes the handler to commands received by the wrapped transport.
+func (s *Spool) Subscribe(commandType string, handler transport.CommandHandler) {
+	s.transport.Subscribe(commandType, handler)
+}
+
+func (s *Spool) getSpooledVersion(id string, msgType string) (string, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	entry, found := s.entries[recordKey(id, msgType)]
+	if !found {
+		return "", false
+	}
+
+	return entry.record.Version, true
+}
+
+// spool adds the message to the spool, replacing a spooled message with the same id and type, and triggers writing it
+// to disk. if the same version is already spooled its record is returned as is. returns nil if the message was not
+// spooled, and the delivery results of the records that were replaced or evicted.
+func (s *Spool) spool(message *transport.Message) (*record, []deliveryResult) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := recordKey(message.ID, message.MsgType)
+	existingEntry, found := s.entries[key]
+
+	if found && existingEntry.record.Version == message.Version { // e.g. resent after a restart or a resync
+		s.addCallback(existingEntry.record.Sequence, message.DeliveryCallback)
+		return existingEntry.record, nil
+	}
+
+	var results []deliveryResult
+
+	if found {
+		results = append(results, deliveryResult{
+			callbacks: s.takeCallbacks(existingEntry.record.Sequence),
+			err:       transport.ErrMessageSuperseded,
+		})
+	} else {
+		fits, evicted := s.makeRoom(int64(len(message.Payload)))
+		results = append(results, evicted...)
+
+		if !fits {
+			s.log.Info(fmt.Sprintf("spool is full, message '%s' from type '%s' with version '%s' is not spooled",
+				message.ID, message.MsgType, message.Version))
+			return nil, results
+		}
+	}
+
+	s.lastSequence++
+	newRecord := &record{
+		Sequence: s.lastSequence,
+		ID:       message.ID,
+		MsgType:  message.MsgType,
+		Version:  message.Version,
+		Payload:  message.Payload,
+		Metadata: message.Metadata,
+	}
+
+	if found {
+		s.totalBytes -= int64(len(existingEntry.record.Payload))
+		existingEntry.record = newRecord
+		existingEntry.dirty = true
+	} else {
+		s.entries[key] = &spoolEntry{record: newRecord, dirty: true}
+	}
+
+	s.totalBytes += int64(len(newRecord.Payload))
+	s.addCallback(newRecord.Sequence, message.DeliveryCallback)
+	s.triggerPersist()
+
+	return newRecord, results
+}
+
+// makeRoom evicts entries according to the eviction policy until a new entry of the given size fits.
+// returns false if the entry doesn't fit, and the delivery results of the evicted records.
+func (s *Spool) makeRoom(size int64) (bool, []deliveryResult) {
+	var evicted []deliveryResult
+
+	for len(s.entries) >= s.maxEntries || s.totalBytes+size > s.maxBytes {
+		if s.evictionPolicy == EvictNone || len(s.entries) == 0 {
+			return false, evicted
+		}
+
+		evictedKey := s.selectEntryToEvict()
+		evictedRecord := s.entries[evictedKey].record
+
+		s.log.Info(fmt.Sprintf("spool is full, evicting message '%s' from type '%s' with version '%s'",
+			evictedRecord.ID, evictedRecord.MsgType, evictedRecord.Version))
+		s.removeEntry(evictedKey)
+
+		evicted = append(evicted, deliveryResult{
+			callbacks: s.takeCallbacks(evictedRecord.Sequence),
+			err:       errMessageEvicted,
+		})
+	}
+
+	return true, evicted
+}
+
+func (s *Spool) selectEntryToEvict() string {
+	selectedKey := ""
+
+	var selectedRecord *record
+
+	for key, entry := range s.entries {
+		if selectedRecord == nil ||
+			(s.evictionPolicy == EvictOldest && entry.record.Sequence < selectedRecord.Sequence) ||
+			(s.evictionPolicy == EvictLargest && len(entry.record.Payload) > len(selectedRecord.Payload)) {
+			selectedKey, selectedRecord = key, entry.record
+		}
+	}
+
+	return selectedKey
+}
+
+// send sends the spooled record using the wrapped transport. the record is not sent if it was replaced, evicted or
+// delivered since it was read, or if it is already in flight.
+func (s *Spool) send(spooledRecord *record) {
+	s.lock.Lock()
+
+	entry, found := s.entries[recordKey(spooledRecord.ID, spooledRecord.MsgType)]
+	if !found || entry.record.Sequence != spooledRecord.Sequence || entry.inFlightSequence == spooledRecord.Sequence {
+		s.lock.Unlock()
+		return
+	}
+
+	entry.inFlightSequence = spooledRecord.Sequence
+	s.lock.Unlock()
+
+	s.transport.SendAsync(&transport.Message{
+		ID:       spooledRecord.ID,
+		MsgType:  spooledRecord.MsgType,
+		Version:  spooledRecord.Version,
+		Payload:  spooledRecord.Payload,
+		Metadata: spooledRecord.Metadata,
+		DeliveryCallback: func(err error) {
+			s.handleDeliveryResult(spooledRecord, err).report()
+		},
+	})
+}
+
+// handleDeliveryResult removes the record from the spool if it was delivered and wasn't replaced in the meantime, and
+// returns the delivery result to report. a failed record stays spooled and its result is not reported, it's replayed.
+func (s *Spool) handleDeliveryResult(deliveredRecord *record, err error) deliveryResult {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := recordKey(deliveredRecord.ID, deliveredRecord.MsgType)
+
+	entry, found := s.entries[key]
+	if !found || entry.record.Sequence != deliveredRecord.Sequence {
+		return deliveryResult{} // the record was replaced by a newer one or evicted, its result was reported
+	}
+
+	entry.inFlightSequence = 0
+
+	if err != nil {
+		s.log.Info(fmt.Sprintf("failed to deliver message '%s' from type '%s' with version '%s', will replay it - %v",
+			deliveredRecord.ID, deliveredRecord.MsgType, deliveredRecord.Version, err))
+
+		return deliveryResult{} // keep it spooled, will be replayed later
+	}
+
+	s.removeEntry(key)
+	s.triggerReplay() // the transport is reachable, replay the rest of the spool
+
+	return deliveryResult{callbacks: s.takeCallbacks(deliveredRecord.Sequence)}
+}
+
+// addCallback adds a delivery callback of the record with the given sequence. must be called with the lock held.
+func (s *Spool) addCallback(sequence uint64, callback transport.DeliveryCallback) {
+	if callback != nil {
+		s.callbacks[sequence] = append(s.callbacks[sequence], callback)
+	}
+}
+
+// takeCallbacks removes and returns the delivery callbacks of the record with the given sequence. must be called with
+// the lock held.
+func (s *Spool) takeCallbacks(sequence uint64) []transport.DeliveryCallback {
+	callbacks := s.callbacks[sequence]
+	delete(s.callbacks, sequence)
+
+	return callbacks
+}
+
+// deliveryResult is a delivery result to report to the callbacks of a record, once the lock is released.
+type deliveryResult struct {
+	callbacks []transport.DeliveryCallback
+	err       error
+}
+
+func (result deliveryResult) report() {
+	for _, callback := range result.callbacks {
+		callback(result.err)
+	}
+}
+
+func (s *Spool) replayPeriodically() {
+	ticker := time.NewTicker(s.replayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+			s.replay()
+		case <-s.replayChan:
+			s.replay()
+		}
+	}
+}
+
+func (s *Spool) triggerReplay() {
+	select {
+	case s.replayChan <- struct{}{}:
+	default:
+	}
+}
+
+// replay sends the spooled records that are not in flight, in the order they were spooled.
+func (s *Spool) replay() {
+	s.lock.Lock()
+
+	records := make([]*record, 0, len(s.entries))
+
+	for _, entry := range s.entries {
+		if entry.inFlightSequence == 0 {
+			records = append(records, entry.record)
+		}
+	}
+
+	s.lock.Unlock()
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Sequence < records[j].Sequence
+	})
+
+	for _, spooledRecord := range records {
+		s.log.Info(fmt.Sprintf("replaying message '%s' from type '%s' with version '%s'", spooledRecord.ID,
+			spooledRecord.MsgType, spooledRecord.Version))
+		s.send(spooledRecord) // rechecks the record, it may have been replaced or delivered in the meantime
+	}
+}
+
+func (s *Spool) persistInBackground() {
+	for {
+		select {
+		case <-s.stopChan: // Stop writes the rest
+			return
+		case <-s.persistChan:
+			s.persist()
+		}
+	}
+}
+
+func (s *Spool) triggerPersist() {
+	select {
+	case s.persistChan <- struct{}{}:
+	default:
+	}
+}
+
+// persist writes the records that weren't written to disk yet. the records are written outside the lock, a record
+// that was delivered or evicted while it was written is removed again.
+func (s *Spool) persist() {
+	s.persistLock.Lock()
+	defer s.persistLock.Unlock()
+
+	s.lock.Lock()
+
+	records := make([]*record, 0, len(s.entries))
+
+	for _, entry := range s.entries {
+		if entry.dirty {
+			entry.dirty = false
+			records = append(records, entry.record)
+		}
+	}
+
+	s.lock.Unlock()
+
+	for _, recordToWrite := range records {
+		err := s.writeRecord(recordToWrite)
+
+		s.lock.Lock()
+
+		entry, found := s.entries[recordKey(recordToWrite.ID, recordToWrite.MsgType)]
+
+		switch {
+		case !found:
+			s.removeFile(s.recordPath(recordToWrite.ID, recordToWrite.MsgType))
+		case err != nil && entry.record.Sequence == recordToWrite.Sequence:
+			s.log.Error(err, "failed to spool message", "id", recordToWrite.ID, "type", recordToWrite.MsgType)
+			entry.dirty = true // retried on the next write
+		}
+
+		s.lock.Unlock()
+	}
+}
+
+func (s *Spool) removeEntry(key string) {
+	entry := s.entries[key]
+	s.totalBytes -= int64(len(entry.record.Payload))
+	delete(s.entries, key)
+	s.removeFile(s.recordPath(entry.record.ID, entry.record.MsgType))
+}
+
+// writeRecord writes the record to a temporary file and renames it, so a crash never leaves a partial record.
+func (s *Spool) writeRecord(recordToWrite *record) error {
+	recordBytes, err := json.Marshal(recordToWrite)
+	if err != nil {
+		return fmt.Errorf("failed to marshal record - %w", err)
+	}
+
+	tempFile, err := ioutil.TempFile(s.dir, "*"+tempFileSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file - %w", err)
+	}
+
+	defer s.removeFile(tempFile.Name()) // no-op if renamed
+
+	if _, err := tempFile.Write(recordBytes); err != nil {
+		_ = tempFile.Close()
+		return fmt.Errorf("failed to write temporary file - %w", err)
+	}
+
+	if err := tempFile.Sync(); err != nil {
+		_ = tempFile.Close()
+		return fmt.Errorf("failed to sync temporary file - %w", err)
+	}
+
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file - %w", err)
+	}
+
+	if err := os.Chmod(tempFile.Name(), filePermissions); err != nil {
+		return fmt.Errorf("failed to set temporary file permissions - %w", err)
+	}
+
+	if err := os.Rename(tempFile.Name(), s.recordPath(recordToWrite.ID, recordToWrite.MsgType)); err != nil {
+		return fmt.Errorf("failed to rename temporary file - %w", err)
+	}
+
+	return nil
+}
+
+func readRecord(path string) (*record, error) {
+	recordBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read record - %w", err)
+	}
+
+	spooledRecord := &record{}
+	if err := json.Unmarshal(recordBytes, spooledRecord); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal record - %w", err)
+	}
+
+	return spooledRecord, nil
+}
+
+func (s *Spool) removeFile(path string) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		s.log.Error(err, "failed to remove spool file", "path", path)
+	}
+}
+
+func (s *Spool) recordPath(id string, msgType string) string {
+	fileName := base64.RawURLEncoding.EncodeToString([]byte(recordKey(id, msgType)))
+	return filepath.Join(s.dir, fileName+recordFileSuffix)
+}
+
+func recordKey(id string, msgType string) string {
+	return fmt.Sprintf("%s.%s", msgType, id)
+}
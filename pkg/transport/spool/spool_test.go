@@ -0,0 +1,242 @@
+package spool
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/transporttest"
+)
+
+const (
+	testMsgType = "StatusBundle"
+	testID      = "hub1.policies"
+)
+
+var errUnreachable = errors.New("transport is unreachable")
+
+func newTestSpool(t *testing.T, dir string, transportToWrap transport.Transport) *Spool {
+	t.Helper()
+
+	spool := &Spool{
+		transport:      transportToWrap,
+		dir:            dir,
+		maxEntries:     defaultMaxEntries,
+		maxBytes:       defaultMaxBytes,
+		evictionPolicy: defaultEvictionPolicy,
+		entries:        make(map[string]*spoolEntry),
+		callbacks:      make(map[uint64][]transport.DeliveryCallback),
+		replayChan:     make(chan struct{}, 1),
+		persistChan:    make(chan struct{}, 1),
+		stopChan:       make(chan struct{}, 1),
+		log:            logrtesting.NullLogger{},
+	}
+
+	if err := spool.load(); err != nil {
+		t.Fatalf("failed to load the spool: %v", err)
+	}
+
+	return spool
+}
+
+func newTestDir(t *testing.T) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatalf("failed to create a temporary directory: %v", err)
+	}
+
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	return dir
+}
+
+func newMessage(version string, deliveryCallback transport.DeliveryCallback) *transport.Message {
+	return &transport.Message{
+		ID:               testID,
+		MsgType:          testMsgType,
+		Version:          version,
+		Payload:          []byte("payload-" + version),
+		DeliveryCallback: deliveryCallback,
+	}
+}
+
+func TestSendAsyncDoesNotWriteOnTheCallerGoroutine(t *testing.T) {
+	dir := newTestDir(t)
+	fake := transporttest.NewTransport()
+	spool := newTestSpool(t, dir, fake) // not started, nothing writes in the background
+
+	spool.SendAsync(newMessage("1", nil))
+
+	if len(fake.Sent()) != 1 {
+		t.Fatal("expected the message to be sent")
+	}
+
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Fatalf("expected SendAsync not to write to disk, found %d files", len(files))
+	}
+
+	spool.Stop() // writes the pending records
+
+	fake.Sent()[0].ReportDeliveryResult(errUnreachable)
+
+	reloaded := newTestSpool(t, dir, transporttest.NewTransport())
+	if version, found := reloaded.getSpooledVersion(testID, testMsgType); !found || version != "1" {
+		t.Fatalf("expected version 1 to be spooled on disk, got %q", version)
+	}
+}
+
+func TestDeliveredRecordIsNotLeftOnDisk(t *testing.T) {
+	dir := newTestDir(t)
+	fake := transporttest.NewTransport()
+	spool := newTestSpool(t, dir, fake)
+
+	spool.SendAsync(newMessage("1", nil))
+	fake.Sent()[0].ReportDeliveryResult(nil)
+	spool.Stop()
+
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Fatalf("expected the delivered record not to be written, found %d files", len(files))
+	}
+}
+
+func TestReplayDoesNotSendReplacedRecord(t *testing.T) {
+	fake := transporttest.NewTransport()
+	spool := newTestSpool(t, newTestDir(t), fake)
+
+	spool.SendAsync(newMessage("1", nil))
+	fake.Sent()[0].ReportDeliveryResult(errUnreachable) // stays spooled
+
+	spool.lock.Lock()
+	staleRecord := spool.entries[recordKey(testID, testMsgType)].record // a snapshot taken by replay
+	spool.lock.Unlock()
+
+	resultChan := make(chan error, 1)
+	spool.SendAsync(newMessage("2", func(err error) { resultChan <- err }))
+
+	spool.send(staleRecord)
+
+	sent := fake.Sent()
+	if len(sent) != 2 || sent[1].Version != "2" {
+		t.Fatalf("expected only version 2 to be sent after the replacement, sent %d messages", len(sent))
+	}
+
+	// version 2 is in flight, so replay must not send it again
+	spool.replay()
+
+	if len(fake.Sent()) != 2 {
+		t.Fatal("expected the in flight record not to be replayed")
+	}
+
+	sent[1].ReportDeliveryResult(nil)
+
+	if err := <-resultChan; err != nil {
+		t.Fatalf("expected version 2 to be delivered, got %v", err)
+	}
+
+	if _, found := spool.getSpooledVersion(testID, testMsgType); found {
+		t.Fatal("expected the delivered record to be removed from the spool")
+	}
+}
+
+func TestReplacedVersionIsReportedSuperseded(t *testing.T) {
+	fake := transporttest.NewTransport()
+	spool := newTestSpool(t, newTestDir(t), fake)
+
+	var result error
+
+	spool.SendAsync(newMessage("1", func(err error) { result = err }))
+	spool.SendAsync(newMessage("2", nil))
+
+	if !errors.Is(result, transport.ErrMessageSuperseded) {
+		t.Fatalf("expected %v, got %v", transport.ErrMessageSuperseded, result)
+	}
+
+	// the result of the replaced version that was in flight doesn't affect the spooled version
+	fake.Sent()[0].ReportDeliveryResult(nil)
+
+	if version, _ := spool.getSpooledVersion(testID, testMsgType); version != "2" {
+		t.Fatalf("expected version 2 to stay spooled, got %q", version)
+	}
+}
+
+func TestFailedDeliveryIsReplayedAndReportedOnceDelivered(t *testing.T) {
+	fake := transporttest.NewTransport()
+	spool := newTestSpool(t, newTestDir(t), fake)
+
+	var results []error
+
+	spool.SendAsync(newMessage("1", func(err error) { results = append(results, err) }))
+	fake.Sent()[0].ReportDeliveryResult(errUnreachable)
+
+	if len(results) != 0 {
+		t.Fatalf("expected the failure not to be reported while the message is spooled, got %v", results)
+	}
+
+	spool.replay()
+
+	sent := fake.Sent()
+	if len(sent) != 2 || sent[1].Version != "1" {
+		t.Fatalf("expected version 1 to be replayed, sent %d messages", len(sent))
+	}
+
+	sent[1].ReportDeliveryResult(nil)
+
+	if len(results) != 1 || results[0] != nil {
+		t.Fatalf("expected a single successful delivery result, got %v", results)
+	}
+}
+
+func TestResentVersionIsNotSpooledOrSentAgain(t *testing.T) {
+	dir := newTestDir(t)
+	fake := transporttest.NewTransport()
+	spool := newTestSpool(t, dir, fake)
+
+	var results []error
+
+	spool.SendAsync(newMessage("1", func(err error) { results = append(results, err) }))
+	spool.persist()
+
+	spool.SendAsync(newMessage("1", func(err error) { results = append(results, err) }))
+
+	if sent := fake.Sent(); len(sent) != 1 {
+		t.Fatalf("expected the version in flight not to be sent again, sent %d messages", len(sent))
+	}
+
+	spool.lock.Lock()
+	dirty := spool.entries[recordKey(testID, testMsgType)].dirty
+	spool.lock.Unlock()
+
+	if dirty {
+		t.Fatal("expected the spooled record not to be written again")
+	}
+
+	fake.Sent()[0].ReportDeliveryResult(nil)
+
+	if len(results) != 2 || results[0] != nil || results[1] != nil {
+		t.Fatalf("expected both sends to be reported as delivered, got %v", results)
+	}
+}
+
+func TestStopReportsTheSpooledMessagesAsStopped(t *testing.T) {
+	fake := transporttest.NewTransport()
+	spool := newTestSpool(t, newTestDir(t), fake)
+
+	var result error
+
+	spool.SendAsync(newMessage("1", func(err error) { result = err }))
+	fake.Sent()[0].ReportDeliveryResult(errUnreachable)
+	spool.Stop()
+
+	if !errors.Is(result, transport.ErrTransportStopped) {
+		t.Fatalf("expected %v, got %v", transport.ErrTransportStopped, result)
+	}
+
+	if version, found := spool.getSpooledVersion(testID, testMsgType); !found || version != "1" {
+		t.Fatal("expected the message to stay spooled after stop")
+	}
+}
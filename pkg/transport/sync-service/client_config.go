@@ -0,0 +1,141 @@
+package syncservice
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	appKeyFileName    = "appKey"
+	appSecretFileName = "appSecret"
+	defaultAppKey     = "user@myorg"
+	defaultAppSecret  = ""
+)
+
+var errFailedToLoadCACert = errors.New("failed to append CA certificate to the pool")
+
+// clientConfig holds the configuration that is used to create the sync service client.
+type clientConfig struct {
+	protocol string
+	host     string
+	port     uint16
+	// credentialsDir is the directory of a mounted secret that holds the appKey and appSecret files, optional.
+	credentialsDir string
+	// caCertPath is the path of a CA bundle used to verify an https sync service, optional.
+	caCertPath string
+	// clientCertPath and clientKeyPath are the paths of the client certificate and key presented to an https sync
+	// service that requires mutual TLS, optional.
+	clientCertPath string
+	clientKeyPath  string
+}
+
+// createClient creates a sync service client using the current content of the credentials, CA and client certificate
+// files.
+// returns the client and a checksum of the files it was created from.
+func (config *clientConfig) createClient() (*essClient, string, error) {
+	appKey, appSecret, err := config.readCredentials()
+	if err != nil {
+		return nil, "", err
+	}
+
+	checksum, err := config.checksum()
+	if err != nil {
+		return nil, "", err
+	}
+
+	tlsConfig, err := config.tlsConfig()
+	if err != nil {
+		return nil, "", err
+	}
+
+	httpTransport := &http.Transport{TLSClientConfig: tlsConfig}
+
+	return newESSClient(config.protocol, config.host, config.port, httpTransport, appKey, appSecret), checksum, nil
+}
+
+func (config *clientConfig) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if config.caCertPath != "" {
+		caCert, err := ioutil.ReadFile(config.caCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate - %w", err)
+		}
+
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("%w: %s", errFailedToLoadCACert, config.caCertPath)
+		}
+
+		tlsConfig.RootCAs = certPool
+	}
+
+	if config.clientCertPath != "" {
+		clientCert, err := tls.LoadX509KeyPair(config.clientCertPath, config.clientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate - %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	return tlsConfig, nil
+}
+
+func (config *clientConfig) readCredentials() (string, string, error) {
+	if config.credentialsDir == "" {
+		return defaultAppKey, defaultAppSecret, nil
+	}
+
+	appKey, err := ioutil.ReadFile(filepath.Join(config.credentialsDir, appKeyFileName))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read app key - %w", err)
+	}
+
+	appSecret, err := ioutil.ReadFile(filepath.Join(config.credentialsDir, appSecretFileName))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read app secret - %w", err)
+	}
+
+	return strings.TrimSpace(string(appKey)), strings.TrimSpace(string(appSecret)), nil
+}
+
+// checksum returns a checksum of the credentials, CA and client certificate files, used to detect rotation of the
+// files.
+func (config *clientConfig) checksum() (string, error) {
+	paths := make([]string, 0, 5)
+
+	if config.credentialsDir != "" {
+		paths = append(paths, filepath.Join(config.credentialsDir, appKeyFileName),
+			filepath.Join(config.credentialsDir, appSecretFileName))
+	}
+
+	if config.caCertPath != "" {
+		paths = append(paths, config.caCertPath)
+	}
+
+	if config.clientCertPath != "" {
+		paths = append(paths, config.clientCertPath, config.clientKeyPath)
+	}
+
+	hash := sha256.New()
+
+	for _, path := range paths {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s - %w", path, err)
+		}
+
+		hash.Write(content)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,240 @@
+package syncservice
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/sync-service/fakeess"
+)
+
+// testCA signs the client certificates of the tests.
+type testCA struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate the CA key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	certificateDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create the CA certificate: %v", err)
+	}
+
+	certificate, err := x509.ParseCertificate(certificateDER)
+	if err != nil {
+		t.Fatalf("failed to parse the CA certificate: %v", err)
+	}
+
+	return &testCA{certificate: certificate, key: key}
+}
+
+func (ca *testCA) certPool() *x509.CertPool {
+	certPool := x509.NewCertPool()
+	certPool.AddCert(ca.certificate)
+
+	return certPool
+}
+
+// writeClientCert writes a client certificate with the given common name and its key to the given paths.
+func (ca *testCA) writeClientCert(t *testing.T, commonName string, certPath string, keyPath string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate the client key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	certificateDER, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("failed to create the client certificate: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal the client key: %v", err)
+	}
+
+	writeFile(t, certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificateDER}))
+	writeFile(t, keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func writeFile(t *testing.T, path string, content []byte) {
+	t.Helper()
+
+	if err := ioutil.WriteFile(path, content, 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+// mutualTLSFiles are the credentials, CA and client certificate files of a test sync service.
+type mutualTLSFiles struct {
+	credentialsDir string
+	clientCertPath string
+	clientKeyPath  string
+}
+
+// setMutualTLSEnvVars writes the files that authenticate with the given server and points the sync service to them.
+func setMutualTLSEnvVars(t *testing.T, server *fakeess.Server, ca *testCA) *mutualTLSFiles {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := &mutualTLSFiles{
+		credentialsDir: dir,
+		clientCertPath: filepath.Join(dir, "tls.crt"),
+		clientKeyPath:  filepath.Join(dir, "tls.key"),
+	}
+	caCertPath := filepath.Join(dir, "ca.crt")
+
+	writeFile(t, caCertPath, server.CACertificate())
+	ca.writeClientCert(t, "leaf-hub", files.clientCertPath, files.clientKeyPath)
+	files.writeCredentials(t, "leaf-hub", "secret")
+
+	setEnvVar(t, envVarCredentialsDir, files.credentialsDir)
+	setEnvVar(t, envVarCACertPath, caCertPath)
+	setEnvVar(t, envVarClientCertPath, files.clientCertPath)
+	setEnvVar(t, envVarClientKeyPath, files.clientKeyPath)
+
+	return files
+}
+
+func (files *mutualTLSFiles) writeCredentials(t *testing.T, appKey string, appSecret string) {
+	t.Helper()
+
+	writeFile(t, filepath.Join(files.credentialsDir, appKeyFileName), []byte(appKey+"\n"))
+	writeFile(t, filepath.Join(files.credentialsDir, appSecretFileName), []byte(appSecret+"\n"))
+}
+
+func TestReadEnvVarsRequiresClientCertAndKeyTogether(t *testing.T) {
+	for name, test := range map[string]struct {
+		clientCertPath string
+		clientKeyPath  string
+		expectedErr    error
+	}{
+		"neither":   {},
+		"both":      {clientCertPath: "tls.crt", clientKeyPath: "tls.key"},
+		"only cert": {clientCertPath: "tls.crt", expectedErr: errEnvVarIllegalValue},
+		"only key":  {clientKeyPath: "tls.key", expectedErr: errEnvVarIllegalValue},
+	} {
+		t.Run(name, func(t *testing.T) {
+			setEnvVar(t, envVarSyncServiceProtocol, "https")
+			setEnvVar(t, envVarSyncServiceHost, "localhost")
+			setEnvVar(t, envVarSyncServicePort, "8443")
+			setEnvVar(t, envVarClientCertPath, test.clientCertPath)
+			setEnvVar(t, envVarClientKeyPath, test.clientKeyPath)
+
+			if _, err := readEnvVars(); !errors.Is(err, test.expectedErr) {
+				t.Fatalf("expected %v, got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestSendAsyncPresentsTheClientCertificate(t *testing.T) {
+	ca := newTestCA(t)
+	server := fakeess.NewTLSServer(ca.certPool())
+	defer server.Close()
+
+	setMutualTLSEnvVars(t, server, ca)
+	server.SetCredentials("leaf-hub", "secret")
+
+	syncService := newTestSyncService(t, server)
+	syncService.Start()
+	defer syncService.Stop()
+
+	resultChan := make(chan error, 1)
+
+	syncService.SendAsync(&transport.Message{
+		ID:               "hub1.policies",
+		MsgType:          "StatusBundle",
+		Version:          "1",
+		DeliveryCallback: func(err error) { resultChan <- err },
+	})
+
+	select {
+	case err := <-resultChan:
+		if err != nil {
+			t.Fatalf("failed to send the message: %v", err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for the delivery result")
+	}
+
+	if commonName := server.ClientCommonName(); commonName != "leaf-hub" {
+		t.Fatalf("expected the client certificate of leaf-hub, got %q", commonName)
+	}
+}
+
+func TestCommandsAreReceivedWithRotatedCredentials(t *testing.T) {
+	ca := newTestCA(t)
+	server := fakeess.NewTLSServer(ca.certPool())
+	defer server.Close()
+
+	files := setMutualTLSEnvVars(t, server, ca)
+	server.SetCredentials("leaf-hub", "secret")
+	setEnvVar(t, envVarCommandsPollingInterval, testPollingInterval.String())
+
+	syncService := newTestSyncService(t, server)
+	handledChan := make(chan struct{})
+
+	syncService.Subscribe(transport.CommandTypeResync, func(*transport.Command) error {
+		close(handledChan)
+		return nil
+	})
+
+	syncService.Start()
+	defer syncService.Stop()
+
+	// rotate the files while the commands are polled, the old credentials are rejected from now on
+	ca.writeClientCert(t, "rotated", files.clientCertPath, files.clientKeyPath)
+	files.writeCredentials(t, "leaf-hub", "rotated-secret")
+	server.SetCredentials("leaf-hub", "rotated-secret")
+	syncService.refreshCredentials()
+
+	addCommandObject(t, server)
+
+	select {
+	case <-handledChan:
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for the command to be received with the rotated credentials")
+	}
+
+	if commonName := server.ClientCommonName(); commonName != "rotated" {
+		t.Fatalf("expected the rotated client certificate, got %q", commonName)
+	}
+}
@@ -0,0 +1,210 @@
+package syncservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/open-horizon/edge-sync-service-client/client"
+)
+
+const (
+	objectsPath           = "/api/v1/objects/"
+	unixSocketAddress     = "localhost:8080"
+	essClientRequestLimit = time.Minute
+	maxErrorMessageSize   = 1024
+)
+
+var (
+	errObjectNotFound   = errors.New("object not found")
+	errUnexpectedStatus = errors.New("unexpected response status")
+)
+
+// objectUpdatePayload is the body of an update object request.
+type objectUpdatePayload struct {
+	Meta client.ObjectMetaData `json:"meta"`
+}
+
+// essClient is a client of the object API of the Edge Sync Service. it's used instead of the client of the
+// edge-sync-service-client library, since that client doesn't let the caller present a client certificate, and its
+// updates poller can't be stopped safely and never lists an object it already delivered again.
+// the object types of the library are used on the wire.
+type essClient struct {
+	httpClient *http.Client
+	objectsURL string
+	appKey     string
+	appSecret  string
+}
+
+// newESSClient creates a client of the Edge Sync Service. the protocol is http, https, unix or secure-unix. with the
+// unix protocols, host is the path of the socket of the Edge Sync Service and port is ignored.
+func newESSClient(protocol string, host string, port uint16, httpTransport *http.Transport, appKey string,
+	appSecret string) *essClient {
+	address := fmt.Sprintf("%s:%d", host, port)
+
+	switch strings.ToLower(protocol) {
+	case "unix", "secure-unix":
+		socketPath := host
+		dialer := net.Dialer{}
+		httpTransport.DialContext = func(ctx context.Context, _ string, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socketPath)
+		}
+
+		address = unixSocketAddress
+
+		if strings.ToLower(protocol) == "unix" {
+			protocol = "http"
+		} else {
+			protocol = "https"
+		}
+	}
+
+	return &essClient{
+		httpClient: &http.Client{Transport: httpTransport, Timeout: essClientRequestLimit},
+		objectsURL: fmt.Sprintf("%s://%s%s", protocol, address, objectsPath),
+		appKey:     appKey,
+		appSecret:  appSecret,
+	}
+}
+
+// listObjects returns the objects of the given type that were sent to this client and weren't consumed or marked as
+// deleted yet.
+func (c *essClient) listObjects(objectType string) ([]client.ObjectMetaData, error) {
+	// received=true includes the objects that were listed before and weren't consumed
+	response, err := c.do(http.MethodGet, c.objectURL(objectType, "", "")+"?received=true", nil)
+	if errors.Is(err, errObjectNotFound) { // no objects of the type
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to list objects of type '%s' - %w", objectType, err)
+	}
+
+	defer response.Body.Close()
+
+	var objects []client.ObjectMetaData
+	if err := json.NewDecoder(response.Body).Decode(&objects); err != nil {
+		return nil, fmt.Errorf("failed to decode objects of type '%s' - %w", objectType, err)
+	}
+
+	return objects, nil
+}
+
+// getObjectMetadata returns errObjectNotFound if the object doesn't exist.
+func (c *essClient) getObjectMetadata(objectType string, objectID string) (*client.ObjectMetaData, error) {
+	response, err := c.do(http.MethodGet, c.objectURL(objectType, objectID, ""), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the metadata of object '%s' - %w", objectID, err)
+	}
+
+	defer response.Body.Close()
+
+	var metaData client.ObjectMetaData
+	if err := json.NewDecoder(response.Body).Decode(&metaData); err != nil {
+		return nil, fmt.Errorf("failed to decode the metadata of object '%s' - %w", objectID, err)
+	}
+
+	return &metaData, nil
+}
+
+func (c *essClient) fetchObjectData(metaData *client.ObjectMetaData) ([]byte, error) {
+	response, err := c.do(http.MethodGet, c.objectURL(metaData.ObjectType, metaData.ObjectID, "data"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the data of object '%s' - %w", metaData.ObjectID, err)
+	}
+
+	defer response.Body.Close()
+
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the data of object '%s' - %w", metaData.ObjectID, err)
+	}
+
+	return data, nil
+}
+
+// updateObject creates or updates the metadata of an object.
+func (c *essClient) updateObject(metaData *client.ObjectMetaData) error {
+	payload, err := json.Marshal(&objectUpdatePayload{Meta: *metaData})
+	if err != nil {
+		return fmt.Errorf("failed to marshal the metadata of object '%s' - %w", metaData.ObjectID, err)
+	}
+
+	return c.doAndClose(http.MethodPut, c.objectURL(metaData.ObjectType, metaData.ObjectID, ""), payload)
+}
+
+func (c *essClient) updateObjectData(metaData *client.ObjectMetaData, data []byte) error {
+	return c.doAndClose(http.MethodPut, c.objectURL(metaData.ObjectType, metaData.ObjectID, "data"), data)
+}
+
+// markObjectConsumed marks an object as consumed, so it's not listed again, even after a restart.
+func (c *essClient) markObjectConsumed(metaData *client.ObjectMetaData) error {
+	return c.doAndClose(http.MethodPut, c.objectURL(metaData.ObjectType, metaData.ObjectID, "consumed"), nil)
+}
+
+// markObjectDeleted acknowledges an object that the hub of hubs deleted, so it's not listed again.
+func (c *essClient) markObjectDeleted(metaData *client.ObjectMetaData) error {
+	return c.doAndClose(http.MethodPut, c.objectURL(metaData.ObjectType, metaData.ObjectID, "deleted"), nil)
+}
+
+func (c *essClient) objectURL(objectType string, objectID string, operation string) string {
+	objectURL := c.objectsURL + url.PathEscape(objectType)
+
+	if objectID != "" {
+		objectURL += "/" + url.PathEscape(objectID)
+
+		if operation != "" {
+			objectURL += "/" + operation
+		}
+	}
+
+	return objectURL
+}
+
+func (c *essClient) doAndClose(method string, requestURL string, body []byte) error {
+	response, err := c.do(method, requestURL, body)
+	if err != nil {
+		return err
+	}
+
+	_, _ = io.Copy(ioutil.Discard, response.Body)
+	response.Body.Close()
+
+	return nil
+}
+
+// do sends a request and returns the response if its status is 2xx. a 404 response is reported as errObjectNotFound.
+func (c *essClient) do(method string, requestURL string, body []byte) (*http.Response, error) {
+	request, err := http.NewRequest(method, requestURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the request - %w", err)
+	}
+
+	request.SetBasicAuth(c.appKey, c.appSecret)
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send the request - %w", err)
+	}
+
+	if response.StatusCode >= http.StatusOK && response.StatusCode < http.StatusMultipleChoices {
+		return response, nil
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return nil, errObjectNotFound
+	}
+
+	message, _ := ioutil.ReadAll(io.LimitReader(response.Body, maxErrorMessageSize))
+
+	return nil, fmt.Errorf("%w: %s - %s", errUnexpectedStatus, response.Status, strings.TrimSpace(string(message)))
+}
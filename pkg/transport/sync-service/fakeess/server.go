@@ -0,0 +1,490 @@
+package fakeess
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/open-horizon/edge-sync-service-client/client"
+)
+
+const objectsPath = "/api/v1/objects/"
+
+// Operation is an object endpoint served by the fake Edge Sync Service.
+type Operation string
+
+const (
+	// OperationUpdateObject creates or updates the metadata of an object.
+	OperationUpdateObject Operation = "UpdateObject"
+	// OperationUpdateObjectData updates the data of an existing object.
+	OperationUpdateObjectData Operation = "UpdateObjectData"
+	// OperationGetObjectMetadata returns the metadata of an object.
+	OperationGetObjectMetadata Operation = "GetObjectMetadata"
+	// OperationGetObjectData returns the data of an object.
+	OperationGetObjectData Operation = "GetObjectData"
+	// OperationMarkObjectConsumed marks an object that was sent to the client as consumed.
+	OperationMarkObjectConsumed Operation = "MarkObjectConsumed"
+	// OperationMarkObjectDeleted acknowledges the deletion of an object that was sent to the client.
+	OperationMarkObjectDeleted Operation = "MarkObjectDeleted"
+	// OperationListObjects lists the objects of a type that were sent to the client and weren't consumed or deleted.
+	OperationListObjects Operation = "ListObjects"
+)
+
+// Server is a fake Edge Sync Service that serves the object endpoints used by the sync service transport, keeping the
+// objects in memory. tests can inspect the objects that were sent and inject errors.
+type Server struct {
+	server        *httptest.Server
+	objects       map[string]*Object
+	requestCounts map[Operation]int
+	failures      map[Operation]*failure
+	// appKey and appSecret are the credentials requests must authenticate with, if credentialsSet.
+	appKey           string
+	appSecret        string
+	credentialsSet   bool
+	clientCommonName string
+	lock             sync.Mutex
+}
+
+// Object is an object stored in the fake Edge Sync Service.
+type Object struct {
+	MetaData client.ObjectMetaData
+	Data     []byte
+	// Versions are the versions the object was updated with, in order.
+	Versions []string
+	// Consumed is true if the client marked the object as consumed.
+	Consumed bool
+	// DeletionAcknowledged is true if the client marked the deleted object as deleted.
+	DeletionAcknowledged bool
+}
+
+// failure is an error injected into an operation.
+type failure struct {
+	statusCode int
+	// remaining is the number of requests left to fail, negative means until the failures are cleared.
+	remaining int
+}
+
+// objectUpdatePayload is the body of an update object request.
+type objectUpdatePayload struct {
+	Meta client.ObjectMetaData `json:"meta"`
+}
+
+// NewServer creates and starts a new fake Edge Sync Service, listening on a local port.
+func NewServer() *Server {
+	server := newServer()
+	server.server = httptest.NewServer(http.HandlerFunc(server.handle))
+
+	return server
+}
+
+// NewTLSServer creates and starts a new fake Edge Sync Service that serves https on a local port and requires a
+// client certificate signed by one of the given CAs.
+func NewTLSServer(clientCAs *x509.CertPool) *Server {
+	server := newServer()
+	server.server = httptest.NewUnstartedServer(http.HandlerFunc(server.handle))
+	server.server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.server.StartTLS()
+
+	return server
+}
+
+func newServer() *Server {
+	return &Server{
+		objects:       make(map[string]*Object),
+		requestCounts: make(map[Operation]int),
+		failures:      make(map[Operation]*failure),
+	}
+}
+
+// Close shuts the server down.
+func (server *Server) Close() {
+	server.server.Close()
+}
+
+// Protocol returns the protocol the server listens on.
+func (server *Server) Protocol() string {
+	if server.server.TLS != nil {
+		return "https"
+	}
+
+	return "http"
+}
+
+// CACertificate returns the PEM encoded certificate of an https server, to be trusted by the client.
+func (server *Server) CACertificate() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.server.Certificate().Raw})
+}
+
+// SetCredentials makes the server reject the requests that don't authenticate with the given app key and secret.
+func (server *Server) SetCredentials(appKey string, appSecret string) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	server.appKey, server.appSecret, server.credentialsSet = appKey, appSecret, true
+}
+
+// ClientCommonName returns the common name of the client certificate of the last authorized request to an https
+// server.
+func (server *Server) ClientCommonName() string {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	return server.clientCommonName
+}
+
+// Host returns the host the server listens on.
+func (server *Server) Host() string {
+	serverURL, _ := url.Parse(server.server.URL)
+	return serverURL.Hostname()
+}
+
+// Port returns the port the server listens on.
+func (server *Server) Port() uint16 {
+	serverURL, _ := url.Parse(server.server.URL)
+	port, _ := strconv.ParseUint(serverURL.Port(), 10, 16)
+
+	return uint16(port)
+}
+
+// URL returns the base url of the server.
+func (server *Server) URL() string {
+	return server.server.URL
+}
+
+// Object returns a copy of the object with the given type and id, and whether it exists.
+func (server *Server) Object(objectType string, objectID string) (Object, bool) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	object, found := server.objects[objectKey(objectType, objectID)]
+	if !found {
+		return Object{}, false
+	}
+
+	return object.copy(), true
+}
+
+// AddObject adds an object, e.g. a command object that the hub of hubs sent to the client.
+func (server *Server) AddObject(metaData client.ObjectMetaData, data []byte) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	server.objects[objectKey(metaData.ObjectType, metaData.ObjectID)] = &Object{
+		MetaData: metaData,
+		Data:     append([]byte{}, data...),
+		Versions: []string{metaData.Version},
+	}
+}
+
+// Objects returns copies of all the objects of the given type, or of all the types if objectType is "".
+func (server *Server) Objects(objectType string) []Object {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	objects := make([]Object, 0, len(server.objects))
+
+	for _, object := range server.objects {
+		if objectType == "" || object.MetaData.ObjectType == objectType {
+			objects = append(objects, object.copy())
+		}
+	}
+
+	return objects
+}
+
+// RequestCount returns the number of requests of the given operation that were received, including failed ones.
+func (server *Server) RequestCount(operation Operation) int {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	return server.requestCounts[operation]
+}
+
+// FailNext makes the next count requests of the given operation fail with the given http status code. a count of 0
+// stops failing the operation.
+func (server *Server) FailNext(operation Operation, statusCode int, count int) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	if count == 0 {
+		delete(server.failures, operation)
+		return
+	}
+
+	server.failures[operation] = &failure{statusCode: statusCode, remaining: count}
+}
+
+// FailAlways makes all the requests of the given operation fail with the given http status code, until the failures
+// are cleared.
+func (server *Server) FailAlways(operation Operation, statusCode int) {
+	server.FailNext(operation, statusCode, -1)
+}
+
+// ClearFailures stops failing requests.
+func (server *Server) ClearFailures() {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	server.failures = make(map[Operation]*failure)
+}
+
+// Reset removes all the objects, the request counts and the failures.
+func (server *Server) Reset() {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	server.objects = make(map[string]*Object)
+	server.requestCounts = make(map[Operation]int)
+	server.failures = make(map[Operation]*failure)
+}
+
+func (server *Server) handle(writer http.ResponseWriter, request *http.Request) {
+	// the path is /api/v1/objects/<type>[/<id>[/data|/consumed|/deleted]]
+	pathParts := strings.Split(strings.TrimPrefix(request.URL.Path, objectsPath), "/")
+	if !strings.HasPrefix(request.URL.Path, objectsPath) || len(pathParts) > 3 {
+		http.NotFound(writer, request)
+		return
+	}
+
+	objectType, objectID := pathParts[0], ""
+	if len(pathParts) > 1 {
+		objectID = pathParts[1]
+	}
+
+	var operation Operation
+
+	var handler func(http.ResponseWriter, *http.Request, string, string)
+
+	switch {
+	case len(pathParts) == 1 && request.Method == http.MethodGet:
+		operation, handler = OperationListObjects, server.listObjects
+	case len(pathParts) == 2 && request.Method == http.MethodPut:
+		operation, handler = OperationUpdateObject, server.updateObject
+	case len(pathParts) == 2 && request.Method == http.MethodGet:
+		operation, handler = OperationGetObjectMetadata, server.getObjectMetadata
+	case len(pathParts) == 3 && pathParts[2] == "data" && request.Method == http.MethodPut:
+		operation, handler = OperationUpdateObjectData, server.updateObjectData
+	case len(pathParts) == 3 && pathParts[2] == "data" && request.Method == http.MethodGet:
+		operation, handler = OperationGetObjectData, server.getObjectData
+	case len(pathParts) == 3 && pathParts[2] == "consumed" && request.Method == http.MethodPut:
+		operation, handler = OperationMarkObjectConsumed, server.markObjectConsumed
+	case len(pathParts) == 3 && pathParts[2] == "deleted" && request.Method == http.MethodPut:
+		operation, handler = OperationMarkObjectDeleted, server.markObjectDeleted
+	default:
+		http.NotFound(writer, request)
+		return
+	}
+
+	if !server.authorize(request) {
+		http.Error(writer, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if statusCode, failed := server.countRequest(operation); failed {
+		http.Error(writer, fmt.Sprintf("injected failure of %s", operation), statusCode)
+		return
+	}
+
+	handler(writer, request, objectType, objectID)
+}
+
+// authorize returns true if the request is authenticated with the credentials, when they are set, and records the
+// client certificate it was sent with.
+func (server *Server) authorize(request *http.Request) bool {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	if server.credentialsSet {
+		appKey, appSecret, _ := request.BasicAuth()
+		if appKey != server.appKey || appSecret != server.appSecret {
+			return false
+		}
+	}
+
+	if request.TLS != nil && len(request.TLS.PeerCertificates) > 0 {
+		server.clientCommonName = request.TLS.PeerCertificates[0].Subject.CommonName
+	}
+
+	return true
+}
+
+// countRequest counts a request of the operation and returns the status code and true if it has to fail.
+func (server *Server) countRequest(operation Operation) (int, bool) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	server.requestCounts[operation]++
+
+	injectedFailure, found := server.failures[operation]
+	if !found {
+		return 0, false
+	}
+
+	if injectedFailure.remaining > 0 {
+		injectedFailure.remaining--
+
+		if injectedFailure.remaining == 0 {
+			delete(server.failures, operation)
+		}
+	}
+
+	return injectedFailure.statusCode, true
+}
+
+func (server *Server) updateObject(writer http.ResponseWriter, request *http.Request, objectType string,
+	objectID string) {
+	var payload objectUpdatePayload
+	if err := json.NewDecoder(request.Body).Decode(&payload); err != nil {
+		http.Error(writer, fmt.Sprintf("failed to decode object metadata - %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if payload.Meta.ObjectType != objectType || payload.Meta.ObjectID != objectID {
+		http.Error(writer, "object type and id don't match the url", http.StatusBadRequest)
+		return
+	}
+
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	object, found := server.objects[objectKey(objectType, objectID)]
+	if !found {
+		object = &Object{}
+		server.objects[objectKey(objectType, objectID)] = object
+	}
+
+	object.MetaData = payload.Meta
+	object.Versions = append(object.Versions, payload.Meta.Version)
+}
+
+func (server *Server) updateObjectData(writer http.ResponseWriter, request *http.Request, objectType string,
+	objectID string) {
+	data, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		http.Error(writer, fmt.Sprintf("failed to read object data - %v", err), http.StatusBadRequest)
+		return
+	}
+
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	object, found := server.objects[objectKey(objectType, objectID)]
+	if !found {
+		http.NotFound(writer, request)
+		return
+	}
+
+	object.Data = data
+}
+
+func (server *Server) getObjectMetadata(writer http.ResponseWriter, request *http.Request, objectType string,
+	objectID string) {
+	server.lock.Lock()
+	object, found := server.objects[objectKey(objectType, objectID)]
+
+	var metaData client.ObjectMetaData
+	if found {
+		metaData = object.MetaData
+	}
+	server.lock.Unlock()
+
+	if !found {
+		http.NotFound(writer, request)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(writer).Encode(&metaData)
+}
+
+func (server *Server) getObjectData(writer http.ResponseWriter, request *http.Request, objectType string,
+	objectID string) {
+	server.lock.Lock()
+	object, found := server.objects[objectKey(objectType, objectID)]
+
+	var data []byte
+	if found {
+		data = append([]byte{}, object.Data...)
+	}
+	server.lock.Unlock()
+
+	if !found {
+		http.NotFound(writer, request)
+		return
+	}
+
+	_, _ = writer.Write(data)
+}
+
+func (server *Server) markObjectConsumed(writer http.ResponseWriter, request *http.Request, objectType string,
+	objectID string) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	object, found := server.objects[objectKey(objectType, objectID)]
+	if !found {
+		http.NotFound(writer, request)
+		return
+	}
+
+	object.Consumed = true
+}
+
+func (server *Server) markObjectDeleted(writer http.ResponseWriter, request *http.Request, objectType string,
+	objectID string) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	object, found := server.objects[objectKey(objectType, objectID)]
+	if !found {
+		http.NotFound(writer, request)
+		return
+	}
+
+	object.DeletionAcknowledged = true
+}
+
+// listObjects responds with not found if there are no objects to list, as the Edge Sync Service does.
+func (server *Server) listObjects(writer http.ResponseWriter, request *http.Request, objectType string, _ string) {
+	server.lock.Lock()
+
+	var metaData []client.ObjectMetaData
+
+	for _, object := range server.objects {
+		if object.MetaData.ObjectType == objectType && !object.Consumed && !object.DeletionAcknowledged {
+			metaData = append(metaData, object.MetaData)
+		}
+	}
+	server.lock.Unlock()
+
+	if len(metaData) == 0 {
+		http.NotFound(writer, request)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(writer).Encode(metaData)
+}
+
+func (object *Object) copy() Object {
+	return Object{
+		MetaData:             object.MetaData,
+		Data:                 append([]byte{}, object.Data...),
+		Versions:             append([]string{}, object.Versions...),
+		Consumed:             object.Consumed,
+		DeletionAcknowledged: object.DeletionAcknowledged,
+	}
+}
+
+func objectKey(objectType string, objectID string) string {
+	return fmt.Sprintf("%s/%s", objectType, objectID)
+}
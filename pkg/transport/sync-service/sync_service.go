@@ -1,14 +1,16 @@
 package syncservice
 
 import (
-	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
 	"github.com/open-horizon/edge-sync-service-client/client"
 )
 
@@ -16,94 +18,279 @@ const (
 	envVarSyncServiceProtocol = "SYNC_SERVICE_PROTOCOL"
 	envVarSyncServiceHost     = "SYNC_SERVICE_HOST"
 	envVarSyncServicePort     = "SYNC_SERVICE_PORT"
+
+	envVarCredentialsDir             = "SYNC_SERVICE_CREDENTIALS_DIR"
+	envVarCACertPath                 = "SYNC_SERVICE_CA_CERT_PATH"
+	envVarClientCertPath             = "SYNC_SERVICE_CLIENT_CERT_PATH"
+	envVarClientKeyPath              = "SYNC_SERVICE_CLIENT_KEY_PATH"
+	envVarCredentialsRefreshInterval = "SYNC_SERVICE_CREDENTIALS_REFRESH_INTERVAL"
+
+	envVarMaxRetries                     = "SYNC_SERVICE_MAX_RETRIES"
+	envVarRetryInitialBackoff            = "SYNC_SERVICE_RETRY_INITIAL_BACKOFF"
+	envVarRetryMaxBackoff                = "SYNC_SERVICE_RETRY_MAX_BACKOFF"
+	envVarCircuitBreakerFailureThreshold = "SYNC_SERVICE_CIRCUIT_BREAKER_FAILURE_THRESHOLD"
+	envVarCircuitBreakerCooldown         = "SYNC_SERVICE_CIRCUIT_BREAKER_COOLDOWN"
+	envVarDrainTimeout                   = "SYNC_SERVICE_DRAIN_TIMEOUT"
+	envVarCommandsObjectType             = "SYNC_SERVICE_COMMANDS_OBJECT_TYPE"
+	envVarCommandsPollingInterval        = "SYNC_SERVICE_COMMANDS_POLLING_INTERVAL"
+
+	defaultMaxRetries                     = 5
+	defaultRetryInitialBackoff            = time.Second
+	defaultRetryMaxBackoff                = 30 * time.Second
+	defaultCircuitBreakerFailureThreshold = 5
+	defaultCircuitBreakerCooldown         = time.Minute
+	defaultDrainTimeout                   = 10 * time.Second
+	defaultCredentialsRefreshInterval     = time.Minute
+	defaultCommandsObjectType             = "LeafHubCommand"
+	defaultCommandsPollingInterval        = 10 * time.Second
 )
 
 var (
-	errEnvVarNotFound  = errors.New("not found environment variable")
-	errEnvVarWrongType = errors.New("wrong type of environment variable")
+	errEnvVarNotFound     = errors.New("not found environment variable")
+	errEnvVarWrongType    = errors.New("wrong type of environment variable")
+	errEnvVarIllegalValue = errors.New("illegal value of environment variable")
+	errSyncServiceStopped = errors.New("sync service was stopped")
 )
 
+// TransportType is the transport type the sync service transport is registered under.
+const TransportType = "sync-service"
+
+func init() {
+	transport.Register(TransportType, func(log logr.Logger) (transport.Service, error) {
+		return NewSyncService(log)
+	})
+}
+
 // SyncService abstracts Sync Service client.
 type SyncService struct {
-	client    *client.SyncServiceClient
-	msgChan   chan *syncServiceMessage
-	stopChan  chan struct{}
-	startOnce sync.Once
-	stopOnce  sync.Once
-	log       logr.Logger
+	clientConfig               *clientConfig
+	client                     *essClient
+	clientChecksum             string
+	clientLock                 sync.RWMutex
+	credentialsRefreshInterval time.Duration
+	commandsObjectType         string
+	commandsPollingInterval    time.Duration
+	commandDispatcher          *transport.CommandDispatcher
+	queue                      *transport.MessageQueue
+	retryPolicy                *transport.RetryPolicy
+	circuitBreaker             *transport.CircuitBreaker
+	drainTimeout               time.Duration
+	drainChan                  chan struct{}
+	doneChan                   chan struct{}
+	stopChan                   chan struct{}
+	startOnce                  sync.Once
+	stopOnce                   sync.Once
+	log                        logr.Logger
 }
 
 // NewSyncService creates a new instance of SyncService.
 func NewSyncService(log logr.Logger) (*SyncService, error) {
-	serverProtocol, host, port, err := readEnvVars()
+	syncServiceClientConfig, err := readEnvVars()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize sync service - %w", err)
+	}
+
+	retryPolicy, failureThreshold, cooldown, err := readRetryEnvVars()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize sync service - %w", err)
+	}
+
+	drainTimeout, err := readDurationEnvVar(envVarDrainTimeout, defaultDrainTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize sync service - %w", err)
+	}
+
+	credentialsRefreshInterval, err := readDurationEnvVar(envVarCredentialsRefreshInterval,
+		defaultCredentialsRefreshInterval)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize sync service - %w", err)
 	}
 
-	syncServiceClient := client.NewSyncServiceClient(serverProtocol, host, port)
+	commandsObjectType := os.Getenv(envVarCommandsObjectType)
+	if commandsObjectType == "" {
+		commandsObjectType = defaultCommandsObjectType
+	}
+
+	commandsPollingInterval, err := readDurationEnvVar(envVarCommandsPollingInterval, defaultCommandsPollingInterval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize sync service - %w", err)
+	}
 
-	syncServiceClient.SetAppKeyAndSecret("user@myorg", "")
+	if commandsPollingInterval <= 0 {
+		return nil, fmt.Errorf("failed to initialize sync service - %w: %s must be positive", errEnvVarIllegalValue,
+			envVarCommandsPollingInterval)
+	}
+
+	queueCapacity, err := transport.ReadMessageQueueCapacity()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize sync service - %w", err)
+	}
+
+	syncServiceClient, clientChecksum, err := syncServiceClientConfig.createClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize sync service - %w", err)
+	}
 
 	return &SyncService{
-		client:   syncServiceClient,
-		log:      log,
-		msgChan:  make(chan *syncServiceMessage),
-		stopChan: make(chan struct{}, 1),
+		clientConfig:               syncServiceClientConfig,
+		client:                     syncServiceClient,
+		clientChecksum:             clientChecksum,
+		credentialsRefreshInterval: credentialsRefreshInterval,
+		commandsObjectType:         commandsObjectType,
+		commandsPollingInterval:    commandsPollingInterval,
+		commandDispatcher:          transport.NewCommandDispatcher(),
+		log:                        log,
+		queue:                      transport.NewMessageQueue("sync-service", queueCapacity),
+		retryPolicy:                retryPolicy,
+		circuitBreaker:             transport.NewCircuitBreaker("sync-service", failureThreshold, cooldown, log),
+		drainTimeout:               drainTimeout,
+		drainChan:                  make(chan struct{}),
+		doneChan:                   make(chan struct{}),
+		stopChan:                   make(chan struct{}, 1),
 	}, nil
 }
 
-func readEnvVars() (string, string, uint16, error) {
+func readEnvVars() (*clientConfig, error) {
 	protocol := os.Getenv(envVarSyncServiceProtocol)
 	if protocol == "" {
-		return "", "", 0, fmt.Errorf("%w: %s", errEnvVarNotFound, envVarSyncServiceProtocol)
+		return nil, fmt.Errorf("%w: %s", errEnvVarNotFound, envVarSyncServiceProtocol)
 	}
 
 	host := os.Getenv(envVarSyncServiceHost)
 	if host == "" {
-		return "", "", 0, fmt.Errorf("%w: %s", errEnvVarNotFound, envVarSyncServiceHost)
+		return nil, fmt.Errorf("%w: %s", errEnvVarNotFound, envVarSyncServiceHost)
 	}
 
 	portStr := os.Getenv(envVarSyncServicePort)
 	if portStr == "" {
-		return "", "", 0, fmt.Errorf("%w: %s", errEnvVarNotFound, envVarSyncServicePort)
+		return nil, fmt.Errorf("%w: %s", errEnvVarNotFound, envVarSyncServicePort)
 	}
 
 	port, err := strconv.Atoi(portStr)
 	if err != nil {
-		return "", "", 0, fmt.Errorf("%w: %s must be an integer", errEnvVarWrongType, envVarSyncServicePort)
+		return nil, fmt.Errorf("%w: %s must be an integer", errEnvVarWrongType, envVarSyncServicePort)
+	}
+
+	clientCertPath := os.Getenv(envVarClientCertPath)
+	clientKeyPath := os.Getenv(envVarClientKeyPath)
+
+	if (clientCertPath == "") != (clientKeyPath == "") {
+		return nil, fmt.Errorf("%w: %s and %s must be set together", errEnvVarIllegalValue, envVarClientCertPath,
+			envVarClientKeyPath)
+	}
+
+	return &clientConfig{
+		protocol:       protocol,
+		host:           host,
+		port:           uint16(port),
+		credentialsDir: os.Getenv(envVarCredentialsDir),
+		caCertPath:     os.Getenv(envVarCACertPath),
+		clientCertPath: clientCertPath,
+		clientKeyPath:  clientKeyPath,
+	}, nil
+}
+
+func readRetryEnvVars() (*transport.RetryPolicy, int, time.Duration, error) {
+	maxRetries, err := readIntEnvVar(envVarMaxRetries, defaultMaxRetries)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	initialBackoff, err := readDurationEnvVar(envVarRetryInitialBackoff, defaultRetryInitialBackoff)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	maxBackoff, err := readDurationEnvVar(envVarRetryMaxBackoff, defaultRetryMaxBackoff)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	failureThreshold, err := readIntEnvVar(envVarCircuitBreakerFailureThreshold, defaultCircuitBreakerFailureThreshold)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	cooldown, err := readDurationEnvVar(envVarCircuitBreakerCooldown, defaultCircuitBreakerCooldown)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	retryPolicy := &transport.RetryPolicy{
+		MaxRetries:     maxRetries,
+		InitialBackoff: initialBackoff,
+		MaxBackoff:     maxBackoff,
+	}
+
+	return retryPolicy, failureThreshold, cooldown, nil
+}
+
+// readIntEnvVar returns the default value if the environment variable is not set.
+func readIntEnvVar(name string, defaultValue int) (int, error) {
+	valueStr := os.Getenv(name)
+	if valueStr == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s must be an integer", errEnvVarWrongType, name)
 	}
 
-	return protocol, host, uint16(port), nil
+	return value, nil
+}
+
+// readDurationEnvVar returns the default value if the environment variable is not set.
+func readDurationEnvVar(name string, defaultValue time.Duration) (time.Duration, error) {
+	valueStr := os.Getenv(name)
+	if valueStr == "" {
+		return defaultValue, nil
+	}
+
+	value, err := time.ParseDuration(valueStr)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s must be a duration", errEnvVarWrongType, name)
+	}
+
+	return value, nil
 }
 
 // Start function starts sync service.
 func (s *SyncService) Start() {
 	s.startOnce.Do(func() {
 		go s.sendMessages()
+		go s.refreshCredentialsPeriodically()
+		go s.receiveCommands()
 	})
 }
 
-// Stop function stops sync service.
+// Stop function stops sync service. messages that are waiting in the queue are sent until the drain timeout expires.
 func (s *SyncService) Stop() {
 	s.stopOnce.Do(func() {
+		s.queue.Close() // messages that are sent from now on are reported as failed
+		close(s.drainChan)
+
+		if transport.WasStarted(&s.startOnce) {
+			select {
+			case <-s.doneChan:
+				s.log.Info("all messages were sent")
+			case <-time.After(s.drainTimeout):
+				s.log.Info(fmt.Sprintf("drain timeout expired, %d messages were not sent", s.queue.Len()))
+			}
+		}
+
 		close(s.stopChan)
 	})
 }
 
 // SendAsync function sends a message to the sync service asynchronously.
-func (s *SyncService) SendAsync(id string, msgType string, version string, payload []byte) {
-	message := &syncServiceMessage{
-		id:      id,
-		msgType: msgType,
-		version: version,
-		payload: payload,
-	}
-	s.msgChan <- message
+func (s *SyncService) SendAsync(message *transport.Message) {
+	s.queue.Push(message)
 }
 
 // GetVersion if the object doesn't exist or an error occurred returns an empty string, otherwise returns the version.
 func (s *SyncService) GetVersion(id string, msgType string) string {
-	objectMetadata, err := s.client.GetObjectMetadata(msgType, id)
+	objectMetadata, err := s.getClient().getObjectMetadata(msgType, id)
 	if err != nil {
 		return ""
 	}
@@ -111,31 +298,183 @@ func (s *SyncService) GetVersion(id string, msgType string) string {
 	return objectMetadata.Version
 }
 
+// Subscribe subscribes the handler to commands of the given type. commands are objects of the commands object type
+// that the hub of hubs sends to this leaf hub through the Edge Sync Service, their data is a json encoded
+// transport.Command.
+func (s *SyncService) Subscribe(commandType string, handler transport.CommandHandler) {
+	s.commandDispatcher.Subscribe(commandType, handler)
+}
+
 func (s *SyncService) sendMessages() {
+	defer close(s.doneChan)
+
 	for {
+		msg := s.queue.Pop(s.drainChan)
+		if msg == nil { // drained
+			return
+		}
+
 		select {
-		case <-s.stopChan:
+		case <-s.stopChan: // drain timeout expired
+			msg.ReportDeliveryResult(errSyncServiceStopped)
 			return
-		case msg := <-s.msgChan:
-			metaData := client.ObjectMetaData{
-				ObjectID:   msg.id,
-				ObjectType: msg.msgType,
-				Version:    msg.version,
-			}
+		default:
+		}
 
-			if err := s.client.UpdateObject(&metaData); err != nil {
-				s.log.Error(err, "Failed to update the object in the Edge Sync Service")
-				continue
-			}
+		msg.ReportDeliveryResult(s.sendMessageWithRetries(msg))
+	}
+}
 
-			reader := bytes.NewReader(msg.payload)
-			if err := s.client.UpdateObjectData(&metaData, reader); err != nil {
-				s.log.Error(err, "Failed to update the object data in the Edge Sync Service")
-				continue
-			}
+// sendMessageWithRetries resends a failed message according to the retry policy. while the circuit breaker is open,
+// no attempt is made. the message is abandoned if a newer message with the same id and type is waiting in the queue.
+func (s *SyncService) sendMessageWithRetries(msg *transport.Message) error {
+	for attempt := 0; ; attempt++ {
+		if err := s.waitForCircuitBreaker(msg); err != nil {
+			return err
+		}
+
+		err := s.sendMessage(msg)
+		s.circuitBreaker.RecordResult(err)
+
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= s.retryPolicy.MaxRetries {
+			return err
+		}
+
+		if err := s.waitBeforeResend(msg, s.retryPolicy.Backoff(attempt)); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *SyncService) waitForCircuitBreaker(msg *transport.Message) error {
+	for {
+		waitDuration := s.circuitBreaker.WaitDuration()
+		if waitDuration == 0 {
+			return nil
+		}
+
+		if err := s.waitBeforeResend(msg, waitDuration); err != nil {
+			return err
+		}
+	}
+}
+
+// waitBeforeResend returns an error if sync service was stopped or if the message was superseded while waiting.
+func (s *SyncService) waitBeforeResend(msg *transport.Message, waitDuration time.Duration) error {
+	timer := time.NewTimer(waitDuration)
+	defer timer.Stop()
+
+	select {
+	case <-s.stopChan:
+		return errSyncServiceStopped
+	case <-timer.C:
+	}
+
+	if s.queue.Contains(msg.ID, msg.MsgType) {
+		s.log.Info(fmt.Sprintf("Message '%s' from type '%s' with version '%s' was superseded, not resending",
+			msg.ID, msg.MsgType, msg.Version))
+		return transport.ErrMessageSuperseded
+	}
+
+	return nil
+}
+
+func (s *SyncService) sendMessage(msg *transport.Message) error {
+	description, err := encodeMetadata(msg.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode message metadata - %w", err)
+	}
+
+	metaData := client.ObjectMetaData{
+		ObjectID:    msg.ID,
+		ObjectType:  msg.MsgType,
+		Version:     msg.Version,
+		Description: description,
+	}
+
+	syncServiceClient := s.getClient()
+
+	if err := syncServiceClient.updateObject(&metaData); err != nil {
+		s.log.Error(err, "Failed to update the object in the Edge Sync Service")
+		return fmt.Errorf("failed to update the object in the Edge Sync Service - %w", err)
+	}
+
+	if err := syncServiceClient.updateObjectData(&metaData, msg.Payload); err != nil {
+		s.log.Error(err, "Failed to update the object data in the Edge Sync Service")
+		return fmt.Errorf("failed to update the object data in the Edge Sync Service - %w", err)
+	}
+
+	s.log.Info(fmt.Sprintf("Message '%s' from type '%s' with version '%s' sent", msg.ID, msg.MsgType, msg.Version))
+
+	return nil
+}
+
+// encodeMetadata encodes the message metadata as json, to be carried in the object description.
+func encodeMetadata(metadata map[string]string) (string, error) {
+	if len(metadata) == 0 {
+		return "", nil
+	}
+
+	metadataBytes, err := json.Marshal(metadata)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal metadata - %w", err)
+	}
+
+	return string(metadataBytes), nil
+}
+
+func (s *SyncService) getClient() *essClient {
+	s.clientLock.RLock()
+	defer s.clientLock.RUnlock()
 
-			s.log.Info(fmt.Sprintf("Message '%s' from type '%s' with version '%s' sent", msg.id, msg.msgType,
-				msg.version))
+	return s.client
+}
+
+// refreshCredentialsPeriodically recreates the client when the mounted credentials, CA or client certificate files
+// are rotated.
+func (s *SyncService) refreshCredentialsPeriodically() {
+	ticker := time.NewTicker(s.credentialsRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+			s.refreshCredentials()
 		}
 	}
 }
+
+func (s *SyncService) refreshCredentials() {
+	checksum, err := s.clientConfig.checksum()
+	if err != nil {
+		s.log.Error(err, "Failed to read the Edge Sync Service credentials")
+		return
+	}
+
+	s.clientLock.RLock()
+	changed := checksum != s.clientChecksum
+	s.clientLock.RUnlock()
+
+	if !changed {
+		return
+	}
+
+	syncServiceClient, checksum, err := s.clientConfig.createClient()
+	if err != nil {
+		s.log.Error(err, "Failed to recreate the Edge Sync Service client with the rotated credentials")
+		return
+	}
+
+	s.clientLock.Lock()
+	s.client = syncServiceClient
+	s.clientChecksum = checksum
+	s.clientLock.Unlock()
+
+	s.log.Info("Edge Sync Service credentials were rotated, client was recreated")
+}
@@ -0,0 +1,93 @@
+package syncservice
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+	"github.com/open-horizon/edge-sync-service-client/client"
+)
+
+// receiveCommands polls the Edge Sync Service for command objects and dispatches them to the subscribed handlers.
+// each poll uses the current client, so polling moves to a client that was recreated with rotated credentials.
+func (s *SyncService) receiveCommands() {
+	ticker := time.NewTicker(s.commandsPollingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+			s.pollCommands()
+		}
+	}
+}
+
+// pollCommands handles the command objects that weren't consumed yet. the Edge Sync Service lists an object until
+// it's marked as consumed, so a command that no handler handled is dispatched again in the next poll.
+func (s *SyncService) pollCommands() {
+	syncServiceClient := s.getClient()
+
+	objects, err := syncServiceClient.listObjects(s.commandsObjectType)
+	if err != nil {
+		s.log.Error(err, "Failed to poll for command objects")
+		return
+	}
+
+	for i := range objects {
+		select {
+		case <-s.stopChan:
+			return
+		default:
+			s.handleCommandObject(syncServiceClient, &objects[i])
+		}
+	}
+}
+
+func (s *SyncService) handleCommandObject(syncServiceClient *essClient, objectMetadata *client.ObjectMetaData) {
+	if objectMetadata.Deleted {
+		if err := syncServiceClient.markObjectDeleted(objectMetadata); err != nil {
+			s.log.Error(err, "Failed to mark the command object as deleted", "id", objectMetadata.ObjectID)
+		}
+
+		return
+	}
+
+	data, err := syncServiceClient.fetchObjectData(objectMetadata)
+	if err != nil {
+		s.log.Error(err, "Failed to fetch the data of the command object", "id", objectMetadata.ObjectID)
+		return
+	}
+
+	command := &transport.Command{}
+
+	if err := json.Unmarshal(data, command); err != nil {
+		s.log.Error(err, "Failed to parse the command object, ignoring it", "id", objectMetadata.ObjectID)
+	} else {
+		if command.ID == "" {
+			command.ID = objectMetadata.ObjectID
+		}
+
+		s.log.Info(fmt.Sprintf("Command '%s' from type '%s' received", command.ID, command.Type))
+
+		err := s.commandDispatcher.Dispatch(command)
+		if errors.Is(err, transport.ErrCommandIgnored) || errors.Is(err, transport.ErrNoCommandHandler) {
+			// not consumed, so it's dispatched again in the next poll, e.g. once the handler is ready to handle it
+			s.log.Info(fmt.Sprintf("Command '%s' from type '%s' was not handled - %v", command.ID, command.Type,
+				err))
+			return
+		}
+
+		if err != nil {
+			s.log.Error(err, "Failed to handle command", "id", command.ID, "type", command.Type)
+		}
+	}
+
+	// a failed command is not dispatched again, unless marking it as consumed fails
+	if err := syncServiceClient.markObjectConsumed(objectMetadata); err != nil {
+		s.log.Error(err, "Failed to mark the command object as consumed", "id", objectMetadata.ObjectID)
+	}
+}
@@ -0,0 +1,177 @@
+package syncservice
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/sync-service/fakeess"
+	"github.com/open-horizon/edge-sync-service-client/client"
+)
+
+const (
+	testCommandID       = "command-1"
+	testTimeout         = 5 * time.Second
+	testPollingInterval = 10 * time.Millisecond
+)
+
+var errHandlerFailed = errors.New("handler failed")
+
+func newTestSyncServiceClient(t *testing.T, server *fakeess.Server) *essClient {
+	t.Helper()
+
+	config := &clientConfig{protocol: server.Protocol(), host: server.Host(), port: server.Port()}
+
+	syncServiceClient, _, err := config.createClient()
+	if err != nil {
+		t.Fatalf("failed to create the sync service client: %v", err)
+	}
+
+	return syncServiceClient
+}
+
+// addCommandObject adds a resync command object and returns its metadata.
+func addCommandObject(t *testing.T, server *fakeess.Server) *client.ObjectMetaData {
+	t.Helper()
+
+	data, err := json.Marshal(&transport.Command{ID: testCommandID, Type: transport.CommandTypeResync})
+	if err != nil {
+		t.Fatalf("failed to marshal the command: %v", err)
+	}
+
+	metaData := client.ObjectMetaData{ObjectType: defaultCommandsObjectType, ObjectID: testCommandID}
+	server.AddObject(metaData, data)
+
+	return &metaData
+}
+
+func TestHandleCommandObjectMarksOnlyHandledCommandsConsumed(t *testing.T) {
+	for name, test := range map[string]struct {
+		handlers         []transport.CommandHandler
+		expectedConsumed bool
+	}{
+		"handled": {
+			handlers:         []transport.CommandHandler{func(*transport.Command) error { return nil }},
+			expectedConsumed: true,
+		},
+		"failed": {
+			handlers:         []transport.CommandHandler{func(*transport.Command) error { return errHandlerFailed }},
+			expectedConsumed: true,
+		},
+		"ignored": {
+			handlers: []transport.CommandHandler{
+				func(*transport.Command) error { return transport.ErrCommandIgnored },
+				func(*transport.Command) error { return transport.ErrCommandIgnored },
+			},
+			expectedConsumed: false,
+		},
+		"handled by one of the handlers": {
+			handlers: []transport.CommandHandler{
+				func(*transport.Command) error { return transport.ErrCommandIgnored },
+				func(*transport.Command) error { return nil },
+			},
+			expectedConsumed: true,
+		},
+		"no handler": {
+			expectedConsumed: false,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			server := fakeess.NewServer()
+			defer server.Close()
+
+			syncService := &SyncService{
+				commandDispatcher: transport.NewCommandDispatcher(),
+				log:               logrtesting.NullLogger{},
+			}
+
+			for _, handler := range test.handlers {
+				syncService.Subscribe(transport.CommandTypeResync, handler)
+			}
+
+			syncService.handleCommandObject(newTestSyncServiceClient(t, server), addCommandObject(t, server))
+
+			object, _ := server.Object(defaultCommandsObjectType, testCommandID)
+			if object.Consumed != test.expectedConsumed {
+				t.Fatalf("expected consumed to be %t, got %t", test.expectedConsumed, object.Consumed)
+			}
+		})
+	}
+}
+
+func TestIgnoredCommandIsDispatchedAgainOnceTheHandlerIsReady(t *testing.T) {
+	server := fakeess.NewServer()
+	defer server.Close()
+
+	setEnvVar(t, envVarCommandsPollingInterval, testPollingInterval.String())
+
+	syncService := newTestSyncService(t, server)
+	handledChan := make(chan struct{})
+	attempts := 0
+
+	// e.g. a resync that arrives before the periodic sync runs
+	syncService.Subscribe(transport.CommandTypeResync, func(*transport.Command) error {
+		if attempts++; attempts < 3 {
+			return transport.ErrCommandIgnored
+		}
+
+		close(handledChan)
+
+		return nil
+	})
+
+	addCommandObject(t, server)
+	syncService.Start()
+	defer syncService.Stop()
+
+	select {
+	case <-handledChan:
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for the ignored command to be dispatched again")
+	}
+
+	waitForCondition(t, func() bool {
+		object, _ := server.Object(defaultCommandsObjectType, testCommandID)
+		return object.Consumed
+	})
+}
+
+func TestDeletedCommandIsNotDispatched(t *testing.T) {
+	server := fakeess.NewServer()
+	defer server.Close()
+
+	syncService := &SyncService{
+		commandDispatcher: transport.NewCommandDispatcher(),
+		log:               logrtesting.NullLogger{},
+	}
+	syncService.Subscribe(transport.CommandTypeResync, func(*transport.Command) error {
+		t.Fatal("expected the deleted command not to be dispatched")
+		return nil
+	})
+
+	objectMetadata := addCommandObject(t, server)
+	objectMetadata.Deleted = true
+
+	syncService.handleCommandObject(newTestSyncServiceClient(t, server), objectMetadata)
+
+	if object, _ := server.Object(defaultCommandsObjectType, testCommandID); !object.DeletionAcknowledged {
+		t.Fatal("expected the deletion to be acknowledged")
+	}
+}
+
+func waitForCondition(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(testTimeout)
+
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the condition")
+		}
+
+		time.Sleep(testPollingInterval)
+	}
+}
@@ -0,0 +1,34 @@
+package syncservice
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/conformance"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/sync-service/fakeess"
+)
+
+func TestConformance(t *testing.T) {
+	server := fakeess.NewServer()
+	defer server.Close()
+
+	for envVar, value := range map[string]string{
+		envVarSyncServiceProtocol: server.Protocol(),
+		envVarSyncServiceHost:     server.Host(),
+		envVarSyncServicePort:     strconv.Itoa(int(server.Port())),
+	} {
+		if err := os.Setenv(envVar, value); err != nil {
+			t.Fatalf("failed to set %s: %v", envVar, err)
+		}
+
+		defer os.Unsetenv(envVar)
+	}
+
+	conformance.Run(t, func() (transport.Transport, error) {
+		return NewSyncService(logrtesting.NullLogger{})
+	}, &conformance.Options{DeliveryTimeout: 10 * time.Second})
+}
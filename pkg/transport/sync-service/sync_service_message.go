@@ -1,8 +0,0 @@
-package syncservice
-
-type syncServiceMessage struct {
-	id      string
-	msgType string
-	version string
-	payload []byte
-}
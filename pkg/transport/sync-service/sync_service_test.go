@@ -0,0 +1,167 @@
+package syncservice
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport/sync-service/fakeess"
+)
+
+func setEnvVar(t *testing.T, name string, value string) {
+	t.Helper()
+
+	if err := os.Setenv(name, value); err != nil {
+		t.Fatalf("failed to set %s: %v", name, err)
+	}
+
+	t.Cleanup(func() { _ = os.Unsetenv(name) })
+}
+
+func newTestSyncService(t *testing.T, server *fakeess.Server) *SyncService {
+	t.Helper()
+
+	setEnvVar(t, envVarSyncServiceProtocol, server.Protocol())
+	setEnvVar(t, envVarSyncServiceHost, server.Host())
+	setEnvVar(t, envVarSyncServicePort, strconv.Itoa(int(server.Port())))
+	setEnvVar(t, envVarDrainTimeout, time.Minute.String())
+
+	syncService, err := NewSyncService(logrtesting.NullLogger{})
+	if err != nil {
+		t.Fatalf("failed to create the sync service: %v", err)
+	}
+
+	return syncService
+}
+
+func TestStopWithoutStartDoesNotWaitForTheDrainTimeout(t *testing.T) {
+	server := fakeess.NewServer()
+	defer server.Close()
+
+	syncService := newTestSyncService(t, server)
+	stoppedChan := make(chan struct{})
+
+	go func() {
+		syncService.Stop()
+		close(stoppedChan)
+	}()
+
+	select {
+	case <-stoppedChan:
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for the sync service to stop")
+	}
+}
+
+func TestSendAsyncAfterStopReportsStopped(t *testing.T) {
+	server := fakeess.NewServer()
+	defer server.Close()
+
+	syncService := newTestSyncService(t, server)
+	syncService.Start()
+	syncService.Stop()
+
+	resultChan := make(chan error, 1)
+
+	syncService.SendAsync(&transport.Message{
+		ID:               "hub1.policies",
+		MsgType:          "StatusBundle",
+		Version:          "1",
+		DeliveryCallback: func(err error) { resultChan <- err },
+	})
+
+	select {
+	case err := <-resultChan:
+		if !errors.Is(err, transport.ErrTransportStopped) {
+			t.Fatalf("expected %v, got %v", transport.ErrTransportStopped, err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for the delivery result")
+	}
+}
+
+func TestSendAsyncReportsTheErrorAfterTheMaxRetries(t *testing.T) {
+	server := fakeess.NewServer()
+	defer server.Close()
+
+	server.FailAlways(fakeess.OperationUpdateObject, http.StatusInternalServerError)
+
+	setEnvVar(t, envVarMaxRetries, "2")
+	setEnvVar(t, envVarRetryInitialBackoff, time.Millisecond.String())
+	setEnvVar(t, envVarRetryMaxBackoff, time.Millisecond.String())
+	setEnvVar(t, envVarCircuitBreakerFailureThreshold, "10")
+
+	syncService := newTestSyncService(t, server)
+	syncService.Start()
+	defer syncService.Stop()
+
+	resultChan := make(chan error, 1)
+
+	syncService.SendAsync(&transport.Message{
+		ID:               "hub1.policies",
+		MsgType:          "StatusBundle",
+		Version:          "1",
+		DeliveryCallback: func(err error) { resultChan <- err },
+	})
+
+	select {
+	case err := <-resultChan:
+		if err == nil {
+			t.Fatal("expected the delivery to fail")
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for the delivery result")
+	}
+
+	if requests := server.RequestCount(fakeess.OperationUpdateObject); requests != 3 {
+		t.Fatalf("expected the first attempt and 2 retries, got %d requests", requests)
+	}
+}
+
+func TestSendAsyncWaitsWhileTheCircuitBreakerIsOpen(t *testing.T) {
+	server := fakeess.NewServer()
+	defer server.Close()
+
+	server.FailAlways(fakeess.OperationUpdateObject, http.StatusInternalServerError)
+
+	setEnvVar(t, envVarMaxRetries, "1")
+	setEnvVar(t, envVarRetryInitialBackoff, time.Millisecond.String())
+	setEnvVar(t, envVarRetryMaxBackoff, time.Millisecond.String())
+	setEnvVar(t, envVarCircuitBreakerFailureThreshold, "1")
+	setEnvVar(t, envVarCircuitBreakerCooldown, time.Minute.String())
+
+	syncService := newTestSyncService(t, server)
+	syncService.drainTimeout = 0 // don't wait for the cooldown when stopping
+	syncService.Start()
+
+	resultChan := make(chan error, 1)
+
+	syncService.SendAsync(&transport.Message{
+		ID:               "hub1.policies",
+		MsgType:          "StatusBundle",
+		Version:          "1",
+		DeliveryCallback: func(err error) { resultChan <- err },
+	})
+
+	// the first failure opens the circuit breaker, the retry waits for the cooldown until the service is stopped
+	time.Sleep(100 * time.Millisecond)
+	syncService.Stop()
+
+	select {
+	case err := <-resultChan:
+		if !errors.Is(err, errSyncServiceStopped) {
+			t.Fatalf("expected %v, got %v", errSyncServiceStopped, err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for the delivery result")
+	}
+
+	if requests := server.RequestCount(fakeess.OperationUpdateObject); requests != 1 {
+		t.Fatalf("expected no retry while the circuit breaker is open, got %d requests", requests)
+	}
+}
@@ -1,7 +1,77 @@
 package transport
 
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
 // Transport is the transport layer interface to be consumed by the leaf hub status sync.
 type Transport interface {
-	SendAsync(id string, msgType string, version string, payload []byte)
+	// SendAsync sends a message asynchronously. the delivery result is reported using the message delivery callback.
+	SendAsync(message *Message)
+	// GetVersion returns the version of the last message sent with the given id and type, or empty string if unknown.
 	GetVersion(id string, msgType string) string
+	// Subscribe registers a handler for commands of the given type that are received from the hub of hubs.
+	// transports that can't receive commands never invoke the handler.
+	Subscribe(commandType string, handler CommandHandler)
+}
+
+// DeliveryCallback is invoked once the delivery of a message completes. err is nil if the message was delivered.
+type DeliveryCallback func(err error)
+
+// Message abstracts a message that is sent via the transport layer.
+type Message struct {
+	ID      string
+	MsgType string
+	Version string
+	Payload []byte
+	// Metadata holds additional information the receiver needs in order to process the payload, e.g. its encoding.
+	Metadata         map[string]string
+	DeliveryCallback DeliveryCallback
+}
+
+// SetMetadata sets a metadata entry of the message.
+func (message *Message) SetMetadata(key string, value string) {
+	if message.Metadata == nil {
+		message.Metadata = make(map[string]string)
+	}
+
+	message.Metadata[key] = value
+}
+
+// ReportDeliveryResult reports the delivery result of the message to the delivery callback, if one was set.
+func (message *Message) ReportDeliveryResult(err error) {
+	if message.DeliveryCallback != nil {
+		message.DeliveryCallback(err)
+	}
+}
+
+// WasStarted returns true if startOnce already ran, i.e. the transport was started. otherwise it makes startOnce do
+// nothing from now on, so a transport that is being stopped can't be started anymore, and returns false.
+func WasStarted(startOnce *sync.Once) bool {
+	started := true
+	startOnce.Do(func() { started = false })
+
+	return started
+}
+
+// CompareVersions compares versions numerically if both are numbers (e.g. bundle generations), otherwise
+// lexicographically. returns a negative number if a < b, zero if a == b and a positive number if a > b.
+func CompareVersions(a string, b string) int {
+	aNumber, aErr := strconv.ParseUint(a, 10, 64)
+	bNumber, bErr := strconv.ParseUint(b, 10, 64)
+
+	if aErr != nil || bErr != nil {
+		return strings.Compare(a, b)
+	}
+
+	switch {
+	case aNumber < bNumber:
+		return -1
+	case aNumber > bNumber:
+		return 1
+	default:
+		return 0
+	}
 }
@@ -0,0 +1,144 @@
+// Package transporttest provides a recording fake transport.Transport for the tests of the transports that wrap
+// another transport, e.g. the middlewares.
+package transporttest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/open-cluster-management/leaf-hub-status-sync/pkg/transport"
+)
+
+// Transport is a fake transport that records the messages that are sent using it. a Transport created by NewTransport
+// leaves the delivery results to the test, which reports them using the recorded messages. a Transport created by
+// NewDeliveringTransport delivers the messages in the background, from a queue like the one of the real transports.
+type Transport struct {
+	lock      sync.Mutex
+	sent      []*transport.Message
+	delivered []*transport.Message
+	versions  map[string]string // key is id.msgType
+	failures  map[string]error  // key is message id
+	queue     *transport.MessageQueue
+	stopChan  chan struct{}
+	commands  *transport.CommandDispatcher
+}
+
+// NewTransport creates a fake transport that records the sent messages and doesn't report their delivery results.
+func NewTransport() *Transport {
+	return &Transport{
+		versions: make(map[string]string),
+		failures: make(map[string]error),
+		commands: transport.NewCommandDispatcher(),
+	}
+}
+
+// NewDeliveringTransport creates a fake transport that pushes the sent messages to a message queue of the given
+// capacity and delivers them one by one in the background. a delivered message's version is returned by GetVersion.
+// the delivery stops when the test completes.
+func NewDeliveringTransport(t testing.TB, queueCapacity int) *Transport {
+	t.Helper()
+
+	fake := NewTransport()
+	fake.queue = transport.NewMessageQueue("transporttest", queueCapacity)
+	fake.stopChan = make(chan struct{})
+
+	go fake.deliver()
+
+	t.Cleanup(func() { close(fake.stopChan) })
+
+	return fake
+}
+
+// SendAsync records the message, and queues it if the transport delivers the messages.
+func (f *Transport) SendAsync(message *transport.Message) {
+	f.lock.Lock()
+	f.sent = append(f.sent, message)
+	f.lock.Unlock()
+
+	if f.queue != nil {
+		f.queue.Push(message)
+	}
+}
+
+// GetVersion returns the version that was set using SetVersion or the version of the last delivered message.
+func (f *Transport) GetVersion(id string, msgType string) string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.versions[versionKey(id, msgType)]
+}
+
+// Subscribe subscribes the handler to the commands of the given type that are dispatched using Dispatch.
+func (f *Transport) Subscribe(commandType string, handler transport.CommandHandler) {
+	f.commands.Subscribe(commandType, handler)
+}
+
+// Dispatch invokes the handlers that are subscribed to the command type, as if the command was received from the hub.
+func (f *Transport) Dispatch(command *transport.Command) error {
+	return f.commands.Dispatch(command)
+}
+
+// SetVersion sets the version GetVersion returns for the given id and type.
+func (f *Transport) SetVersion(id string, msgType string, version string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.versions[versionKey(id, msgType)] = version
+}
+
+// FailDelivery makes the delivery of the messages with the given id fail with err.
+func (f *Transport) FailDelivery(id string, err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.failures[id] = err
+}
+
+// Sent returns the messages that were sent using the transport, in order.
+func (f *Transport) Sent() []*transport.Message {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return append([]*transport.Message(nil), f.sent...)
+}
+
+// Delivered returns the messages that were delivered successfully, in order.
+func (f *Transport) Delivered() []*transport.Message {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return append([]*transport.Message(nil), f.delivered...)
+}
+
+// DroppedCount returns the number of messages that were dropped from the queue of a delivering transport.
+func (f *Transport) DroppedCount() uint64 {
+	if f.queue == nil {
+		return 0
+	}
+
+	return f.queue.DroppedCount()
+}
+
+func (f *Transport) deliver() {
+	for {
+		message := f.queue.Pop(f.stopChan)
+		if message == nil {
+			return
+		}
+
+		f.lock.Lock()
+		err := f.failures[message.ID]
+
+		if err == nil {
+			f.delivered = append(f.delivered, message)
+			f.versions[versionKey(message.ID, message.MsgType)] = message.Version
+		}
+		f.lock.Unlock()
+
+		message.ReportDeliveryResult(err)
+	}
+}
+
+func versionKey(id string, msgType string) string {
+	return id + "." + msgType
+}